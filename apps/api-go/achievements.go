@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"log"
+
+	"github.com/typing-code-learn/api-go/internal/database"
+	"github.com/typing-code-learn/api-go/internal/gamification"
+)
+
+const achievementsUsage = `Usage: api-server achievements <command>
+
+Commands:
+  backfill [-dry-run]   Award achievement badges earned by historical verified runs`
+
+// runAchievementsCommand implements the `achievements` CLI subcommand
+func runAchievementsCommand(db database.Store, achievements *gamification.Achievements, args []string) error {
+	if len(args) < 1 || args[0] != "backfill" {
+		return errors.New(achievementsUsage)
+	}
+
+	fs := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "only list the badges that would be awarded")
+	if err := fs.Parse(args[1:]); err != nil {
+		return errors.New(achievementsUsage)
+	}
+
+	userIDs, err := db.GetUserIDsWithMetrics()
+	if err != nil {
+		return err
+	}
+
+	awarded := 0
+	for _, userID := range userIDs {
+		pending, err := achievements.Pending(userID)
+		if err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			continue
+		}
+		if !*dryRun {
+			if _, err := achievements.Evaluate(userID); err != nil {
+				return err
+			}
+		}
+		for _, r := range pending {
+			log.Printf("User %s earned %q (%s)", userID, r.Badge.Name, r.ID)
+		}
+		awarded += len(pending)
+	}
+
+	if *dryRun {
+		log.Printf("Dry run: %d badges would be awarded to %d users with metrics", awarded, len(userIDs))
+	} else {
+		log.Printf("Awarded %d badges to %d users with metrics", awarded, len(userIDs))
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/typing-code-learn/api-go/internal/auth"
+	"github.com/typing-code-learn/api-go/internal/database"
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+const adminUsage = `Usage: api-server admin <command>
+
+Commands:
+  promote <username>           Grant the admin role to an existing user
+  demote <username>            Revoke the admin role from a user
+  create <username> [email]    Create a registered admin user (password from ADMIN_PASSWORD or stdin)`
+
+// runAdminCommand implements the `admin` CLI subcommand
+func runAdminCommand(db database.Store, authService *auth.Service, args []string) error {
+	if len(args) < 2 {
+		return errors.New(adminUsage)
+	}
+
+	username := args[1]
+	switch args[0] {
+	case "promote", "demote":
+		user, err := db.GetUserByUsername(username)
+		if err != nil {
+			return fmt.Errorf("user %q not found: %w", username, err)
+		}
+		role := models.RoleAdmin
+		if args[0] == "demote" {
+			role = models.RoleUser
+		}
+		if err := db.SetUserRole(user.ID, role); err != nil {
+			return err
+		}
+		log.Printf("User %s now has role %q", username, role)
+		return nil
+	case "create":
+		email := ""
+		if len(args) > 2 {
+			email = args[2]
+		}
+		password := os.Getenv("ADMIN_PASSWORD")
+		if password == "" {
+			fmt.Fprint(os.Stderr, "Password: ")
+			line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil && line == "" {
+				return fmt.Errorf("failed to read password: %w", err)
+			}
+			password = strings.TrimRight(line, "\r\n")
+		}
+		return bootstrapAdmin(db, authService, username, email, password)
+	default:
+		return errors.New(adminUsage)
+	}
+}
+
+// bootstrapAdmin makes sure the given account exists and holds the admin role.
+// Existing accounts are promoted as-is; missing ones are created with password.
+func bootstrapAdmin(db database.Store, authService *auth.Service, username, email, password string) error {
+	user, err := db.GetUserByUsername(username)
+	if err == sql.ErrNoRows {
+		if err := auth.ValidatePassword(password); err != nil {
+			return fmt.Errorf("cannot create admin %q: %w", username, err)
+		}
+		passwordHash, err := authService.HashPassword(password)
+		if err != nil {
+			return err
+		}
+		user, err = db.CreateRegisteredUser(username, email, passwordHash, username, "")
+		if err != nil {
+			return fmt.Errorf("cannot create admin %q: %w", username, err)
+		}
+		log.Printf("Created user %s", username)
+	} else if err != nil {
+		return err
+	}
+
+	if user.Role == models.RoleAdmin {
+		return nil
+	}
+	if err := db.SetUserRole(user.ID, models.RoleAdmin); err != nil {
+		return err
+	}
+	log.Printf("User %s promoted to admin", username)
+	return nil
+}
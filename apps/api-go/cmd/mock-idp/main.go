@@ -0,0 +1,193 @@
+// Command mock-idp is an identity provider for trying OAuth login locally
+// without registering an app at GitHub or an OpenID Connect provider.
+//
+// Usage:
+//
+//	mock-idp [-addr :9999] [-login octocat] [-id 583231] [-email octocat@example.com]
+//
+// It serves both the GitHub endpoints (/login/oauth/authorize,
+// /login/oauth/access_token, /api/v3/user) and OpenID Connect discovery at
+// /.well-known/openid-configuration. Every authorization request is approved
+// at once as the configured user; ?login= on the authorize URL picks another
+// one. Any client ID and secret are accepted, but PKCE is checked.
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// grant is an issued authorization code or access token
+type grant struct {
+	login       string
+	redirectURI string
+	challenge   string
+}
+
+type idp struct {
+	issuer string
+	id     int64
+	login  string
+	email  string
+
+	mu     sync.Mutex
+	codes  map[string]grant
+	tokens map[string]string // access token -> login
+}
+
+func main() {
+	addr := flag.String("addr", ":9999", "listen address")
+	issuer := flag.String("issuer", "", "public URL of this server (default http://localhost<addr>)")
+	login := flag.String("login", "octocat", "username of the default user")
+	id := flag.Int64("id", 583231, "numeric ID of the default user")
+	email := flag.String("email", "octocat@example.com", "verified email of the default user (empty for none)")
+	flag.Parse()
+
+	if *issuer == "" {
+		*issuer = "http://localhost" + *addr
+	}
+	p := &idp{
+		issuer: strings.TrimRight(*issuer, "/"),
+		id:     *id,
+		login:  *login,
+		email:  *email,
+		codes:  make(map[string]grant),
+		tokens: make(map[string]string),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/login/oauth/authorize", p.authorize)
+	mux.HandleFunc("/login/oauth/access_token", p.token)
+	mux.HandleFunc("/api/v3/user", p.user)
+	mux.HandleFunc("/userinfo", p.user)
+
+	log.Printf("Mock identity provider for %s on %s", p.login, p.issuer)
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
+
+func (p *idp) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 p.issuer,
+		"authorization_endpoint": p.issuer + "/login/oauth/authorize",
+		"token_endpoint":         p.issuer + "/login/oauth/access_token",
+		"userinfo_endpoint":      p.issuer + "/userinfo",
+	})
+}
+
+// authorize approves the request and redirects back with a code
+func (p *idp) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirectURI, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || redirectURI.Scheme == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		http.Error(w, "PKCE with S256 is required", http.StatusBadRequest)
+		return
+	}
+	login := q.Get("login")
+	if login == "" {
+		login = p.login
+	}
+
+	code := randomString()
+	p.mu.Lock()
+	p.codes[code] = grant{login: login, redirectURI: q.Get("redirect_uri"), challenge: q.Get("code_challenge")}
+	p.mu.Unlock()
+
+	back := redirectURI.Query()
+	back.Set("code", code)
+	back.Set("state", q.Get("state"))
+	redirectURI.RawQuery = back.Encode()
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+// token exchanges a code for an access token, reporting errors with a 200
+// status like GitHub does
+func (p *idp) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	p.mu.Lock()
+	g, ok := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	p.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	switch {
+	case !ok:
+		writeJSON(w, http.StatusOK, map[string]string{"error": "bad_verification_code"})
+		return
+	case g.redirectURI != r.PostForm.Get("redirect_uri"):
+		writeJSON(w, http.StatusOK, map[string]string{"error": "redirect_uri_mismatch"})
+		return
+	case base64.RawURLEncoding.EncodeToString(sum[:]) != g.challenge:
+		writeJSON(w, http.StatusOK, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+
+	token := randomString()
+	p.mu.Lock()
+	p.tokens[token] = g.login
+	p.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]string{"access_token": token, "token_type": "bearer"})
+}
+
+// user answers both as GitHub's /user and as an OpenID Connect userinfo
+// endpoint
+func (p *idp) user(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	p.mu.Lock()
+	login, ok := p.tokens[token]
+	p.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "Bad credentials"})
+		return
+	}
+
+	id, email := p.id, p.email
+	if login != p.login {
+		// Other users get a stable ID and address derived from their name
+		sum := sha256.Sum256([]byte(login))
+		id = int64(sum[0])<<16 | int64(sum[1])<<8 | int64(sum[2])
+		email = login + "@example.com"
+	}
+	info := map[string]interface{}{
+		"id":                 id,
+		"login":              login,
+		"sub":                strconv.FormatInt(id, 10),
+		"preferred_username": login,
+	}
+	if email != "" {
+		info["email"] = email
+		info["email_verified"] = true
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+func randomString() string {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
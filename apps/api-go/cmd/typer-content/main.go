@@ -0,0 +1,184 @@
+// Command typer-content provides tooling for lesson authors.
+//
+// Usage:
+//
+//	typer-content lint [-schema path] [-json] [content-dir]
+//	typer-content check-engine [fixtures.json]
+//
+// lint validates a content directory without starting the API or touching a
+// database and exits with status 1 when any issue is found.
+//
+// check-engine runs the golden fixtures shared with the web client's typing
+// engine through the Go engine and exits with status 1 on any mismatch.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/typing-code-learn/api-go/internal/engine"
+	"github.com/typing-code-learn/api-go/internal/lessons"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "lint":
+		os.Exit(runLint(os.Args[2:]))
+	case "check-engine":
+		os.Exit(runCheckEngine(os.Args[2:]))
+	case "-h", "--help", "help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: typer-content <command> [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Commands:")
+	fmt.Fprintln(os.Stderr, "  lint          Check lesson content for schema and authoring issues")
+	fmt.Fprintln(os.Stderr, "  check-engine  Run the shared typing engine fixtures against the Go engine")
+}
+
+func runLint(args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	schemaPath := fs.String("schema", getEnv("LESSON_SCHEMA", "../../packages/lesson-schema/lesson.schema.json"), "path to lesson.schema.json (empty to skip schema checks)")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: typer-content lint [-schema path] [-json] [content-dir]")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	contentDir := getEnv("CONTENT_DIR", "../../content")
+	if fs.NArg() > 0 {
+		contentDir = fs.Arg(0)
+	}
+
+	var schema *lessons.Schema
+	if *schemaPath != "" {
+		s, err := lessons.LoadSchema(*schemaPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 2
+		}
+		schema = s
+	}
+
+	report, err := lessons.Lint(contentDir, schema)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+
+	if *asJSON {
+		if report.Issues == nil {
+			report.Issues = []lessons.Issue{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(report)
+	} else {
+		printReport(report, contentDir)
+	}
+
+	if len(report.Issues) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func runCheckEngine(args []string) int {
+	fs := flag.NewFlagSet("check-engine", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: typer-content check-engine [fixtures.json]")
+	}
+	_ = fs.Parse(args)
+
+	path := "../../packages/engine-fixtures/fixtures.json"
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+
+	fixtures, err := engine.LoadFixtures(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+
+	failed := 0
+	for _, f := range fixtures {
+		problems := f.Check()
+		if len(problems) == 0 {
+			fmt.Printf("  ✔ %s\n", f.Name)
+			continue
+		}
+		failed++
+		fmt.Printf("  ✘ %s\n", f.Name)
+		for _, p := range problems {
+			fmt.Printf("      %s\n", p)
+		}
+	}
+
+	fmt.Printf("\n%d/%d fixture(s) passed\n", len(fixtures)-failed, len(fixtures))
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// printReport writes issues grouped by file, with paths relative to contentDir
+func printReport(report *lessons.Report, contentDir string) {
+	if len(report.Issues) == 0 {
+		fmt.Println("✔ No issues found")
+		return
+	}
+
+	byPath := make(map[string][]lessons.Issue)
+	var paths []string
+	for _, issue := range report.Issues {
+		if _, ok := byPath[issue.Path]; !ok {
+			paths = append(paths, issue.Path)
+		}
+		byPath[issue.Path] = append(byPath[issue.Path], issue)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		display := path
+		if rel, err := filepath.Rel(contentDir, path); err == nil {
+			display = rel
+		}
+		issues := byPath[path]
+		if issues[0].LessonID != "" {
+			fmt.Printf("%s (%s)\n", display, issues[0].LessonID)
+		} else {
+			fmt.Println(display)
+		}
+		for _, issue := range issues {
+			fmt.Printf("  ✘ [%s] %s\n", issue.Rule, issue.Message)
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("%d issue(s) in %d file(s)\n", len(report.Issues), len(paths))
+}
+
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
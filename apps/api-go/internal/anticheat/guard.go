@@ -0,0 +1,177 @@
+// Package anticheat checks submitted typing metrics for numbers a human
+// typist could not have produced.
+package anticheat
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+// Flag reasons
+const (
+	ReasonWPMLimit         = "wpm_limit"
+	ReasonTimeMismatch     = "time_mismatch"
+	ReasonCharMismatch     = "char_mismatch"
+	ReasonAccuracyMismatch = "accuracy_mismatch"
+	ReasonDuplicateBurst   = "duplicate_burst"
+)
+
+// Guard holds the thresholds used to judge submitted metrics
+type Guard struct {
+	// BurstWPM applies to lessons of at most BurstChars characters, where
+	// short sprints are plausible; SustainedWPM applies to longer lessons
+	BurstWPM     float64
+	BurstChars   int
+	SustainedWPM float64
+
+	// MinKeyInterval is the fastest plausible average time between keys
+	MinKeyInterval time.Duration
+
+	// BurstLimit identical submissions within BurstWindow are flagged
+	BurstLimit  int
+	BurstWindow time.Duration
+}
+
+// NewGuard creates a guard with sensible defaults
+func NewGuard() *Guard {
+	return &Guard{
+		BurstWPM:       250,
+		BurstChars:     60,
+		SustainedWPM:   180,
+		MinKeyInterval: 25 * time.Millisecond,
+		BurstLimit:     3,
+		BurstWindow:    10 * time.Minute,
+	}
+}
+
+// Verdict is the outcome of a check. Rejected submissions are impossible and
+// must not be stored; flagged ones are stored but held for review.
+type Verdict struct {
+	Reject  bool
+	Reasons []string
+}
+
+// Flagged reports whether any suspicious pattern was found
+func (v Verdict) Flagged() bool {
+	return len(v.Reasons) > 0
+}
+
+// String joins the reasons into a single flag reason
+func (v Verdict) String() string {
+	return strings.Join(v.Reasons, "; ")
+}
+
+// MaxWPM returns the highest plausible WPM for a lesson of the given length
+func (g *Guard) MaxWPM(chars int) float64 {
+	if chars <= g.BurstChars {
+		return g.BurstWPM
+	}
+	return g.SustainedWPM
+}
+
+// Check judges a metrics submission. lessonChars is the number of characters
+// the lesson requires (0 if unknown) and recent holds the user's metrics
+// submitted within the burst window.
+func (g *Guard) Check(req models.MetricsRequest, lessonChars int, recent []models.TypingMetrics) Verdict {
+	var v Verdict
+
+	if invalidMetrics(req) {
+		v.Reject = true
+		v.Reasons = append(v.Reasons, "metrics contain negative or out of range values")
+		return v
+	}
+
+	flag := func(reason, format string, args ...interface{}) {
+		v.Reasons = append(v.Reasons, reason+": "+fmt.Sprintf(format, args...))
+	}
+
+	chars := req.TotalChars
+	if lessonChars > 0 {
+		chars = lessonChars
+	}
+	if max := g.MaxWPM(chars); req.WPM > max {
+		flag(ReasonWPMLimit, "%.1f WPM exceeds %.0f for %d chars", req.WPM, max, chars)
+	}
+
+	// totalTime must account for the keys typed and the reported WPM
+	keys := req.CorrectChars + req.IncorrectChars
+	if minTime := time.Duration(keys) * g.MinKeyInterval; keys > 1 && secondsToDuration(req.TotalTime) < minTime {
+		flag(ReasonTimeMismatch, "%d keys in %.1fs", keys, req.TotalTime)
+	} else if !wpmMatchesTime(req) {
+		flag(ReasonTimeMismatch, "%.1f WPM does not match %d chars in %.1fs", req.WPM, req.CorrectChars, req.TotalTime)
+	}
+
+	if lessonChars > 0 && req.TotalChars != lessonChars {
+		flag(ReasonCharMismatch, "totalChars %d, lesson has %d", req.TotalChars, lessonChars)
+	} else if req.CorrectChars+req.IncorrectChars < req.TotalChars {
+		flag(ReasonCharMismatch, "correct %d + incorrect %d do not cover %d chars", req.CorrectChars, req.IncorrectChars, req.TotalChars)
+	}
+
+	if !accuracyMatches(req) {
+		flag(ReasonAccuracyMismatch, "accuracy %.1f does not match %d correct and %d incorrect", req.Accuracy, req.CorrectChars, req.IncorrectChars)
+	}
+
+	if n := identicalSubmissions(req, recent); n+1 >= g.BurstLimit {
+		flag(ReasonDuplicateBurst, "%d identical submissions within %s", n+1, g.BurstWindow)
+	}
+
+	return v
+}
+
+// invalidMetrics reports values no client could legitimately send
+func invalidMetrics(req models.MetricsRequest) bool {
+	for _, f := range []float64{req.WPM, req.Accuracy, req.TotalTime} {
+		if math.IsNaN(f) || math.IsInf(f, 0) || f < 0 {
+			return true
+		}
+	}
+	return req.Accuracy > 100 ||
+		req.TotalChars < 0 || req.CorrectChars < 0 || req.IncorrectChars < 0 ||
+		req.CorrectChars > req.TotalChars
+}
+
+// wpmMatchesTime checks WPM against correct chars and time, allowing for the
+// one-decimal rounding the clients apply to both WPM and totalTime
+func wpmMatchesTime(req models.MetricsRequest) bool {
+	if req.TotalTime <= 0 {
+		return req.WPM == 0
+	}
+	words := float64(req.CorrectChars) / 5
+	lowest := words/((req.TotalTime+0.05)/60) - 0.05
+	highest := math.Inf(1)
+	if req.TotalTime > 0.05 {
+		highest = words/((req.TotalTime-0.05)/60) + 0.05
+	}
+	return req.WPM >= lowest && req.WPM <= highest
+}
+
+// accuracyMatches checks accuracy against correct and incorrect key counts
+func accuracyMatches(req models.MetricsRequest) bool {
+	keys := req.CorrectChars + req.IncorrectChars
+	expected := 100.0
+	if keys > 0 {
+		expected = float64(req.CorrectChars) / float64(keys) * 100
+	}
+	return math.Abs(req.Accuracy-expected) <= 0.051
+}
+
+// identicalSubmissions counts recent metrics with exactly the same numbers
+func identicalSubmissions(req models.MetricsRequest, recent []models.TypingMetrics) int {
+	n := 0
+	for _, m := range recent {
+		if m.LessonID == req.LessonID && m.WPM == req.WPM && m.Accuracy == req.Accuracy &&
+			m.TotalTime == req.TotalTime && m.TotalChars == req.TotalChars &&
+			m.CorrectChars == req.CorrectChars && m.IncorrectChars == req.IncorrectChars {
+			n++
+		}
+	}
+	return n
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
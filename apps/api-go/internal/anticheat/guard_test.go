@@ -0,0 +1,142 @@
+package anticheat
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+// clean is a plausible run of a 150-character lesson: 30 words in 10 seconds
+func clean() models.MetricsRequest {
+	return models.MetricsRequest{
+		LessonID: "go-basics-01", WPM: 180, Accuracy: 100,
+		TotalTime: 10, TotalChars: 150, CorrectChars: 150,
+	}
+}
+
+// reasons returns the codes of a verdict's reasons, without their details
+func reasons(v Verdict) []string {
+	var codes []string
+	for _, r := range v.Reasons {
+		code, _, _ := strings.Cut(r, ":")
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name        string
+		change      func(req *models.MetricsRequest)
+		lessonChars int
+		reject      bool
+		reasons     []string
+	}{
+		{"clean run at the sustained limit", func(req *models.MetricsRequest) {}, 150, false, nil},
+		{"lesson length unknown", func(req *models.MetricsRequest) {}, 0, false, nil},
+
+		// Impossible values are rejected outright
+		{"negative WPM", func(req *models.MetricsRequest) { req.WPM = -1 }, 150, true, nil},
+		{"accuracy above 100", func(req *models.MetricsRequest) { req.Accuracy = 100.1 }, 150, true, nil},
+		{"NaN time", func(req *models.MetricsRequest) { req.TotalTime = math.NaN() }, 150, true, nil},
+		{"more correct than total chars", func(req *models.MetricsRequest) { req.CorrectChars = 151 }, 150, true, nil},
+		{"negative incorrect chars", func(req *models.MetricsRequest) { req.IncorrectChars = -1 }, 150, true, nil},
+
+		// WPM limits: sustained for long lessons, burst for short ones
+		{"just over the sustained limit", func(req *models.MetricsRequest) { req.WPM = 180.1 }, 150, false, []string{ReasonWPMLimit}},
+		{"at the burst limit", func(req *models.MetricsRequest) {
+			*req = models.MetricsRequest{WPM: 250, Accuracy: 100, TotalTime: 2.88, TotalChars: 60, CorrectChars: 60}
+		}, 60, false, nil},
+		{"just over the burst limit", func(req *models.MetricsRequest) {
+			*req = models.MetricsRequest{WPM: 250.1, Accuracy: 100, TotalTime: 2.88, TotalChars: 60, CorrectChars: 60}
+		}, 60, false, []string{ReasonWPMLimit}},
+		{"burst speed on a longer lesson", func(req *models.MetricsRequest) {
+			*req = models.MetricsRequest{WPM: 250, Accuracy: 100, TotalTime: 2.928, TotalChars: 61, CorrectChars: 61}
+		}, 61, false, []string{ReasonWPMLimit}},
+
+		// Time must cover the keys and match the WPM
+		{"fastest plausible keys", func(req *models.MetricsRequest) {
+			// 100 keys, mostly wrong, at 25ms each
+			*req = models.MetricsRequest{WPM: 48, Accuracy: 10, TotalTime: 2.5, TotalChars: 10, CorrectChars: 10, IncorrectChars: 90}
+		}, 10, false, nil},
+		{"keys faster than plausible", func(req *models.MetricsRequest) {
+			*req = models.MetricsRequest{WPM: 50, Accuracy: 10, TotalTime: 2.4, TotalChars: 10, CorrectChars: 10, IncorrectChars: 90}
+		}, 10, false, []string{ReasonTimeMismatch}},
+		{"WPM within rounding of the time", func(req *models.MetricsRequest) { req.WPM = 180.9 }, 0, false, []string{ReasonWPMLimit}},
+		{"WPM that doesn't match the time", func(req *models.MetricsRequest) { req.WPM = 120 }, 150, false, []string{ReasonTimeMismatch}},
+		{"WPM without any time", func(req *models.MetricsRequest) { req.TotalTime = 0; req.TotalChars, req.CorrectChars = 1, 1 }, 0, false, []string{ReasonTimeMismatch}},
+
+		// Characters must add up
+		{"wrong lesson length", func(req *models.MetricsRequest) {}, 200, false, []string{ReasonCharMismatch}},
+		{"keys don't cover the lesson", func(req *models.MetricsRequest) {
+			req.TotalChars = 151
+		}, 0, false, []string{ReasonCharMismatch}},
+
+		// Accuracy must match the keys, to the client's rounding
+		{"rounded accuracy", func(req *models.MetricsRequest) {
+			req.IncorrectChars = 5
+			req.Accuracy = 96.8 // 150 of 155
+		}, 150, false, nil},
+		{"inflated accuracy", func(req *models.MetricsRequest) {
+			req.IncorrectChars = 5
+			req.Accuracy = 97
+		}, 150, false, []string{ReasonAccuracyMismatch}},
+	}
+
+	g := NewGuard()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := clean()
+			tt.change(&req)
+			v := g.Check(req, tt.lessonChars, nil)
+			if v.Reject != tt.reject {
+				t.Errorf("got reject %v, want %v (%s)", v.Reject, tt.reject, v)
+			}
+			if tt.reject {
+				return
+			}
+			if got := reasons(v); !reflect.DeepEqual(got, tt.reasons) {
+				t.Errorf("got reasons %v, want %v", got, tt.reasons)
+			}
+			if v.Flagged() != (len(tt.reasons) > 0) {
+				t.Errorf("Flagged() = %v with reasons %v", v.Flagged(), v.Reasons)
+			}
+		})
+	}
+}
+
+func TestCheckDuplicateBurst(t *testing.T) {
+	g := NewGuard()
+	req := clean()
+	same := models.TypingMetrics{
+		LessonID: req.LessonID, WPM: req.WPM, Accuracy: req.Accuracy, TotalTime: req.TotalTime,
+		TotalChars: req.TotalChars, CorrectChars: req.CorrectChars,
+	}
+	other := same
+	other.LessonID = "go-basics-02"
+
+	tests := []struct {
+		name    string
+		recent  []models.TypingMetrics
+		flagged bool
+	}{
+		{"no recent runs", nil, false},
+		{"one identical run", []models.TypingMetrics{same}, false},
+		{"identical runs on other lessons", []models.TypingMetrics{same, other, other}, false},
+		{"the burst limit", []models.TypingMetrics{same, same}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := g.Check(req, 150, tt.recent)
+			if v.Reject {
+				t.Fatalf("rejected: %s", v)
+			}
+			if flagged := reflect.DeepEqual(reasons(v), []string{ReasonDuplicateBurst}); flagged != tt.flagged {
+				t.Errorf("got reasons %v, want flagged: %v", v.Reasons, tt.flagged)
+			}
+		})
+	}
+}
@@ -3,29 +3,68 @@ package auth
 import (
 	"errors"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/typing-code-learn/api-go/internal/models"
 	"golang.org/x/crypto/bcrypt"
 )
 
 const (
-	// TokenDuration is the lifetime of a JWT token
-	TokenDuration = 24 * time.Hour
+	// AccessTokenDuration is the lifetime of a JWT access token
+	AccessTokenDuration = 15 * time.Minute
+	// RefreshTokenDuration is how long a login session can go unused before
+	// its refresh token expires
+	RefreshTokenDuration = 30 * 24 * time.Hour
 	// MinSecretLength is a minimal recommended size for HMAC secrets.
 	MinSecretLength = 32
+	// TypingSessionDuration is how long a typing session can stay open
+	TypingSessionDuration = 2 * time.Hour
+	// MFAChallengeDuration is how long a user has to enter their second
+	// factor after giving the right password
+	MFAChallengeDuration = 5 * time.Minute
+	// OAuthStateDuration is how long a user has to finish logging in at an
+	// identity provider
+	OAuthStateDuration = 10 * time.Minute
+)
+
+// typingSessionIssuer and mfaChallengeIssuer differ from the auth token
+// issuer so these tokens are never accepted as credentials
+const (
+	typingSessionIssuer = "typer-api/typing-session"
+	mfaChallengeIssuer  = "typer-api/mfa-challenge"
+	oauthStateIssuer    = "typer-api/oauth-state"
 )
 
 var (
 	ErrInvalidToken       = errors.New("invalid token")
+	ErrRevokedToken       = errors.New("token has been revoked")
 	ErrInvalidCredentials = errors.New("invalid credentials")
 )
 
+// Denylist reports access tokens that were revoked before they expired
+type Denylist interface {
+	IsTokenRevoked(jti string) (bool, error)
+}
+
+// SessionTracker records where and when login sessions are used
+type SessionTracker interface {
+	TouchAuthSession(id, userAgent, ipAddress string, at time.Time) error
+}
+
 // Service handles authentication operations
 type Service struct {
-	secretKey []byte
+	keys     *Keyring
+	denylist Denylist
+
+	tracker     SessionTracker
+	trackMu     sync.Mutex
+	lastTouched map[string]time.Time // session or token ID -> last recorded use
+
+	personalTokens PersonalTokenStore
 }
 
 // NewService creates a new auth service with the given secret
@@ -35,11 +74,33 @@ func NewService(secret string) *Service {
 		// for security reasons, but we'll keep a fallback for dev convenience.
 		secret = "dev-secret-key-change-in-production-at-least-32-chars-long"
 	}
+	return NewServiceWithKeyring(NewHMACKeyring(secret))
+}
+
+// NewServiceWithKeyring creates a new auth service that signs and verifies
+// tokens with the given keyring
+func NewServiceWithKeyring(keys *Keyring) *Service {
 	return &Service{
-		secretKey: []byte(secret),
+		keys:        keys,
+		lastTouched: make(map[string]time.Time),
 	}
 }
 
+// JWKS returns the public verification keys
+func (s *Service) JWKS() JWKSet {
+	return s.keys.JWKS()
+}
+
+// SetDenylist makes ValidateToken reject access tokens revoked in d
+func (s *Service) SetDenylist(d Denylist) {
+	s.denylist = d
+}
+
+// SetSessionTracker makes AuthMiddleware record the use of login sessions in t
+func (s *Service) SetSessionTracker(t SessionTracker) {
+	s.tracker = t
+}
+
 func IsStrongEnoughSecret(secret string) bool {
 	return len(secret) >= MinSecretLength
 }
@@ -49,18 +110,26 @@ type Claims struct {
 	UserID   string `json:"userId"`
 	Username string `json:"username"`
 	IsGuest  bool   `json:"isGuest"`
+	Role     string `json:"role,omitempty"`
+	// SessionID is the login session the token was issued for
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken creates a JWT token for a user
-func (s *Service) GenerateToken(user models.User) (string, error) {
-	expirationTime := time.Now().Add(TokenDuration)
+// GenerateToken creates a short-lived JWT access token for a user's login
+// session and returns it together with its jti
+func (s *Service) GenerateToken(user models.User, sessionID string) (string, string, error) {
+	expirationTime := time.Now().Add(AccessTokenDuration)
+	jti := uuid.New().String()
 
 	claims := &Claims{
-		UserID:   user.ID,
-		Username: user.Username,
-		IsGuest:  user.IsGuest,
+		UserID:    user.ID,
+		Username:  user.Username,
+		IsGuest:   user.IsGuest,
+		Role:      user.Role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
@@ -68,33 +137,142 @@ func (s *Service) GenerateToken(user models.User) (string, error) {
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.secretKey)
+	token, err := s.keys.sign(claims)
+	if err != nil {
+		return "", "", err
+	}
+	return token, jti, nil
 }
 
 // ValidateToken validates a JWT token and returns the claims
 func (s *Service) ValidateToken(tokenString string) (*Claims, error) {
 	claims := &Claims{}
+	if err := s.parse(tokenString, claims, "typer-api"); err != nil {
+		return nil, err
+	}
 
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		// Validate the algorithm used for signing
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, ErrInvalidToken
+	// Tokens issued before login sessions existed carry no jti and simply
+	// run out on their own
+	if s.denylist != nil && claims.ID != "" {
+		revoked, err := s.denylist.IsTokenRevoked(claims.ID)
+		if err != nil {
+			return nil, err
 		}
-		return s.secretKey, nil
-	}, jwt.WithIssuer("typer-api"))
+		if revoked {
+			return nil, ErrRevokedToken
+		}
+	}
 
-	if err != nil {
+	return claims, nil
+}
+
+// TypingSessionClaims binds a typing session to a user, a lesson and the
+// time the server started it. The session ID is carried in the jti claim.
+type TypingSessionClaims struct {
+	UserID   string `json:"uid"`
+	LessonID string `json:"lessonId"`
+	jwt.RegisteredClaims
+}
+
+// GenerateTypingSessionToken signs a token for a typing session
+func (s *Service) GenerateTypingSessionToken(session models.TypingSession) (string, error) {
+	claims := &TypingSessionClaims{
+		UserID:   session.UserID,
+		LessonID: session.LessonID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        session.ID,
+			ExpiresAt: jwt.NewNumericDate(session.StartedAt.Add(TypingSessionDuration)),
+			IssuedAt:  jwt.NewNumericDate(session.StartedAt),
+			Issuer:    typingSessionIssuer,
+		},
+	}
+
+	return s.keys.sign(claims)
+}
+
+// ValidateTypingSessionToken validates a typing session token and returns its claims
+func (s *Service) ValidateTypingSessionToken(tokenString string) (*TypingSessionClaims, error) {
+	claims := &TypingSessionClaims{}
+	if err := s.parse(tokenString, claims, typingSessionIssuer); err != nil {
 		return nil, err
 	}
+	return claims, nil
+}
 
-	if !token.Valid {
-		return nil, ErrInvalidToken
+// GenerateMFAToken signs a challenge token proving that a user gave the right
+// password and still has to pass their second factor
+func (s *Service) GenerateMFAToken(userID string) (string, error) {
+	now := time.Now()
+	claims := &jwt.RegisteredClaims{
+		ID:        uuid.New().String(),
+		Subject:   userID,
+		ExpiresAt: jwt.NewNumericDate(now.Add(MFAChallengeDuration)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		Issuer:    mfaChallengeIssuer,
 	}
+	return s.keys.sign(claims)
+}
 
+// ValidateMFAToken validates a challenge token and returns the user it was
+// issued to
+func (s *Service) ValidateMFAToken(tokenString string) (string, error) {
+	claims := &jwt.RegisteredClaims{}
+	if err := s.parse(tokenString, claims, mfaChallengeIssuer); err != nil {
+		return "", err
+	}
+	if claims.Subject == "" {
+		return "", ErrInvalidToken
+	}
+	return claims.Subject, nil
+}
+
+// OAuthStateClaims carry a login at an identity provider across the browser
+// round trip: the state and PKCE verifier to check the callback with, and
+// what to do with the identity
+type OAuthStateClaims struct {
+	Provider string `json:"provider"`
+	State    string `json:"state"`
+	Verifier string `json:"verifier"`
+	// UserID is the account to link the identity to, or the guest account
+	// to convert when Link is false
+	UserID   string `json:"uid,omitempty"`
+	Link     bool   `json:"link,omitempty"`
+	Redirect string `json:"redirect,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// GenerateOAuthState signs OAuth state claims for a short-lived cookie
+func (s *Service) GenerateOAuthState(claims OAuthStateClaims) (string, error) {
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(now.Add(OAuthStateDuration)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		Issuer:    oauthStateIssuer,
+	}
+	return s.keys.sign(&claims)
+}
+
+// ValidateOAuthState validates an OAuth state cookie and returns its claims
+func (s *Service) ValidateOAuthState(tokenString string) (*OAuthStateClaims, error) {
+	claims := &OAuthStateClaims{}
+	if err := s.parse(tokenString, claims, oauthStateIssuer); err != nil {
+		return nil, err
+	}
 	return claims, nil
 }
 
+// parse verifies a token against the keyring and decodes its claims
+func (s *Service) parse(tokenString string, claims jwt.Claims, issuer string) error {
+	token, err := jwt.ParseWithClaims(tokenString, claims, s.keys.keyFunc, jwt.WithIssuer(issuer))
+	if err != nil {
+		return err
+	}
+	if !token.Valid {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
 // HashPassword hashes a password using bcrypt
 func (s *Service) HashPassword(password string) (string, error) {
 	if password == "" {
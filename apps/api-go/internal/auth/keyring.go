@@ -0,0 +1,295 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSABits is the smallest RSA modulus accepted for signing keys
+const minRSABits = 2048
+
+// Key is a JWT key identified by its kid. Verify-only keys have no private half.
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	private interface{} // []byte, *rsa.PrivateKey or ed25519.PrivateKey
+	public  interface{} // []byte, *rsa.PublicKey or ed25519.PublicKey
+}
+
+// CanSign reports whether the key holds private material
+func (k *Key) CanSign() bool {
+	return k.private != nil
+}
+
+// Keyring holds every key tokens are verified with and the one key new tokens
+// are signed with, so keys can be rotated without logging everyone out
+type Keyring struct {
+	keys    map[string]*Key
+	signing *Key
+	// legacy verifies tokens issued before they carried a kid
+	legacy *Key
+}
+
+// KeyringConfig describes where keys come from
+type KeyringConfig struct {
+	// Dir holds one key per file, named after its kid: "<kid>.pem" for RSA or
+	// Ed25519 keys (PKCS#8/PKCS#1 private keys, or PKIX public keys for
+	// verification only) and "<kid>.secret" for HMAC secrets
+	Dir string
+	// SigningKeyID selects the signing key; it defaults to Secret's key, or to
+	// the only private key in Dir
+	SigningKeyID string
+	// Secret is the HMAC secret from JWT_SECRET
+	Secret string
+	// PreviousSecrets are retired HMAC secrets still accepted for verification
+	PreviousSecrets []string
+}
+
+// NewHMACKeyring creates a keyring holding a single HMAC secret
+func NewHMACKeyring(secret string) *Keyring {
+	key := hmacKey(secret)
+	return &Keyring{keys: map[string]*Key{key.ID: key}, signing: key, legacy: key}
+}
+
+// LoadKeyring builds a keyring from a key directory and HMAC secrets
+func LoadKeyring(cfg KeyringConfig) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]*Key)}
+
+	if cfg.Dir != "" {
+		if err := k.loadDir(cfg.Dir); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Secret != "" {
+		key := hmacKey(cfg.Secret)
+		if err := k.add(key); err != nil {
+			return nil, err
+		}
+		k.legacy = key
+	}
+	for _, secret := range cfg.PreviousSecrets {
+		if secret = strings.TrimSpace(secret); secret == "" {
+			continue
+		}
+		key := hmacKey(secret)
+		key.private = nil
+		if err := k.add(key); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case cfg.SigningKeyID != "":
+		key, ok := k.keys[cfg.SigningKeyID]
+		if !ok {
+			return nil, fmt.Errorf("signing key %q not found", cfg.SigningKeyID)
+		}
+		if !key.CanSign() {
+			return nil, fmt.Errorf("signing key %q has no private key", cfg.SigningKeyID)
+		}
+		k.signing = key
+	case k.legacy != nil:
+		k.signing = k.legacy
+	default:
+		for _, key := range k.keys {
+			if !key.CanSign() {
+				continue
+			}
+			if k.signing != nil {
+				return nil, errors.New("several signing keys found; set the signing key ID")
+			}
+			k.signing = key
+		}
+	}
+	if k.signing == nil {
+		return nil, errors.New("no signing key configured")
+	}
+	return k, nil
+}
+
+// SigningKey returns the key new tokens are signed with
+func (k *Keyring) SigningKey() *Key {
+	return k.signing
+}
+
+// KeyIDs returns the kids of all keys, sorted
+func (k *Keyring) KeyIDs() []string {
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (k *Keyring) add(key *Key) error {
+	if _, exists := k.keys[key.ID]; exists {
+		return fmt.Errorf("duplicate key ID %q", key.ID)
+	}
+	k.keys[key.ID] = key
+	return nil
+}
+
+func (k *Keyring) loadDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read key directory: %w", err)
+	}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".pem" && ext != ".secret") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		id := strings.TrimSuffix(entry.Name(), ext)
+		var key *Key
+		if ext == ".secret" {
+			secret := strings.TrimSpace(string(data))
+			if !IsStrongEnoughSecret(secret) {
+				return fmt.Errorf("%s: HMAC secret must be at least %d characters", path, MinSecretLength)
+			}
+			key = &Key{ID: id, Method: jwt.SigningMethodHS256, private: []byte(secret), public: []byte(secret)}
+		} else if key, err = parsePEMKey(id, data); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if err := k.add(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hmacKey creates an HS256 key whose kid is derived from the secret, so it
+// stays stable across restarts without revealing the secret
+func hmacKey(secret string) *Key {
+	sum := sha256.Sum256([]byte(secret))
+	return &Key{
+		ID:      "hs-" + hex.EncodeToString(sum[:4]),
+		Method:  jwt.SigningMethodHS256,
+		private: []byte(secret),
+		public:  []byte(secret),
+	}
+}
+
+func parsePEMKey(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		if key.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("RSA key must be at least %d bits", minRSABits)
+		}
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, private: key, public: &key.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, public: key}, nil
+	case ed25519.PrivateKey:
+		return &Key{ID: id, Method: jwt.SigningMethodEdDSA, private: key, public: key.Public()}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: id, Method: jwt.SigningMethodEdDSA, public: key}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+}
+
+// sign signs claims with the signing key and sets its kid header
+func (k *Keyring) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.signing.Method, claims)
+	token.Header["kid"] = k.signing.ID
+	return token.SignedString(k.signing.private)
+}
+
+// keyFunc picks the verification key named by a token's kid and rejects
+// tokens whose algorithm does not match that key
+func (k *Keyring) keyFunc(token *jwt.Token) (interface{}, error) {
+	key := k.legacy
+	if kid, ok := token.Header["kid"].(string); ok {
+		key = k.keys[kid]
+	}
+	if key == nil || token.Method.Alg() != key.Method.Alg() {
+		return nil, ErrInvalidToken
+	}
+	return key.public, nil
+}
+
+// JWK is a public key in JSON Web Key format
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet is the document served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the keyring. HMAC secrets are never
+// published, so tokens signed with them can only be verified by this API.
+func (k *Keyring) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, id := range k.KeyIDs() {
+		key := k.keys[id]
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA", Kid: id, Use: "sig", Alg: key.Method.Alg(),
+				N: b64(pub.N.Bytes()),
+				E: b64(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "OKP", Kid: id, Use: "sig", Alg: key.Method.Alg(),
+				Crv: "Ed25519", X: b64(pub),
+			})
+		}
+	}
+	return set
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,266 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testSecret     = "test-secret-key-that-is-at-least-32-chars"
+	previousSecret = "previous-secret-key-at-least-32-chars-long"
+)
+
+// writePEM writes a key in PKCS#8 (private) or PKIX (public) form to
+// dir/<kid>.pem
+func writePEM(t *testing.T, dir, kid string, key interface{}) {
+	t.Helper()
+	var block *pem.Block
+	switch key.(type) {
+	case *rsa.PrivateKey, ed25519.PrivateKey:
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	default:
+		der, err := x509.MarshalPKIXPublicKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	}
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, minRSABits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newEd25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// verify parses a token with the keyring the way the auth service does
+func verify(k *Keyring, token string) error {
+	_, err := jwt.Parse(token, k.keyFunc)
+	return err
+}
+
+func signWith(t *testing.T, k *Keyring) string {
+	t.Helper()
+	token, err := k.sign(jwt.MapClaims{"sub": "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestKeyringRejectsAlgorithmMismatch(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey := newRSAKey(t)
+	writePEM(t, dir, "rsa-1", rsaKey)
+	k, err := LoadKeyring(KeyringConfig{Dir: dir, Secret: testSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An HS256 token naming the RSA key, signed with its public key as the
+	// HMAC secret
+	publicPEM, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "u1"})
+	forged.Header["kid"] = "rsa-1"
+	token, err := forged.SignedString(publicPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(k, token); err == nil {
+		t.Error("HS256 token with an RS256 kid was accepted")
+	}
+
+	// A kid the keyring doesn't have
+	unknown := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "u1"})
+	unknown.Header["kid"] = "hs-unknown"
+	if token, err = unknown.SignedString([]byte(testSecret)); err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(k, token); err == nil {
+		t.Error("token with an unknown kid was accepted")
+	}
+
+	// Tokens from before kids verify against the secret
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "u1"}).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(k, legacy); err != nil {
+		t.Errorf("token without a kid was rejected: %v", err)
+	}
+}
+
+func TestKeyringPreviousSecrets(t *testing.T) {
+	old, err := LoadKeyring(KeyringConfig{Secret: previousSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldToken := signWith(t, old)
+
+	rotated, err := LoadKeyring(KeyringConfig{Secret: testSecret, PreviousSecrets: []string{previousSecret, " "}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(rotated, oldToken); err != nil {
+		t.Errorf("token signed with the previous secret was rejected: %v", err)
+	}
+	if rotated.SigningKey().ID != hmacKey(testSecret).ID {
+		t.Errorf("signing with %s, want the current secret", rotated.SigningKey().ID)
+	}
+	if err := verify(old, signWith(t, rotated)); err == nil {
+		t.Error("token signed with the new secret verified against the old keyring alone")
+	}
+
+	// Retired secrets only verify
+	_, err = LoadKeyring(KeyringConfig{Secret: testSecret, PreviousSecrets: []string{previousSecret}, SigningKeyID: hmacKey(previousSecret).ID})
+	if err == nil || !strings.Contains(err.Error(), "no private key") {
+		t.Errorf("signing with a previous secret: got %v", err)
+	}
+}
+
+func TestLoadKeyringSigningKey(t *testing.T) {
+	rsaKey := newRSAKey(t)
+	edKey := newEd25519Key(t)
+
+	t.Run("verify-only key", func(t *testing.T) {
+		dir := t.TempDir()
+		writePEM(t, dir, "rsa-1", &rsaKey.PublicKey)
+		if _, err := LoadKeyring(KeyringConfig{Dir: dir}); err == nil {
+			t.Error("keyring without a private key was accepted")
+		}
+		_, err := LoadKeyring(KeyringConfig{Dir: dir, SigningKeyID: "rsa-1"})
+		if err == nil || !strings.Contains(err.Error(), "no private key") {
+			t.Errorf("signing with a public key: got %v", err)
+		}
+	})
+
+	t.Run("several private keys", func(t *testing.T) {
+		dir := t.TempDir()
+		writePEM(t, dir, "rsa-1", rsaKey)
+		writePEM(t, dir, "ed-1", edKey)
+		if _, err := LoadKeyring(KeyringConfig{Dir: dir}); err == nil || !strings.Contains(err.Error(), "several signing keys") {
+			t.Errorf("got %v, want an error asking for the signing key ID", err)
+		}
+
+		k, err := LoadKeyring(KeyringConfig{Dir: dir, SigningKeyID: "ed-1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if k.SigningKey().ID != "ed-1" || k.SigningKey().Method != jwt.SigningMethodEdDSA {
+			t.Errorf("signing with %s (%s), want ed-1", k.SigningKey().ID, k.SigningKey().Method.Alg())
+		}
+		if err := verify(k, signWith(t, k)); err != nil {
+			t.Errorf("EdDSA token was rejected: %v", err)
+		}
+		if _, err := LoadKeyring(KeyringConfig{Dir: dir, SigningKeyID: "missing"}); err == nil {
+			t.Error("unknown signing key ID was accepted")
+		}
+	})
+
+	t.Run("only private key", func(t *testing.T) {
+		dir := t.TempDir()
+		writePEM(t, dir, "rsa-1", rsaKey)
+		writePEM(t, dir, "ed-old", edKey.Public())
+		k, err := LoadKeyring(KeyringConfig{Dir: dir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if k.SigningKey().ID != "rsa-1" {
+			t.Errorf("signing with %s, want rsa-1", k.SigningKey().ID)
+		}
+		if err := verify(k, signWith(t, k)); err != nil {
+			t.Errorf("RS256 token was rejected: %v", err)
+		}
+	})
+
+	t.Run("invalid files", func(t *testing.T) {
+		tests := map[string]string{
+			"short.secret": "too short",
+			"junk.pem":     "not a key",
+		}
+		for name, content := range tests {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadKeyring(KeyringConfig{Dir: dir, Secret: testSecret}); err == nil {
+				t.Errorf("%s was accepted", name)
+			}
+		}
+	})
+}
+
+func TestJWKSOmitsHMACKeys(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey := newRSAKey(t)
+	edKey := newEd25519Key(t)
+	writePEM(t, dir, "rsa-1", rsaKey)
+	writePEM(t, dir, "ed-old", edKey.Public())
+	if err := os.WriteFile(filepath.Join(dir, "hs-file.secret"), []byte(testSecret+"-file"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	k, err := LoadKeyring(KeyringConfig{Dir: dir, Secret: testSecret, PreviousSecrets: []string{previousSecret}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set := k.JWKS()
+	var kids []string
+	for _, jwk := range set.Keys {
+		kids = append(kids, jwk.Kid)
+		if jwk.Kty == "oct" || jwk.Alg == "HS256" {
+			t.Errorf("HMAC key %s was published", jwk.Kid)
+		}
+	}
+	if want := []string{"ed-old", "rsa-1"}; !reflect.DeepEqual(kids, want) {
+		t.Errorf("got keys %v, want %v", kids, want)
+	}
+	for _, jwk := range set.Keys {
+		switch jwk.Kid {
+		case "rsa-1":
+			if jwk.Kty != "RSA" || jwk.Alg != "RS256" || jwk.N == "" || jwk.E != "AQAB" {
+				t.Errorf("unexpected RSA key %+v", jwk)
+			}
+		case "ed-old":
+			if jwk.Kty != "OKP" || jwk.Crv != "Ed25519" || jwk.X != b64(edKey.Public().(ed25519.PublicKey)) {
+				t.Errorf("unexpected Ed25519 key %+v", jwk)
+			}
+		}
+	}
+
+	if keys := NewHMACKeyring(testSecret).JWKS().Keys; len(keys) != 0 {
+		t.Errorf("HMAC-only keyring published %v", keys)
+	}
+}
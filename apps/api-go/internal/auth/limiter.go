@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// AttemptLimiter tracks failed attempts per key (such as an IP address or a
+// username) and tells callers how long a key must wait before trying again.
+// The in-process MemoryLimiter suits a single replica; deployments with
+// several replicas can plug in an implementation backed by a shared store.
+type AttemptLimiter interface {
+	// Wait returns how long key must wait before its next attempt, 0 if none
+	Wait(key string, now time.Time) time.Duration
+	// Fail records a failed attempt and returns the wait it triggers
+	Fail(key string, now time.Time) time.Duration
+	// Reset forgets the failures of a key after a successful attempt
+	Reset(key string)
+}
+
+// BackoffPolicy configures exponential backoff: the first FreeAttempts
+// failures cost nothing, then every further failure doubles the wait,
+// starting at BaseDelay and capped at MaxDelay. Failures are forgotten after
+// ResetAfter without any.
+type BackoffPolicy struct {
+	FreeAttempts int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	ResetAfter   time.Duration
+}
+
+// Delay returns the wait after the given number of consecutive failures
+func (p BackoffPolicy) Delay(failures int) time.Duration {
+	over := failures - p.FreeAttempts
+	if over <= 0 {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := 1; i < over && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+type attemptRecord struct {
+	failures     int
+	lastFailure  time.Time
+	blockedUntil time.Time
+}
+
+// MemoryLimiter is an in-process AttemptLimiter
+type MemoryLimiter struct {
+	policy BackoffPolicy
+
+	mu        sync.Mutex
+	records   map[string]*attemptRecord
+	lastPrune time.Time
+}
+
+// NewMemoryLimiter creates an in-process limiter with the given policy
+func NewMemoryLimiter(policy BackoffPolicy) *MemoryLimiter {
+	return &MemoryLimiter{
+		policy:  policy,
+		records: make(map[string]*attemptRecord),
+	}
+}
+
+// Wait implements AttemptLimiter
+func (l *MemoryLimiter) Wait(key string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rec := l.record(key, now)
+	if rec == nil || !now.Before(rec.blockedUntil) {
+		return 0
+	}
+	return rec.blockedUntil.Sub(now)
+}
+
+// Fail implements AttemptLimiter
+func (l *MemoryLimiter) Fail(key string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.prune(now)
+	rec := l.record(key, now)
+	if rec == nil {
+		rec = &attemptRecord{}
+		l.records[key] = rec
+	}
+	rec.failures++
+	rec.lastFailure = now
+	delay := l.policy.Delay(rec.failures)
+	rec.blockedUntil = now.Add(delay)
+	return delay
+}
+
+// Reset implements AttemptLimiter
+func (l *MemoryLimiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.records, key)
+}
+
+// record returns the live record for key, dropping it once it has expired
+func (l *MemoryLimiter) record(key string, now time.Time) *attemptRecord {
+	rec, ok := l.records[key]
+	if !ok {
+		return nil
+	}
+	if l.expired(rec, now) {
+		delete(l.records, key)
+		return nil
+	}
+	return rec
+}
+
+func (l *MemoryLimiter) expired(rec *attemptRecord, now time.Time) bool {
+	return now.Sub(rec.lastFailure) >= l.policy.ResetAfter && !now.Before(rec.blockedUntil)
+}
+
+// prune drops expired records, at most once per ResetAfter
+func (l *MemoryLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < l.policy.ResetAfter {
+		return
+	}
+	l.lastPrune = now
+	for key, rec := range l.records {
+		if l.expired(rec, now) {
+			delete(l.records, key)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+var testPolicy = BackoffPolicy{
+	FreeAttempts: 3,
+	BaseDelay:    time.Second,
+	MaxDelay:     10 * time.Second,
+	ResetAfter:   time.Hour,
+}
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{3, 0},
+		{4, time.Second},
+		{5, 2 * time.Second},
+		{6, 4 * time.Second},
+		{7, 8 * time.Second},
+		{8, 10 * time.Second},
+		{100, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := testPolicy.Delay(tt.failures); got != tt.want {
+			t.Errorf("Delay(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+
+	// A cap below the base delay wins
+	capped := BackoffPolicy{BaseDelay: time.Minute, MaxDelay: time.Second}
+	if got := capped.Delay(1); got != time.Second {
+		t.Errorf("capped Delay(1) = %s, want 1s", got)
+	}
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	l := NewMemoryLimiter(testPolicy)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < testPolicy.FreeAttempts; i++ {
+		if d := l.Fail("ada", now); d != 0 {
+			t.Fatalf("free failure %d cost %s", i+1, d)
+		}
+	}
+	if w := l.Wait("ada", now); w != 0 {
+		t.Errorf("waiting %s after free failures", w)
+	}
+	if d := l.Fail("ada", now); d != time.Second {
+		t.Errorf("first backed off failure cost %s, want 1s", d)
+	}
+	if w := l.Wait("ada", now.Add(400*time.Millisecond)); w != 600*time.Millisecond {
+		t.Errorf("got wait %s, want 600ms", w)
+	}
+	if w := l.Wait("ada", now.Add(time.Second)); w != 0 {
+		t.Errorf("still waiting %s after the delay", w)
+	}
+	if w := l.Wait("grace", now); w != 0 {
+		t.Errorf("other keys wait %s", w)
+	}
+
+	// Failures are forgotten after ResetAfter
+	later := now.Add(testPolicy.ResetAfter)
+	if d := l.Fail("ada", later); d != 0 {
+		t.Errorf("failure after ResetAfter cost %s, want a fresh start", d)
+	}
+
+	// and on a successful attempt
+	for i := 0; i <= testPolicy.FreeAttempts; i++ {
+		l.Fail("grace", later)
+	}
+	if w := l.Wait("grace", later); w == 0 {
+		t.Fatal("grace is not backed off")
+	}
+	l.Reset("grace")
+	if w := l.Wait("grace", later); w != 0 {
+		t.Errorf("waiting %s after a reset", w)
+	}
+}
+
+func TestMemoryLimiterPrunes(t *testing.T) {
+	l := NewMemoryLimiter(testPolicy)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	for _, key := range []string{"a", "b", "c"} {
+		l.Fail(key, now)
+	}
+
+	// A record still blocked outlives ResetAfter
+	policy := testPolicy
+	policy.FreeAttempts = 0
+	policy.BaseDelay = 2 * time.Hour
+	policy.MaxDelay = 2 * time.Hour
+	blocked := NewMemoryLimiter(policy)
+	blocked.Fail("a", now)
+
+	later := now.Add(testPolicy.ResetAfter)
+	l.Fail("d", later)
+	if len(l.records) != 1 {
+		t.Errorf("%d records left after pruning, want 1", len(l.records))
+	}
+
+	blocked.Fail("b", later)
+	if _, ok := blocked.records["a"]; !ok {
+		t.Error("blocked record was pruned")
+	}
+	if w := blocked.Wait("a", later); w != time.Hour {
+		t.Errorf("got wait %s, want 1h", w)
+	}
+}
@@ -3,8 +3,11 @@ package auth
 import (
 	"context"
 	"encoding/json"
+	"log"
+	"net"
 	"net/http"
 	"strings"
+	"time"
 )
 
 type contextKey string
@@ -13,9 +16,30 @@ const UserContextKey contextKey = "user"
 
 // UserContext represents user information stored in request context
 type UserContext struct {
-	UserID   string
-	Username string
-	IsGuest  bool
+	UserID    string
+	Username  string
+	IsGuest   bool
+	Role      string
+	SessionID string
+
+	// TokenID is set when the request came with a personal access token,
+	// which may only do what its Scopes allow
+	TokenID string
+	Scopes  []string
+}
+
+// HasScope reports whether the request may use a scope. Login sessions have
+// every scope.
+func (u *UserContext) HasScope(scope string) bool {
+	if u.TokenID == "" {
+		return true
+	}
+	for _, s := range u.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
 }
 
 func (s *Service) AuthMiddleware(next http.Handler) http.Handler {
@@ -25,15 +49,30 @@ func (s *Service) AuthMiddleware(next http.Handler) http.Handler {
 		w.Header().Set("X-XSS-Protection", "1; mode=block")
 
 		if token := s.extractToken(r); token != "" {
-			if claims, err := s.ValidateToken(token); err == nil {
-				r = s.injectUserContext(r, claims)
+			if userCtx, err := s.authenticate(token); err == nil {
+				r = withUserContext(r, userCtx)
+				s.touchSession(r, userCtx.SessionID)
 			}
 		}
 		next.ServeHTTP(w, r)
 	})
 }
 
+// RequireAuth rejects requests without a login session. Personal access
+// tokens are refused; routes that accept them use RequireScope instead.
 func (s *Service) RequireAuth(next http.Handler) http.Handler {
+	return s.requireScope("", next)
+}
+
+// RequireScope rejects requests without a login session or a personal access
+// token granted scope
+func (s *Service) RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return s.requireScope(scope, next)
+	}
+}
+
+func (s *Service) requireScope(scope string, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		token := s.extractToken(r)
 		if token == "" {
@@ -41,24 +80,116 @@ func (s *Service) RequireAuth(next http.Handler) http.Handler {
 			return
 		}
 
-		claims, err := s.ValidateToken(token)
+		userCtx, err := s.authenticate(token)
 		if err != nil {
 			respondWithError(w, http.StatusUnauthorized, "invalid or expired token")
 			return
 		}
+		if userCtx.TokenID != "" && (scope == "" || !userCtx.HasScope(scope)) {
+			if scope == "" {
+				respondWithError(w, http.StatusForbidden, "personal access tokens cannot be used here")
+			} else {
+				respondWithError(w, http.StatusForbidden, "token lacks the "+scope+" scope")
+			}
+			return
+		}
 
-		r = s.injectUserContext(r, claims)
+		r = withUserContext(r, userCtx)
 		next.ServeHTTP(w, r)
 	})
 }
 
-func (s *Service) injectUserContext(r *http.Request, claims *Claims) *http.Request {
-	userCtx := UserContext{
-		UserID:   claims.UserID,
-		Username: claims.Username,
-		IsGuest:  claims.IsGuest,
+// RequireRole rejects requests whose authenticated user does not hold one of
+// the given roles. It must run after RequireAuth.
+func (s *Service) RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userCtx, ok := GetUserFromContext(r.Context())
+			if !ok {
+				respondWithError(w, http.StatusUnauthorized, "authentication required")
+				return
+			}
+
+			for _, role := range roles {
+				if userCtx.Role == role {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			respondWithError(w, http.StatusForbidden, "insufficient permissions")
+		})
+	}
+}
+
+// authenticate returns who a token from extractToken belongs to: a login
+// session's access token or a personal access token
+func (s *Service) authenticate(token string) (*UserContext, error) {
+	if IsPersonalToken(token) {
+		return s.validatePersonalToken(token)
+	}
+
+	claims, err := s.ValidateToken(token)
+	if err != nil {
+		return nil, err
+	}
+	return &UserContext{
+		UserID:    claims.UserID,
+		Username:  claims.Username,
+		IsGuest:   claims.IsGuest,
+		Role:      claims.Role,
+		SessionID: claims.SessionID,
+	}, nil
+}
+
+func withUserContext(r *http.Request, userCtx *UserContext) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), UserContextKey, *userCtx))
+}
+
+// sessionTouchInterval limits how often the use of a session is written
+const sessionTouchInterval = time.Minute
+
+// touchSession records the request's user agent and IP address against its
+// login session, at most once per sessionTouchInterval
+func (s *Service) touchSession(r *http.Request, sessionID string) {
+	if s.tracker == nil || sessionID == "" {
+		return
+	}
+
+	now := time.Now().UTC()
+	if !s.shouldTouch(sessionID, now) {
+		return
+	}
+	if err := s.tracker.TouchAuthSession(sessionID, r.UserAgent(), ClientIP(r), now); err != nil {
+		log.Printf("failed to record use of session %s: %v", sessionID, err)
+	}
+}
+
+// shouldTouch reports whether the use of a session or token, by key, is due
+// to be recorded again
+func (s *Service) shouldTouch(key string, now time.Time) bool {
+	s.trackMu.Lock()
+	defer s.trackMu.Unlock()
+
+	if now.Sub(s.lastTouched[key]) < sessionTouchInterval {
+		return false
+	}
+	s.lastTouched[key] = now
+	for k, at := range s.lastTouched {
+		if now.Sub(at) >= sessionTouchInterval {
+			delete(s.lastTouched, k)
+		}
+	}
+	return true
+}
+
+// ClientIP returns the address a request came from, without the port. Proxy
+// headers are only honoured when the server is configured to trust them.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
 	}
-	return r.WithContext(context.WithValue(r.Context(), UserContextKey, userCtx))
+	return host
 }
 
 func respondWithError(w http.ResponseWriter, code int, message string) {
@@ -68,6 +199,8 @@ func respondWithError(w http.ResponseWriter, code int, message string) {
 	_, _ = w.Write(response)
 }
 
+// extractToken returns the token a request authenticates with: the session
+// cookie or a bearer token, which may be a personal access token
 func (s *Service) extractToken(r *http.Request) string {
 	if cookie, err := r.Cookie("token"); err == nil && cookie.Value != "" {
 		return cookie.Value
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// Refresh tokens have the form "<session id>.<random secret>". Only a hash of
+// the whole token is stored, so a leaked database cannot be used to log in.
+
+// NewRefreshToken creates a refresh token for a login session and returns it
+// together with the hash to store
+func NewRefreshToken(sessionID string) (string, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	token := sessionID + "." + base64.RawURLEncoding.EncodeToString(secret)
+	return token, HashToken(token), nil
+}
+
+// ParseRefreshToken returns the session a refresh token belongs to and the
+// hash to compare with the stored one
+func ParseRefreshToken(token string) (string, string, error) {
+	sessionID, secret, ok := strings.Cut(token, ".")
+	if !ok || sessionID == "" || secret == "" {
+		return "", "", ErrInvalidToken
+	}
+	return sessionID, HashToken(token), nil
+}
+
+// NewOneTimeToken creates a random token, such as a password reset token, and
+// returns it together with the hash to store
+func NewOneTimeToken() (string, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+	return token, HashToken(token), nil
+}
+
+// HashToken returns the hex SHA-256 of a refresh or one-time token. These
+// tokens are random, so a fast hash is enough.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
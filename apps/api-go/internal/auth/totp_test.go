@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 seed of RFC 6238 Appendix B, "12345678901234567890"
+var rfcSecret = totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// Appendix B lists 8-digit codes; ours are their last 6 digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := TOTPCode(rfcSecret, TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("code at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+
+	if _, err := TOTPCode("not base32!", 1); err == nil {
+		t.Error("invalid secret was accepted")
+	}
+}
+
+func TestVerifyTOTPSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := TOTPStep(now)
+	codeAt := func(step int64) string {
+		code, err := TOTPCode(rfcSecret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name   string
+		code   string
+		want   int64
+		accept bool
+	}{
+		{"current step", codeAt(current), current, true},
+		{"one step behind", codeAt(current - 1), current - 1, true},
+		{"one step ahead", codeAt(current + 1), current + 1, true},
+		{"two steps behind", codeAt(current - 2), 0, false},
+		{"two steps ahead", codeAt(current + 2), 0, false},
+		{"spaces are ignored", codeAt(current)[:3] + " " + codeAt(current)[3:], current, true},
+		{"too short", codeAt(current)[:5], 0, false},
+		{"wrong code", "000000", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := VerifyTOTP(rfcSecret, tt.code, now, 0)
+			if ok != tt.accept || step != tt.want {
+				t.Errorf("VerifyTOTP = %d, %v, want %d, %v", step, ok, tt.want, tt.accept)
+			}
+		})
+	}
+}
+
+func TestVerifyTOTPRejectsUsedSteps(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := TOTPStep(now)
+	code, err := TOTPCode(rfcSecret, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	step, ok := VerifyTOTP(rfcSecret, code, now, 0)
+	if !ok || step != current {
+		t.Fatalf("first use: got %d, %v", step, ok)
+	}
+	// The same code again, still within its step or the next one
+	if _, ok := VerifyTOTP(rfcSecret, code, now, step); ok {
+		t.Error("code was accepted twice")
+	}
+	if _, ok := VerifyTOTP(rfcSecret, code, now.Add(TOTPPeriod), step); ok {
+		t.Error("code was accepted again in the next step")
+	}
+	// An older code that is still within the skew
+	previous, err := TOTPCode(rfcSecret, current-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := VerifyTOTP(rfcSecret, previous, now, step); ok {
+		t.Error("code from before the last used step was accepted")
+	}
+	// The next code is fine
+	next, err := TOTPCode(rfcSecret, current+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := VerifyTOTP(rfcSecret, next, now.Add(TOTPPeriod), step); !ok || got != current+1 {
+		t.Errorf("next code: got %d, %v", got, ok)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := NewRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != RecoveryCodeCount || len(hashes) != RecoveryCodeCount {
+		t.Fatalf("got %d codes and %d hashes, want %d", len(codes), len(hashes), RecoveryCodeCount)
+	}
+	seen := make(map[string]bool)
+	for i, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("code %q is not formatted xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("code %q was issued twice", code)
+		}
+		seen[code] = true
+		if HashRecoveryCode(code) != hashes[i] {
+			t.Errorf("hash of %q doesn't match", code)
+		}
+	}
+
+	// Codes may be typed the way they were written down
+	want := HashRecoveryCode("abcde-fghij")
+	for _, typed := range []string{"ABCDE-FGHIJ", "abcdefghij", " abcde fghij "} {
+		if HashRecoveryCode(typed) != want {
+			t.Errorf("%q hashes differently from abcde-fghij", typed)
+		}
+	}
+}
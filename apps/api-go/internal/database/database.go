@@ -4,120 +4,122 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	_ "github.com/lib/pq"
 	"github.com/typing-code-learn/api-go/internal/models"
 )
 
 // DB wraps the sql.DB with helper methods
 type DB struct {
 	*sql.DB
+	dialect dialect
 }
 
-// InitDB creates and initializes the PostgreSQL database
+// InitDB opens the SQL database, applies pending migrations and seeds default data
 func InitDB(databaseURL string) (*DB, error) {
-	sqlDB, err := sql.Open("postgres", databaseURL)
+	db, err := Open(databaseURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		return nil, err
 	}
 
-	// Connection pool settings
-	sqlDB.SetMaxOpenConns(25)
-	sqlDB.SetMaxIdleConns(5)
-	sqlDB.SetConnMaxLifetime(5 * time.Minute)
+	if _, err := db.MigrateUp(); err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
 
-	if err := sqlDB.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+	if err := db.InitializeBadges(); err != nil {
+		return nil, fmt.Errorf("failed to initialize badges: %w", err)
 	}
 
-	db := &DB{sqlDB}
+	return db, nil
+}
 
-	if err := db.createTables(); err != nil {
-		return nil, fmt.Errorf("failed to create tables: %w", err)
+// Open connects to the SQL database without touching its schema. URLs starting
+// with sqlite:// open a SQLite file; anything else is handed to PostgreSQL.
+func Open(databaseURL string) (*DB, error) {
+	d, driver, dsn := parseDatabaseURL(databaseURL)
+	sqlDB, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	if err := db.InitializeBadges(); err != nil {
-		return nil, fmt.Errorf("failed to initialize badges: %w", err)
+	// Connection pool settings
+	if d == dialectSQLite {
+		// SQLite allows a single writer; one connection avoids "database is locked"
+		sqlDB.SetMaxOpenConns(1)
+	} else {
+		sqlDB.SetMaxOpenConns(25)
+		sqlDB.SetMaxIdleConns(5)
+		sqlDB.SetConnMaxLifetime(5 * time.Minute)
 	}
 
-	return db, nil
+	if err := sqlDB.Ping(); err != nil {
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return &DB{DB: sqlDB, dialect: d}, nil
 }
 
-func (db *DB) createTables() error {
-	queries := []string{
-		`CREATE TABLE IF NOT EXISTS users (
-			id TEXT PRIMARY KEY, username TEXT UNIQUE NOT NULL, email TEXT UNIQUE,
-			password_hash TEXT, display_name TEXT NOT NULL, github_username TEXT UNIQUE,
-			is_guest BOOLEAN DEFAULT FALSE, current_streak INTEGER DEFAULT 0,
-			last_streak_at TIMESTAMPTZ, created_at TIMESTAMPTZ DEFAULT NOW(),
-			updated_at TIMESTAMPTZ DEFAULT NOW()
-		)`,
-		`CREATE INDEX IF NOT EXISTS idx_users_username ON users(username)`,
-		`CREATE INDEX IF NOT EXISTS idx_users_email ON users(email)`,
-		`CREATE TABLE IF NOT EXISTS progress (
-			id TEXT PRIMARY KEY, user_id TEXT NOT NULL, lesson_id TEXT NOT NULL,
-			completed BOOLEAN DEFAULT FALSE, best_wpm REAL DEFAULT 0,
-			best_accuracy REAL DEFAULT 0, attempts INTEGER DEFAULT 0,
-			last_attempt TIMESTAMPTZ, created_at TIMESTAMPTZ DEFAULT NOW(),
-			updated_at TIMESTAMPTZ DEFAULT NOW(), UNIQUE(user_id, lesson_id)
-		)`,
-		`CREATE TABLE IF NOT EXISTS typing_metrics (
-			id TEXT PRIMARY KEY, user_id TEXT NOT NULL, lesson_id TEXT NOT NULL,
-			wpm REAL NOT NULL, accuracy REAL NOT NULL, total_time REAL NOT NULL,
-			total_chars INTEGER NOT NULL, correct_chars INTEGER NOT NULL,
-			incorrect_chars INTEGER NOT NULL, common_errors TEXT DEFAULT '[]',
-			created_at TIMESTAMPTZ DEFAULT NOW()
-		)`,
-		`CREATE INDEX IF NOT EXISTS idx_progress_user ON progress(user_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_metrics_user ON typing_metrics(user_id)`,
-		`CREATE TABLE IF NOT EXISTS point_transactions (
-			id TEXT PRIMARY KEY, user_id TEXT NOT NULL, source_id TEXT,
-			points INTEGER NOT NULL, reason TEXT NOT NULL, created_at TIMESTAMPTZ DEFAULT NOW()
-		)`,
-		`CREATE INDEX IF NOT EXISTS idx_points_user ON point_transactions(user_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_points_created_at ON point_transactions(created_at)`,
-		`CREATE TABLE IF NOT EXISTS badges (
-			id TEXT PRIMARY KEY, name TEXT UNIQUE NOT NULL, color TEXT NOT NULL,
-			created_at TIMESTAMPTZ DEFAULT NOW(), updated_at TIMESTAMPTZ DEFAULT NOW()
-		)`,
-		`CREATE TABLE IF NOT EXISTS user_badges (
-			user_id TEXT NOT NULL, badge_id TEXT NOT NULL, assigned_at TIMESTAMPTZ DEFAULT NOW(),
-			PRIMARY KEY (user_id, badge_id),
-			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
-			FOREIGN KEY (badge_id) REFERENCES badges(id) ON DELETE CASCADE
-		)`,
-		`CREATE INDEX IF NOT EXISTS idx_user_badges_user ON user_badges(user_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_user_badges_badge ON user_badges(badge_id)`,
-	}
-
-	for _, q := range queries {
-		if _, err := db.Exec(q); err != nil {
-			return fmt.Errorf("failed to execute query: %w", err)
-		}
+// countedPoints excludes points earned by metrics that are flagged or were
+// rejected on review. It expects point_transactions to be aliased as pt.
+const countedPoints = `(pt.metrics_id IS NULL OR pt.metrics_id NOT IN (
+	SELECT id FROM typing_metrics WHERE review_status IN ('` + models.ReviewStatusFlagged + `', '` + models.ReviewStatusRejected + `')))`
+
+// rankedUsers limits a ranking to users with a verified email when
+// verifiedOnly is set. It expects point_transactions to be aliased as pt.
+func rankedUsers(verifiedOnly bool) string {
+	if !verifiedOnly {
+		return ""
 	}
-	return nil
+	return ` AND pt.user_id IN (SELECT id FROM users WHERE email_verified_at IS NOT NULL)`
 }
 
 // SavePointTransaction saves a point earning event
 func (db *DB) SavePointTransaction(pt models.PointTransaction) error {
 	_, err := db.Exec(
-		`INSERT INTO point_transactions (id, user_id, source_id, points, reason, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)`,
-		pt.ID, pt.UserID, pt.SourceID, pt.Points, pt.Reason, pt.CreatedAt,
+		`INSERT INTO point_transactions (id, user_id, source_id, points, reason, metrics_id, strategy, strategy_version, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		pt.ID, pt.UserID, pt.SourceID, pt.Points, pt.Reason, nullIfEmpty(pt.MetricsID), pt.Strategy, pt.StrategyVersion, pt.CreatedAt,
 	)
 	return err
 }
 
-// GetLeaderboard returns the leaderboard for a specific period
-func (db *DB) GetLeaderboard(startDate, endDate time.Time, limit int) ([]models.LeaderboardEntry, error) {
+// GetUserPointTransactions returns all point transactions of a user, oldest
+// first
+func (db *DB) GetUserPointTransactions(userID string) ([]models.PointTransaction, error) {
+	rows, err := db.Query(
+		`SELECT id, user_id, source_id, points, reason, metrics_id, strategy, strategy_version, created_at
+		FROM point_transactions WHERE user_id = $1 ORDER BY created_at, id`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []models.PointTransaction
+	for rows.Next() {
+		var pt models.PointTransaction
+		var metricsID sql.NullString
+		if err := rows.Scan(&pt.ID, &pt.UserID, &pt.SourceID, &pt.Points, &pt.Reason, &metricsID, &pt.Strategy, &pt.StrategyVersion, &pt.CreatedAt); err != nil {
+			return nil, err
+		}
+		pt.MetricsID = metricsID.String
+		transactions = append(transactions, pt)
+	}
+	return transactions, rows.Err()
+}
+
+// GetLeaderboard returns the leaderboard for a specific period, leaving out
+// users without a verified email when verifiedOnly is set
+func (db *DB) GetLeaderboard(startDate, endDate time.Time, limit int, verifiedOnly bool) ([]models.LeaderboardEntry, error) {
 	rows, err := db.Query(
-		`SELECT pt.user_id, u.username, u.github_username, SUM(pt.points) as total_points
+		`SELECT pt.user_id, u.username, u.github_username, u.github_verified_at IS NOT NULL, SUM(pt.points) as total_points
 		FROM point_transactions pt
 		INNER JOIN users u ON pt.user_id = u.id
-		WHERE pt.created_at BETWEEN $1 AND $2
-		GROUP BY pt.user_id, u.username, u.github_username
+		WHERE pt.created_at BETWEEN $1 AND $2 AND `+countedPoints+rankedUsers(verifiedOnly)+`
+		GROUP BY pt.user_id, u.username, u.github_username, u.github_verified_at
 		ORDER BY total_points DESC
 		LIMIT $3`,
 		startDate, endDate, limit,
@@ -132,7 +134,7 @@ func (db *DB) GetLeaderboard(startDate, endDate time.Time, limit int) ([]models.
 	for rows.Next() {
 		var entry models.LeaderboardEntry
 		var githubUsername sql.NullString
-		if err := rows.Scan(&entry.UserID, &entry.Username, &githubUsername, &entry.Points); err != nil {
+		if err := rows.Scan(&entry.UserID, &entry.Username, &githubUsername, &entry.GitHubVerified, &entry.Points); err != nil {
 			return nil, err
 		}
 		if githubUsername.Valid {
@@ -140,14 +142,22 @@ func (db *DB) GetLeaderboard(startDate, endDate time.Time, limit int) ([]models.
 		}
 		entry.Rank = rank
 
-		badges, err := db.GetUserBadges(entry.UserID)
+		rank++
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	// Load badges once the ranking rows are released, so this also works on
+	// single-connection pools (SQLite)
+	for i := range entries {
+		badges, err := db.GetUserBadges(entries[i].UserID)
 		if err != nil {
 			return nil, err
 		}
-		entry.Badges = badges
-
-		rank++
-		entries = append(entries, entry)
+		entries[i].Badges = badges
 	}
 
 	return entries, nil
@@ -157,8 +167,8 @@ func (db *DB) GetLeaderboard(startDate, endDate time.Time, limit int) ([]models.
 func (db *DB) GetUserPoints(userID string, startDate, endDate time.Time) (int, error) {
 	var totalPoints sql.NullInt64
 	err := db.QueryRow(
-		`SELECT SUM(points) FROM point_transactions
-		WHERE user_id = $1 AND created_at BETWEEN $2 AND $3`,
+		`SELECT SUM(pt.points) FROM point_transactions pt
+		WHERE pt.user_id = $1 AND pt.created_at BETWEEN $2 AND $3 AND `+countedPoints,
 		userID, startDate, endDate,
 	).Scan(&totalPoints)
 
@@ -173,8 +183,9 @@ func (db *DB) GetUserPoints(userID string, startDate, endDate time.Time) (int, e
 	return int(totalPoints.Int64), nil
 }
 
-// GetUserRank returns the rank of a user in a specific period
-func (db *DB) GetUserRank(userID string, startDate, endDate time.Time) (int, error) {
+// GetUserRank returns the rank of a user in a specific period among the users
+// GetLeaderboard would list with the same verifiedOnly
+func (db *DB) GetUserRank(userID string, startDate, endDate time.Time, verifiedOnly bool) (int, error) {
 	userPoints, err := db.GetUserPoints(userID, startDate, endDate)
 	if err != nil {
 		return 0, err
@@ -184,11 +195,11 @@ func (db *DB) GetUserRank(userID string, startDate, endDate time.Time) (int, err
 	err = db.QueryRow(
 		`SELECT COUNT(*) + 1 as rank
 		FROM (
-			SELECT user_id, SUM(points) as total_points
-			FROM point_transactions
-			WHERE created_at BETWEEN $1 AND $2
-			GROUP BY user_id
-			HAVING SUM(points) > $3
+			SELECT pt.user_id, SUM(pt.points) as total_points
+			FROM point_transactions pt
+			WHERE pt.created_at BETWEEN $1 AND $2 AND `+countedPoints+rankedUsers(verifiedOnly)+`
+			GROUP BY pt.user_id
+			HAVING SUM(pt.points) > $3
 		) as higher_users`,
 		startDate, endDate, userPoints,
 	).Scan(&rank)
@@ -200,6 +211,48 @@ func (db *DB) GetUserRank(userID string, startDate, endDate time.Time) (int, err
 	return rank, nil
 }
 
+// RecordTierEvent saves a promotion and reports false if the user already
+// reached the tier
+func (db *DB) RecordTierEvent(event models.TierEvent) (bool, error) {
+	result, err := db.Exec(
+		`INSERT INTO tier_events (id, user_id, tier, points, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT DO NOTHING`,
+		event.ID, event.UserID, event.Tier, event.Points, event.CreatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// GetTierEvents returns the promotions of a user, oldest first
+func (db *DB) GetTierEvents(userID string) ([]models.TierEvent, error) {
+	rows, err := db.Query(
+		`SELECT id, user_id, tier, points, created_at
+		FROM tier_events WHERE user_id = $1 ORDER BY created_at`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.TierEvent
+	for rows.Next() {
+		var e models.TierEvent
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Tier, &e.Points, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
 func (db *DB) CreateGuestUser() (*models.User, error) {
 	id := uuid.New().String()
 	guestNum := time.Now().UnixNano() % 10000000
@@ -221,6 +274,7 @@ func (db *DB) CreateGuestUser() (*models.User, error) {
 		Username:    username,
 		DisplayName: displayName,
 		IsGuest:     true,
+		Role:        models.RoleUser,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}, nil
@@ -234,42 +288,36 @@ func (db *DB) CreateRegisteredUser(username, email, passwordHash, displayName, g
 	_, err := db.Exec(
 		`INSERT INTO users (id, username, email, password_hash, display_name, github_username, is_guest, current_streak, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
-		id, username, email, passwordHash, displayName, githubUsername, false, 0, now, now,
+		id, username, nullIfEmpty(email), nullIfEmpty(passwordHash), displayName, nullIfEmpty(githubUsername), false, 0, now, now,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	user := &models.User{
+	return &models.User{
 		ID:             id,
 		Username:       username,
 		Email:          &email,
 		DisplayName:    displayName,
 		GitHubUsername: &githubUsername,
 		IsGuest:        false,
+		Role:           models.RoleUser,
 		CreatedAt:      now,
 		UpdatedAt:      now,
-	}
-
-	// Assign automatic badges
-	if err := db.assignAutomaticBadges(user); err != nil {
-		// Log error but don't fail user creation
-		fmt.Printf("Error assigning automatic badges: %v\n", err)
-	}
-
-	return user, nil
+	}, nil
 }
 
 // GetUserByID returns a user by ID
 func (db *DB) GetUserByID(id string) (*models.User, error) {
 	var user models.User
-	var email sql.NullString
+	var email, githubUsername sql.NullString
 
 	err := db.QueryRow(
-		`SELECT id, username, email, display_name, is_guest, current_streak, last_streak_at, created_at, updated_at
+		`SELECT id, username, email, display_name, github_username, github_verified_at IS NOT NULL, is_guest, role,
+			current_streak, last_streak_at, email_verified_at, totp_enabled_at IS NOT NULL, created_at, updated_at
 		FROM users WHERE id = $1`,
 		id,
-	).Scan(&user.ID, &user.Username, &email, &user.DisplayName, &user.IsGuest, &user.CurrentStreak, &user.LastStreakAt, &user.CreatedAt, &user.UpdatedAt)
+	).Scan(&user.ID, &user.Username, &email, &user.DisplayName, &githubUsername, &user.GitHubVerified, &user.IsGuest, &user.Role, &user.CurrentStreak, &user.LastStreakAt, &user.EmailVerifiedAt, &user.MFAEnabled, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
 		return nil, err
@@ -278,6 +326,9 @@ func (db *DB) GetUserByID(id string) (*models.User, error) {
 	if email.Valid {
 		user.Email = &email.String
 	}
+	if githubUsername.Valid {
+		user.GitHubUsername = &githubUsername.String
+	}
 
 	// Load badges
 	badges, err := db.GetUserBadges(id)
@@ -292,13 +343,14 @@ func (db *DB) GetUserByID(id string) (*models.User, error) {
 // GetUserByUsername returns a user by username
 func (db *DB) GetUserByUsername(username string) (*models.User, error) {
 	var user models.User
-	var email sql.NullString
+	var email, githubUsername sql.NullString
 
 	err := db.QueryRow(
-		`SELECT id, username, email, display_name, is_guest, current_streak, last_streak_at, created_at, updated_at
+		`SELECT id, username, email, display_name, github_username, github_verified_at IS NOT NULL, is_guest, role,
+			current_streak, last_streak_at, email_verified_at, totp_enabled_at IS NOT NULL, created_at, updated_at
 		FROM users WHERE username = $1`,
 		username,
-	).Scan(&user.ID, &user.Username, &email, &user.DisplayName, &user.IsGuest, &user.CurrentStreak, &user.LastStreakAt, &user.CreatedAt, &user.UpdatedAt)
+	).Scan(&user.ID, &user.Username, &email, &user.DisplayName, &githubUsername, &user.GitHubVerified, &user.IsGuest, &user.Role, &user.CurrentStreak, &user.LastStreakAt, &user.EmailVerifiedAt, &user.MFAEnabled, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
 		return nil, err
@@ -307,6 +359,9 @@ func (db *DB) GetUserByUsername(username string) (*models.User, error) {
 	if email.Valid {
 		user.Email = &email.String
 	}
+	if githubUsername.Valid {
+		user.GitHubUsername = &githubUsername.String
+	}
 
 	return &user, nil
 }
@@ -314,13 +369,14 @@ func (db *DB) GetUserByUsername(username string) (*models.User, error) {
 // GetUserByEmail returns a user by email
 func (db *DB) GetUserByEmail(email string) (*models.User, error) {
 	var user models.User
-	var emailVal sql.NullString
+	var emailVal, githubUsername sql.NullString
 
 	err := db.QueryRow(
-		`SELECT id, username, email, display_name, is_guest, current_streak, last_streak_at, created_at, updated_at
+		`SELECT id, username, email, display_name, github_username, github_verified_at IS NOT NULL, is_guest, role,
+			current_streak, last_streak_at, email_verified_at, totp_enabled_at IS NOT NULL, created_at, updated_at
 		FROM users WHERE email = $1`,
 		email,
-	).Scan(&user.ID, &user.Username, &emailVal, &user.DisplayName, &user.IsGuest, &user.CurrentStreak, &user.LastStreakAt, &user.CreatedAt, &user.UpdatedAt)
+	).Scan(&user.ID, &user.Username, &emailVal, &user.DisplayName, &githubUsername, &user.GitHubVerified, &user.IsGuest, &user.Role, &user.CurrentStreak, &user.LastStreakAt, &user.EmailVerifiedAt, &user.MFAEnabled, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
 		return nil, err
@@ -329,6 +385,9 @@ func (db *DB) GetUserByEmail(email string) (*models.User, error) {
 	if emailVal.Valid {
 		user.Email = &emailVal.String
 	}
+	if githubUsername.Valid {
+		user.GitHubUsername = &githubUsername.String
+	}
 
 	return &user, nil
 }
@@ -352,19 +411,111 @@ func (db *DB) GetPasswordHash(username string) (string, error) {
 	return hash.String, nil
 }
 
+// SetPasswordHash replaces a user's password hash
+func (db *DB) SetPasswordHash(userID, passwordHash string) error {
+	res, err := db.Exec(
+		`UPDATE users SET password_hash = $1, updated_at = $2 WHERE id = $3`,
+		passwordHash, time.Now(), userID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetLockedUntil returns the end of a user's login lockout, nil if none was set
+func (db *DB) GetLockedUntil(userID string) (*time.Time, error) {
+	var lockedUntil *time.Time
+	err := db.QueryRow(`SELECT locked_until FROM users WHERE id = $1`, userID).Scan(&lockedUntil)
+	if err != nil {
+		return nil, err
+	}
+	return lockedUntil, nil
+}
+
+// RecordFailedLogin counts a failed login and, if lockedUntil is set, locks
+// the account until then
+func (db *DB) RecordFailedLogin(userID string, lockedUntil *time.Time) error {
+	_, err := db.Exec(
+		`UPDATE users SET failed_login_attempts = failed_login_attempts + 1,
+			locked_until = COALESCE($1, locked_until)
+		WHERE id = $2`,
+		lockedUntil, userID,
+	)
+	return err
+}
+
+// ResetFailedLogins clears a user's failed logins and lockout
+func (db *DB) ResetFailedLogins(userID string) error {
+	_, err := db.Exec(
+		`UPDATE users SET failed_login_attempts = 0, locked_until = NULL WHERE id = $1`,
+		userID,
+	)
+	return err
+}
+
+// MarkEmailVerified records that a user verified their current email address
+func (db *DB) MarkEmailVerified(userID string, at time.Time) error {
+	res, err := db.Exec(
+		`UPDATE users SET email_verified_at = $1, updated_at = $1 WHERE id = $2 AND email IS NOT NULL`,
+		at, userID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ReleaseUnverifiedEmail removes an email from the account holding it if that
+// account never verified it and its verification link has expired, so the
+// address can be registered by its owner. It reports whether it was released.
+func (db *DB) ReleaseUnverifiedEmail(email string, now time.Time) (bool, error) {
+	res, err := db.Exec(
+		`UPDATE users SET email = NULL, updated_at = $1
+		WHERE email = $2 AND email_verified_at IS NULL AND EXISTS (
+			SELECT 1 FROM user_tokens t
+			WHERE t.user_id = users.id AND t.purpose = $3 AND t.used_at IS NULL AND t.expires_at <= $1)`,
+		now, email, models.TokenPurposeEmailVerification,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
 // ConvertGuestToRegistered converts a guest user to a registered user
 func (db *DB) ConvertGuestToRegistered(guestID, username, email, passwordHash, displayName, githubUsername string) (*models.User, error) {
 	now := time.Now()
 
-	_, err := db.Exec(
+	var role string
+	if err := db.QueryRow(`SELECT role FROM users WHERE id = $1`, guestID).Scan(&role); err != nil {
+		return nil, err
+	}
+
+	res, err := db.Exec(
 		`UPDATE users
 		SET username = $1, email = $2, password_hash = $3, display_name = $4, github_username = $5, is_guest = $6, updated_at = $7
 		WHERE id = $8 AND is_guest = $9`,
-		username, email, passwordHash, displayName, githubUsername, false, now, guestID, true,
+		username, nullIfEmpty(email), nullIfEmpty(passwordHash), displayName, nullIfEmpty(githubUsername), false, now, guestID, true,
 	)
 	if err != nil {
 		return nil, err
 	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		// Not a guest (anymore)
+		return nil, sql.ErrNoRows
+	}
 
 	return &models.User{
 		ID:             guestID,
@@ -373,10 +524,32 @@ func (db *DB) ConvertGuestToRegistered(guestID, username, email, passwordHash, d
 		DisplayName:    displayName,
 		GitHubUsername: &githubUsername,
 		IsGuest:        false,
+		Role:           role,
 		UpdatedAt:      now,
 	}, nil
 }
 
+// nullIfEmpty stores empty optional strings as NULL so that UNIQUE columns
+// such as email and github_username accept any number of unset values
+func nullIfEmpty(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// SetUserRole changes the role of a user
+func (db *DB) SetUserRole(userID, role string) error {
+	res, err := db.Exec(
+		`UPDATE users SET role = $1, updated_at = $2 WHERE id = $3`,
+		role, time.Now(), userID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
 // SaveProgress saves or updates a user's progress on a lesson
 func (db *DB) SaveProgress(req models.ProgressRequest) (*models.Progress, error) {
 	now := time.Now()
@@ -497,11 +670,16 @@ func (db *DB) SaveMetrics(req models.MetricsRequest) (*models.TypingMetrics, err
 		errorsJSON = []byte("[]")
 	}
 
+	status := models.ReviewStatusOK
+	if req.FlagReason != "" {
+		status = models.ReviewStatusFlagged
+	}
+
 	_, err = db.Exec(
-		`INSERT INTO typing_metrics (id, user_id, lesson_id, wpm, accuracy, total_time, total_chars, correct_chars, incorrect_chars, common_errors, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		`INSERT INTO typing_metrics (id, user_id, lesson_id, wpm, accuracy, total_time, total_chars, correct_chars, incorrect_chars, common_errors, review_status, flag_reason, session_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`,
 		id, req.UserID, req.LessonID, req.WPM, req.Accuracy, req.TotalTime,
-		req.TotalChars, req.CorrectChars, req.IncorrectChars, string(errorsJSON), now,
+		req.TotalChars, req.CorrectChars, req.IncorrectChars, string(errorsJSON), status, nullIfEmpty(req.FlagReason), nullIfEmpty(req.SessionID), now,
 	)
 	if err != nil {
 		return nil, err
@@ -518,10 +696,121 @@ func (db *DB) SaveMetrics(req models.MetricsRequest) (*models.TypingMetrics, err
 		CorrectChars:   req.CorrectChars,
 		IncorrectChars: req.IncorrectChars,
 		CommonErrors:   req.CommonErrors,
+		ReviewStatus:   status,
+		FlagReason:     req.FlagReason,
+		SessionID:      req.SessionID,
 		CreatedAt:      now,
 	}, nil
 }
 
+// metricsColumns lists the typing_metrics columns read by scanMetrics
+const metricsColumns = `id, user_id, lesson_id, wpm, accuracy, total_time, total_chars, correct_chars,
+	incorrect_chars, common_errors, review_status, flag_reason, reviewed_by, reviewed_at, session_id, created_at`
+
+// scanMetrics reads a typing_metrics row selected with metricsColumns
+func scanMetrics(scanner interface{ Scan(...interface{}) error }) (*models.TypingMetrics, error) {
+	var m models.TypingMetrics
+	var commonErrors string
+	var flagReason, reviewedBy, sessionID sql.NullString
+	err := scanner.Scan(&m.ID, &m.UserID, &m.LessonID, &m.WPM, &m.Accuracy, &m.TotalTime, &m.TotalChars,
+		&m.CorrectChars, &m.IncorrectChars, &commonErrors, &m.ReviewStatus, &flagReason, &reviewedBy, &m.ReviewedAt, &sessionID, &m.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(commonErrors), &m.CommonErrors); err != nil {
+		m.CommonErrors = []models.ErrorEntry{}
+	}
+	m.FlagReason = flagReason.String
+	m.ReviewedBy = reviewedBy.String
+	m.SessionID = sessionID.String
+	return &m, nil
+}
+
+// queryMetrics runs a typing_metrics query selecting metricsColumns
+func (db *DB) queryMetrics(query string, args ...interface{}) ([]models.TypingMetrics, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var metrics []models.TypingMetrics
+	for rows.Next() {
+		m, err := scanMetrics(rows)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, *m)
+	}
+	return metrics, rows.Err()
+}
+
+// GetRecentMetrics returns a user's metrics created since the given time
+func (db *DB) GetRecentMetrics(userID string, since time.Time) ([]models.TypingMetrics, error) {
+	return db.queryMetrics(
+		`SELECT `+metricsColumns+` FROM typing_metrics
+		WHERE user_id = $1 AND created_at >= $2 ORDER BY created_at DESC`,
+		userID, since,
+	)
+}
+
+// GetMetricsHistory returns all metrics of a user, oldest first
+func (db *DB) GetMetricsHistory(userID string) ([]models.TypingMetrics, error) {
+	return db.queryMetrics(
+		`SELECT `+metricsColumns+` FROM typing_metrics
+		WHERE user_id = $1 ORDER BY created_at`,
+		userID,
+	)
+}
+
+// GetUserIDsWithMetrics returns the IDs of all users who have recorded metrics
+func (db *DB) GetUserIDsWithMetrics() ([]string, error) {
+	rows, err := db.Query(`SELECT DISTINCT user_id FROM typing_metrics ORDER BY user_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+// GetMetricsByReviewStatus returns the most recent metrics in a review state
+func (db *DB) GetMetricsByReviewStatus(status string, limit int) ([]models.TypingMetrics, error) {
+	return db.queryMetrics(
+		`SELECT `+metricsColumns+` FROM typing_metrics
+		WHERE review_status = $1 ORDER BY created_at DESC LIMIT $2`,
+		status, limit,
+	)
+}
+
+// ReviewMetrics records an admin decision on flagged metrics
+func (db *DB) ReviewMetrics(id, status, reviewerID string) (*models.TypingMetrics, error) {
+	result, err := db.Exec(
+		`UPDATE typing_metrics SET review_status = $1, reviewed_by = $2, reviewed_at = $3 WHERE id = $4`,
+		status, reviewerID, time.Now(), id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return scanMetrics(db.QueryRow(`SELECT `+metricsColumns+` FROM typing_metrics WHERE id = $1`, id))
+}
+
 // GetUserMetrics returns all metrics for a user
 func (db *DB) GetUserMetrics(userID string) (*models.UserMetricsSummary, error) {
 	var summary models.UserMetricsSummary
@@ -544,127 +833,726 @@ func (db *DB) GetUserMetrics(userID string) (*models.UserMetricsSummary, error)
 	return &summary, nil
 }
 
-// UpdateUserStreak updates the user's daily streak
-func (db *DB) UpdateUserStreak(userID string) (int, error) {
-	var currentStreak int
-	var lastStreakAt *time.Time
-
-	err := db.QueryRow(
-		"SELECT current_streak, last_streak_at FROM users WHERE id = $1",
-		userID,
-	).Scan(&currentStreak, &lastStreakAt)
-
-	if err != nil {
-		return 0, err
-	}
-
-	now := time.Now().UTC()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
-
-	if lastStreakAt == nil {
-		// First time exercise
-		currentStreak = 1
-	} else {
-		lastDate := time.Date(lastStreakAt.Year(), lastStreakAt.Month(), lastStreakAt.Day(), 0, 0, 0, 0, time.UTC)
-
-		if lastDate.Equal(today) {
-			// Already updated today
-			return currentStreak, nil
-		}
-
-		yesterday := today.AddDate(0, 0, -1)
-		if lastDate.Equal(yesterday) {
-			// Streak continues
-			currentStreak++
-		} else {
-			// Streak broken
-			currentStreak = 1
-		}
+// CreateTypingSession starts a new typing session for a user and lesson
+func (db *DB) CreateTypingSession(userID, lessonID string) (*models.TypingSession, error) {
+	session := models.TypingSession{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		LessonID:  lessonID,
+		StartedAt: time.Now().UTC().Truncate(time.Millisecond),
 	}
 
-	_, err = db.Exec(
-		"UPDATE users SET current_streak = $1, last_streak_at = $2, updated_at = $3 WHERE id = $4",
-		currentStreak, now, now, userID,
-	)
-
-	return currentStreak, err
-}
-
-// --- Badge Management ---
-
-// CreateBadge creates a new badge
-func (db *DB) CreateBadge(name, color string) (*models.Badge, error) {
-	id := uuid.New().String()
-	now := time.Now()
-
 	_, err := db.Exec(
-		`INSERT INTO badges (id, name, color, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)`,
-		id, name, color, now, now,
+		`INSERT INTO typing_sessions (id, user_id, lesson_id, started_at) VALUES ($1, $2, $3, $4)`,
+		session.ID, session.UserID, session.LessonID, session.StartedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
-
-	return &models.Badge{
-		ID:        id,
-		Name:      name,
-		Color:     color,
-		CreatedAt: now,
-		UpdatedAt: now,
-	}, nil
+	return &session, nil
 }
 
-// GetBadgeByID returns a badge by ID
-func (db *DB) GetBadgeByID(id string) (*models.Badge, error) {
-	var badge models.Badge
+// GetTypingSession returns a typing session by ID
+func (db *DB) GetTypingSession(id string) (*models.TypingSession, error) {
+	var session models.TypingSession
 	err := db.QueryRow(
-		`SELECT id, name, color, created_at, updated_at
-		FROM badges WHERE id = $1`,
+		`SELECT id, user_id, lesson_id, started_at, finished_at FROM typing_sessions WHERE id = $1`,
 		id,
-	).Scan(&badge.ID, &badge.Name, &badge.Color, &badge.CreatedAt, &badge.UpdatedAt)
-
+	).Scan(&session.ID, &session.UserID, &session.LessonID, &session.StartedAt, &session.FinishedAt)
 	if err != nil {
 		return nil, err
 	}
+	return &session, nil
+}
 
-	return &badge, nil
+// FinishTypingSession marks a session as finished. It returns sql.ErrNoRows
+// if the session does not exist or was already finished, so a session can
+// only ever be scored once.
+func (db *DB) FinishTypingSession(id string, finishedAt time.Time) error {
+	result, err := db.Exec(
+		`UPDATE typing_sessions SET finished_at = $1 WHERE id = $2 AND finished_at IS NULL`,
+		finishedAt, id,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
 }
 
-// GetBadgeByName returns a badge by name
-func (db *DB) GetBadgeByName(name string) (*models.Badge, error) {
-	var badge models.Badge
-	err := db.QueryRow(
-		`SELECT id, name, color, created_at, updated_at
-		FROM badges WHERE name = $1`,
-		name,
-	).Scan(&badge.ID, &badge.Name, &badge.Color, &badge.CreatedAt, &badge.UpdatedAt)
+// CreateAuthSession stores a new login session
+func (db *DB) CreateAuthSession(session models.AuthSession) error {
+	_, err := db.Exec(
+		`INSERT INTO sessions (id, user_id, refresh_token_hash, access_token_id, user_agent, ip_address,
+			created_at, last_used_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		session.ID, session.UserID, session.RefreshTokenHash, nullIfEmpty(session.AccessTokenID),
+		nullIfEmpty(session.UserAgent), nullIfEmpty(session.IPAddress),
+		session.CreatedAt, session.LastUsedAt, session.ExpiresAt,
+	)
+	return err
+}
+
+// authSessionColumns lists the sessions columns read by scanAuthSession
+const authSessionColumns = `id, user_id, refresh_token_hash, access_token_id, user_agent, ip_address,
+	created_at, last_used_at, expires_at, revoked_at`
 
+// scanAuthSession reads a sessions row selected with authSessionColumns
+func scanAuthSession(scanner interface{ Scan(...interface{}) error }) (*models.AuthSession, error) {
+	var session models.AuthSession
+	var accessTokenID, userAgent, ipAddress sql.NullString
+	err := scanner.Scan(&session.ID, &session.UserID, &session.RefreshTokenHash, &accessTokenID,
+		&userAgent, &ipAddress, &session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt, &session.RevokedAt)
 	if err != nil {
 		return nil, err
 	}
+	session.AccessTokenID = accessTokenID.String
+	session.UserAgent = userAgent.String
+	session.IPAddress = ipAddress.String
+	return &session, nil
+}
 
-	return &badge, nil
+// GetAuthSession returns a login session by ID
+func (db *DB) GetAuthSession(id string) (*models.AuthSession, error) {
+	return scanAuthSession(db.QueryRow(`SELECT `+authSessionColumns+` FROM sessions WHERE id = $1`, id))
 }
 
-// GetAllBadges returns all badges
-func (db *DB) GetAllBadges() ([]models.Badge, error) {
+// ListAuthSessions returns a user's live login sessions, most recently used first
+func (db *DB) ListAuthSessions(userID string) ([]models.AuthSession, error) {
 	rows, err := db.Query(
-		`SELECT id, name, color, created_at, updated_at
-		FROM badges ORDER BY created_at`,
+		`SELECT `+authSessionColumns+` FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY COALESCE(last_used_at, created_at) DESC`,
+		userID, time.Now().UTC(),
 	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var badges []models.Badge
+	sessions := []models.AuthSession{}
 	for rows.Next() {
-		var badge models.Badge
-		if err := rows.Scan(&badge.ID, &badge.Name, &badge.Color, &badge.CreatedAt, &badge.UpdatedAt); err != nil {
+		session, err := scanAuthSession(rows)
+		if err != nil {
 			return nil, err
 		}
-		badges = append(badges, badge)
+		sessions = append(sessions, *session)
+	}
+	return sessions, rows.Err()
+}
+
+// TouchAuthSession records that a login session was used from the given
+// user agent and IP address
+func (db *DB) TouchAuthSession(id, userAgent, ipAddress string, at time.Time) error {
+	_, err := db.Exec(
+		`UPDATE sessions SET last_used_at = $1, user_agent = $2, ip_address = $3
+		WHERE id = $4 AND revoked_at IS NULL`,
+		at, nullIfEmpty(userAgent), nullIfEmpty(ipAddress), id,
+	)
+	return err
+}
+
+// RotateAuthSession replaces a session's refresh token. It returns
+// sql.ErrNoRows unless oldHash is still the current token of a live session,
+// so two requests racing with the same token cannot both succeed.
+func (db *DB) RotateAuthSession(id, oldHash, newHash, accessTokenID string, expiresAt time.Time) error {
+	result, err := db.Exec(
+		`UPDATE sessions SET refresh_token_hash = $1, access_token_id = $2, expires_at = $3
+		WHERE id = $4 AND refresh_token_hash = $5 AND revoked_at IS NULL`,
+		newHash, accessTokenID, expiresAt, id, oldHash,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// RevokeAuthSession revokes a login session and denylists its latest access
+// token until denyUntil. It returns sql.ErrNoRows if the session does not
+// exist or was already revoked.
+func (db *DB) RevokeAuthSession(id string, denyUntil time.Time) error {
+	var accessTokenID sql.NullString
+	err := db.QueryRow(
+		`SELECT access_token_id FROM sessions WHERE id = $1 AND revoked_at IS NULL`, id,
+	).Scan(&accessTokenID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	result, err := db.Exec(
+		`UPDATE sessions SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL`, now, id,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	if accessTokenID.Valid {
+		_, err = db.Exec(
+			`INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+			accessTokenID.String, denyUntil,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Expired tokens are rejected anyway, so their entries can go
+	_, err = db.Exec(`DELETE FROM revoked_tokens WHERE expires_at < $1`, now)
+	return err
+}
+
+// IsTokenRevoked reports whether an access token is on the denylist
+func (db *DB) IsTokenRevoked(jti string) (bool, error) {
+	var exists bool
+	err := db.QueryRow(
+		`SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = $1)`, jti,
+	).Scan(&exists)
+	return exists, err
+}
+
+// CreateUserToken stores a user token, replacing the user's unused tokens
+// for the same purpose so only the latest email works
+func (db *DB) CreateUserToken(token models.UserToken) error {
+	_, err := db.Exec(
+		`DELETE FROM user_tokens WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`,
+		token.UserID, token.Purpose,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(
+		`INSERT INTO user_tokens (token_hash, user_id, purpose, created_at, expires_at) VALUES ($1, $2, $3, $4, $5)`,
+		token.Hash, token.UserID, token.Purpose, token.CreatedAt, token.ExpiresAt,
+	)
+	return err
+}
+
+// ConsumeUserToken marks a token as used and returns it. It returns
+// sql.ErrNoRows unless the token exists for that purpose, is unused and has
+// not expired, so a token only ever works once.
+func (db *DB) ConsumeUserToken(hash, purpose string, now time.Time) (*models.UserToken, error) {
+	res, err := db.Exec(
+		`UPDATE user_tokens SET used_at = $1
+		WHERE token_hash = $2 AND purpose = $3 AND used_at IS NULL AND expires_at > $1`,
+		now, hash, purpose,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	var token models.UserToken
+	err = db.QueryRow(
+		`SELECT token_hash, user_id, purpose, created_at, expires_at, used_at FROM user_tokens WHERE token_hash = $1`,
+		hash,
+	).Scan(&token.Hash, &token.UserID, &token.Purpose, &token.CreatedAt, &token.ExpiresAt, &token.UsedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetTOTP returns a user's TOTP second factor, enabled or pending
+func (db *DB) GetTOTP(userID string) (*models.TOTPConfig, error) {
+	var secret sql.NullString
+	var cfg models.TOTPConfig
+	err := db.QueryRow(
+		`SELECT totp_secret, totp_enabled_at, totp_last_step FROM users WHERE id = $1`,
+		userID,
+	).Scan(&secret, &cfg.EnabledAt, &cfg.LastStep)
+	if err != nil {
+		return nil, err
+	}
+	if !secret.Valid {
+		return nil, sql.ErrNoRows
+	}
+	cfg.Secret = secret.String
+	return &cfg, nil
+}
+
+// SetTOTPSecret starts a TOTP enrollment, replacing any pending one. It
+// returns sql.ErrNoRows if the user already has TOTP enabled.
+func (db *DB) SetTOTPSecret(userID, secret string) error {
+	res, err := db.Exec(
+		`UPDATE users SET totp_secret = $1, totp_last_step = 0 WHERE id = $2 AND totp_enabled_at IS NULL`,
+		secret, userID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// EnableTOTP completes a pending enrollment once a code for step was
+// accepted. It returns sql.ErrNoRows if there is no pending enrollment.
+func (db *DB) EnableTOTP(userID string, step int64, at time.Time) error {
+	res, err := db.Exec(
+		`UPDATE users SET totp_enabled_at = $1, totp_last_step = $2
+		WHERE id = $3 AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL`,
+		at, step, userID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// UseTOTPStep records that a code for step was accepted. It returns
+// sql.ErrNoRows if that step or a later one was already used, so concurrent
+// requests cannot both log in with the same code.
+func (db *DB) UseTOTPStep(userID string, step int64) error {
+	res, err := db.Exec(
+		`UPDATE users SET totp_last_step = $1 WHERE id = $2 AND totp_enabled_at IS NOT NULL AND totp_last_step < $1`,
+		step, userID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DisableTOTP removes a user's second factor and recovery codes
+func (db *DB) DisableTOTP(userID string) error {
+	_, err := db.Exec(
+		`UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0 WHERE id = $1`,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, userID)
+	return err
+}
+
+// ReplaceRecoveryCodes stores a new set of recovery codes, invalidating the
+// previous ones
+func (db *DB) ReplaceRecoveryCodes(userID string, hashes []string, at time.Time) error {
+	if _, err := db.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		_, err := db.Exec(
+			`INSERT INTO recovery_codes (code_hash, user_id, created_at) VALUES ($1, $2, $3)`,
+			hash, userID, at,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UseRecoveryCode marks a recovery code as used. It returns sql.ErrNoRows
+// unless the code belongs to the user and is unused.
+func (db *DB) UseRecoveryCode(userID, hash string, at time.Time) error {
+	res, err := db.Exec(
+		`UPDATE recovery_codes SET used_at = $1 WHERE code_hash = $2 AND user_id = $3 AND used_at IS NULL`,
+		at, hash, userID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// CountRecoveryCodes returns how many unused recovery codes a user has left
+func (db *DB) CountRecoveryCodes(userID string) (int, error) {
+	var count int
+	err := db.QueryRow(
+		`SELECT COUNT(*) FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL`,
+		userID,
+	).Scan(&count)
+	return count, err
+}
+
+// GetUserIdentity returns the identity a provider knows by subject
+func (db *DB) GetUserIdentity(provider, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	var username, email sql.NullString
+	err := db.QueryRow(
+		`SELECT provider, subject, user_id, username, email, created_at FROM user_identities
+		WHERE provider = $1 AND subject = $2`,
+		provider, subject,
+	).Scan(&identity.Provider, &identity.Subject, &identity.UserID, &username, &email, &identity.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	identity.Username = username.String
+	identity.Email = email.String
+	return &identity, nil
+}
+
+// ListUserIdentities returns the identities linked to a user
+func (db *DB) ListUserIdentities(userID string) ([]models.UserIdentity, error) {
+	rows, err := db.Query(
+		`SELECT provider, subject, user_id, username, email, created_at FROM user_identities
+		WHERE user_id = $1 ORDER BY provider`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []models.UserIdentity{}
+	for rows.Next() {
+		var identity models.UserIdentity
+		var username, email sql.NullString
+		if err := rows.Scan(&identity.Provider, &identity.Subject, &identity.UserID, &username, &email, &identity.CreatedAt); err != nil {
+			return nil, err
+		}
+		identity.Username = username.String
+		identity.Email = email.String
+		identities = append(identities, identity)
+	}
+	return identities, rows.Err()
+}
+
+// CreateUserIdentity links an identity to a user. A user has at most one
+// identity per provider.
+func (db *DB) CreateUserIdentity(identity models.UserIdentity) error {
+	_, err := db.Exec(
+		`INSERT INTO user_identities (provider, subject, user_id, username, email, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		identity.Provider, identity.Subject, identity.UserID,
+		nullIfEmpty(identity.Username), nullIfEmpty(identity.Email), identity.CreatedAt,
+	)
+	return err
+}
+
+// DeleteUserIdentity unlinks a user's identity at a provider
+func (db *DB) DeleteUserIdentity(userID, provider string) error {
+	res, err := db.Exec(
+		`DELETE FROM user_identities WHERE user_id = $1 AND provider = $2`,
+		userID, provider,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetVerifiedGitHubUsername stores a GitHub username that came from a GitHub
+// login. Whoever else claims it loses it: GitHub usernames are
+// case-insensitive and can change hands, so the latest login is right.
+func (db *DB) SetVerifiedGitHubUsername(userID, username string, at time.Time) error {
+	_, err := db.Exec(
+		`UPDATE users SET github_username = NULL, github_verified_at = NULL, updated_at = $1
+		WHERE LOWER(github_username) = LOWER($2) AND id <> $3`,
+		at, username, userID,
+	)
+	if err != nil {
+		return err
+	}
+	res, err := db.Exec(
+		`UPDATE users SET github_username = $1, github_verified_at = $2, updated_at = $2 WHERE id = $3`,
+		username, at, userID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ClearGitHubVerification marks a user's GitHub username as merely claimed
+func (db *DB) ClearGitHubVerification(userID string) error {
+	_, err := db.Exec(`UPDATE users SET github_verified_at = NULL WHERE id = $1`, userID)
+	return err
+}
+
+// CreatePersonalAccessToken stores a new personal access token
+func (db *DB) CreatePersonalAccessToken(token models.PersonalAccessToken) error {
+	_, err := db.Exec(
+		`INSERT INTO personal_access_tokens (id, user_id, name, token_hash, scopes, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		token.ID, token.UserID, token.Name, token.Hash, strings.Join(token.Scopes, ","), token.CreatedAt, token.ExpiresAt,
+	)
+	return err
+}
+
+// ListPersonalAccessTokens returns a user's personal access tokens, newest
+// first
+func (db *DB) ListPersonalAccessTokens(userID string) ([]models.PersonalAccessToken, error) {
+	rows, err := db.Query(
+		`SELECT id, user_id, name, token_hash, scopes, created_at, expires_at, last_used_at
+		FROM personal_access_tokens WHERE user_id = $1 ORDER BY created_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []models.PersonalAccessToken{}
+	for rows.Next() {
+		token, err := scanPersonalAccessToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *token)
+	}
+	return tokens, rows.Err()
+}
+
+// GetPersonalAccessTokenByHash returns the personal access token with a hash
+func (db *DB) GetPersonalAccessTokenByHash(hash string) (*models.PersonalAccessToken, error) {
+	return scanPersonalAccessToken(db.QueryRow(
+		`SELECT id, user_id, name, token_hash, scopes, created_at, expires_at, last_used_at
+		FROM personal_access_tokens WHERE token_hash = $1`,
+		hash,
+	))
+}
+
+func scanPersonalAccessToken(scanner interface{ Scan(...interface{}) error }) (*models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+	var scopes string
+	err := scanner.Scan(&token.ID, &token.UserID, &token.Name, &token.Hash, &scopes, &token.CreatedAt, &token.ExpiresAt, &token.LastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+	token.Scopes = strings.Split(scopes, ",")
+	return &token, nil
+}
+
+// TouchPersonalAccessToken records when a personal access token was last used
+func (db *DB) TouchPersonalAccessToken(id string, at time.Time) error {
+	_, err := db.Exec(`UPDATE personal_access_tokens SET last_used_at = $1 WHERE id = $2`, at, id)
+	return err
+}
+
+// DeletePersonalAccessToken revokes one of a user's personal access tokens
+func (db *DB) DeletePersonalAccessToken(userID, id string) error {
+	res, err := db.Exec(`DELETE FROM personal_access_tokens WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeletePersonalAccessTokens revokes all of a user's personal access tokens
+func (db *DB) DeletePersonalAccessTokens(userID string) error {
+	_, err := db.Exec(`DELETE FROM personal_access_tokens WHERE user_id = $1`, userID)
+	return err
+}
+
+// UpdateUserStreak updates the user's daily streak
+func (db *DB) UpdateUserStreak(userID string) (int, error) {
+	var currentStreak int
+	var lastStreakAt *time.Time
+
+	err := db.QueryRow(
+		"SELECT current_streak, last_streak_at FROM users WHERE id = $1",
+		userID,
+	).Scan(&currentStreak, &lastStreakAt)
+
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	currentStreak, changed := nextStreak(currentStreak, lastStreakAt, now)
+	if !changed {
+		// Already updated today
+		return currentStreak, nil
+	}
+
+	_, err = db.Exec(
+		"UPDATE users SET current_streak = $1, last_streak_at = $2, updated_at = $3 WHERE id = $4",
+		currentStreak, now, now, userID,
+	)
+
+	return currentStreak, err
+}
+
+// nextStreak computes the streak after practising at now, given the current
+// streak and the last time it was extended. changed is false when the streak
+// was already extended today.
+func nextStreak(currentStreak int, lastStreakAt *time.Time, now time.Time) (streak int, changed bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	if lastStreakAt == nil {
+		// First time exercise
+		return 1, true
+	}
+
+	lastDate := time.Date(lastStreakAt.Year(), lastStreakAt.Month(), lastStreakAt.Day(), 0, 0, 0, 0, time.UTC)
+	if lastDate.Equal(today) {
+		return currentStreak, false
+	}
+
+	yesterday := today.AddDate(0, 0, -1)
+	if lastDate.Equal(yesterday) {
+		// Streak continues
+		return currentStreak + 1, true
+	}
+	// Streak broken
+	return 1, true
+}
+
+// --- Badge Management ---
+
+// badgeColumns are the columns scanBadge reads, in order
+const badgeColumns = `badges.id, badges.name, badges.color, badges.description, badges.description_en,
+	badges.icon, badges.rarity, badges.category, badges.hidden, badges.criteria, badges.criteria_en,
+	badges.created_at, badges.updated_at`
+
+// scanBadge reads a badge selected with badgeColumns, followed by extra
+// columns scanned into dest
+func scanBadge(scanner interface{ Scan(...interface{}) error }, dest ...interface{}) (*models.Badge, error) {
+	var b models.Badge
+	err := scanner.Scan(append([]interface{}{&b.ID, &b.Name, &b.Color, &b.Description, &b.DescriptionEn,
+		&b.Icon, &b.Rarity, &b.Category, &b.Hidden, &b.Criteria, &b.CriteriaEn,
+		&b.CreatedAt, &b.UpdatedAt}, dest...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// CreateBadge creates a new badge from the given fields; ID and timestamps
+// are set here
+func (db *DB) CreateBadge(badge models.Badge) (*models.Badge, error) {
+	badge.ID = uuid.New().String()
+	badge.CreatedAt = time.Now()
+	badge.UpdatedAt = badge.CreatedAt
+	if badge.Rarity == "" {
+		badge.Rarity = models.RarityCommon
+	}
+
+	_, err := db.Exec(
+		`INSERT INTO badges (id, name, color, description, description_en, icon, rarity, category, hidden, criteria, criteria_en, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		badge.ID, badge.Name, badge.Color, badge.Description, badge.DescriptionEn, badge.Icon, badge.Rarity,
+		badge.Category, badge.Hidden, badge.Criteria, badge.CriteriaEn, badge.CreatedAt, badge.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &badge, nil
+}
+
+// UpdateBadge replaces the editable fields of a badge
+func (db *DB) UpdateBadge(badge models.Badge) error {
+	result, err := db.Exec(
+		`UPDATE badges SET name = $1, color = $2, description = $3, description_en = $4, icon = $5, rarity = $6,
+			category = $7, hidden = $8, criteria = $9, criteria_en = $10, updated_at = $11
+		WHERE id = $12`,
+		badge.Name, badge.Color, badge.Description, badge.DescriptionEn, badge.Icon, badge.Rarity,
+		badge.Category, badge.Hidden, badge.Criteria, badge.CriteriaEn, time.Now(), badge.ID,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteBadge deletes a badge; its assignments go with it
+func (db *DB) DeleteBadge(id string) error {
+	result, err := db.Exec(`DELETE FROM badges WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetBadgeByID returns a badge by ID
+func (db *DB) GetBadgeByID(id string) (*models.Badge, error) {
+	return scanBadge(db.QueryRow(`SELECT `+badgeColumns+` FROM badges WHERE id = $1`, id))
+}
+
+// GetBadgeByName returns a badge by name
+func (db *DB) GetBadgeByName(name string) (*models.Badge, error) {
+	return scanBadge(db.QueryRow(`SELECT `+badgeColumns+` FROM badges WHERE name = $1`, name))
+}
+
+// GetAllBadges returns all badges
+func (db *DB) GetAllBadges() ([]models.Badge, error) {
+	rows, err := db.Query(`SELECT ` + badgeColumns + ` FROM badges ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var badges []models.Badge
+	for rows.Next() {
+		badge, err := scanBadge(rows)
+		if err != nil {
+			return nil, err
+		}
+		badges = append(badges, *badge)
 	}
 
 	return badges, nil
@@ -694,9 +1582,9 @@ func (db *DB) RemoveBadgeFromUser(userID, badgeID string) error {
 // GetUserBadges returns all badges for a user
 func (db *DB) GetUserBadges(userID string) ([]models.BadgeWithDetails, error) {
 	rows, err := db.Query(
-		`SELECT b.id, b.name, b.color, b.created_at, b.updated_at, ub.assigned_at
-		FROM badges b
-		INNER JOIN user_badges ub ON b.id = ub.badge_id
+		`SELECT `+badgeColumns+`, ub.assigned_at
+		FROM badges
+		INNER JOIN user_badges ub ON badges.id = ub.badge_id
 		WHERE ub.user_id = $1
 		ORDER BY ub.assigned_at`,
 		userID,
@@ -708,13 +1596,13 @@ func (db *DB) GetUserBadges(userID string) ([]models.BadgeWithDetails, error) {
 
 	var badges []models.BadgeWithDetails
 	for rows.Next() {
-		var badge models.Badge
 		var assignedAt time.Time
-		if err := rows.Scan(&badge.ID, &badge.Name, &badge.Color, &badge.CreatedAt, &badge.UpdatedAt, &assignedAt); err != nil {
+		badge, err := scanBadge(rows, &assignedAt)
+		if err != nil {
 			return nil, err
 		}
 		badges = append(badges, models.BadgeWithDetails{
-			Badge:      badge,
+			Badge:      *badge,
 			AssignedAt: assignedAt,
 		})
 	}
@@ -722,6 +1610,54 @@ func (db *DB) GetUserBadges(userID string) ([]models.BadgeWithDetails, error) {
 	return badges, nil
 }
 
+// RecordBadgeAudit stores an audit log entry for a badge mutation
+func (db *DB) RecordBadgeAudit(entry models.BadgeAuditEntry) error {
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	var targetUserID sql.NullString
+	if entry.TargetUserID != "" {
+		targetUserID = sql.NullString{String: entry.TargetUserID, Valid: true}
+	}
+
+	_, err := db.Exec(
+		`INSERT INTO badge_audit_log (id, actor_id, action, badge_id, target_user_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		entry.ID, entry.ActorID, entry.Action, entry.BadgeID, targetUserID, entry.CreatedAt,
+	)
+	return err
+}
+
+// GetBadgeAuditLog returns the most recent badge audit log entries
+func (db *DB) GetBadgeAuditLog(limit int) ([]models.BadgeAuditEntry, error) {
+	rows, err := db.Query(
+		`SELECT id, actor_id, action, badge_id, target_user_id, created_at
+		FROM badge_audit_log ORDER BY created_at DESC LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.BadgeAuditEntry
+	for rows.Next() {
+		var entry models.BadgeAuditEntry
+		var targetUserID sql.NullString
+		if err := rows.Scan(&entry.ID, &entry.ActorID, &entry.Action, &entry.BadgeID, &targetUserID, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entry.TargetUserID = targetUserID.String
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
 // GetUsersWithBadge returns all users who have a specific badge
 func (db *DB) GetUsersWithBadge(badgeID string) ([]string, error) {
 	rows, err := db.Query(
@@ -745,25 +1681,43 @@ func (db *DB) GetUsersWithBadge(badgeID string) ([]string, error) {
 	return userIDs, nil
 }
 
+// defaultBadges are created on startup if they don't exist
+var defaultBadges = []models.Badge{
+	{
+		Name: "Beta Tester", Color: "#FFD700", // Gold
+		Icon: "flask", Rarity: models.RarityRare, Category: "community",
+		Description: "Estuvo aquí desde la beta", DescriptionEn: "Was here since the beta",
+		Criteria: "Ser uno de los primeros 100 usuarios registrados", CriteriaEn: "Be one of the first 100 registered users",
+	},
+	{
+		Name: "Early Access", Color: "#C0C0C0", // Silver
+		Icon: "rocket", Rarity: models.RarityUncommon, Category: "community",
+		Description: "Se unió en el acceso anticipado", DescriptionEn: "Joined during early access",
+		Criteria: "Ser uno de los primeros 1000 usuarios registrados", CriteriaEn: "Be one of the first 1000 registered users",
+	},
+	{
+		Name: "Donator", Color: "#FF69B4", // Hot Pink
+		Icon: "heart", Rarity: models.RarityEpic, Category: "community",
+		Description: "Apoya el proyecto con una donación", DescriptionEn: "Supports the project with a donation",
+		Criteria: "Asignada por un administrador", CriteriaEn: "Assigned by an admin",
+	},
+	{
+		Name: "Contributor", Color: "#32CD32", // Lime Green
+		Icon: "code", Rarity: models.RarityEpic, Category: "community",
+		Description: "Contribuyó código o lecciones", DescriptionEn: "Contributed code or lessons",
+		Criteria: "Asignada por un administrador", CriteriaEn: "Assigned by an admin",
+	},
+}
+
 // InitializeBadges creates default badges if they don't exist
 func (db *DB) InitializeBadges() error {
-	badges := []struct {
-		name  string
-		color string
-	}{
-		{"Beta Tester", "#FFD700"},  // Gold
-		{"Early Access", "#C0C0C0"}, // Silver
-		{"Donator", "#FF69B4"},      // Hot Pink
-		{"Contributor", "#32CD32"},  // Lime Green
-	}
-
-	for _, b := range badges {
-		_, err := db.GetBadgeByName(b.name)
+	for _, b := range defaultBadges {
+		_, err := db.GetBadgeByName(b.Name)
 		if err == sql.ErrNoRows {
 			// Badge doesn't exist, create it
-			_, err := db.CreateBadge(b.name, b.color)
+			_, err := db.CreateBadge(b)
 			if err != nil {
-				return fmt.Errorf("failed to create badge %s: %w", b.name, err)
+				return fmt.Errorf("failed to create badge %s: %w", b.Name, err)
 			}
 		} else if err != nil {
 			return err
@@ -773,13 +1727,14 @@ func (db *DB) InitializeBadges() error {
 	return nil
 }
 
-// assignAutomaticBadges assigns badges based on user registration order
-func (db *DB) assignAutomaticBadges(user *models.User) error {
+// AssignAutomaticBadges assigns badges based on user registration order
+func (db *DB) AssignAutomaticBadges(userID string) error {
 	// Count registered users (non-guests) created before this user
 	var count int
 	err := db.QueryRow(
-		`SELECT COUNT(*) FROM users WHERE is_guest = false AND created_at < $1`,
-		user.CreatedAt,
+		`SELECT COUNT(*) FROM users WHERE is_guest = false
+			AND created_at < (SELECT created_at FROM users WHERE id = $1)`,
+		userID,
 	).Scan(&count)
 
 	if err != nil {
@@ -792,7 +1747,7 @@ func (db *DB) assignAutomaticBadges(user *models.User) error {
 		if err != nil {
 			return err
 		}
-		if err := db.AssignBadgeToUser(user.ID, badge.ID); err != nil {
+		if err := db.AssignBadgeToUser(userID, badge.ID); err != nil {
 			return err
 		}
 	}
@@ -803,7 +1758,7 @@ func (db *DB) assignAutomaticBadges(user *models.User) error {
 		if err != nil {
 			return err
 		}
-		if err := db.AssignBadgeToUser(user.ID, badge.ID); err != nil {
+		if err := db.AssignBadgeToUser(userID, badge.ID); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"database/sql"
+	"regexp"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// dialect identifies the SQL flavour spoken by the underlying database
+type dialect string
+
+const (
+	dialectPostgres dialect = "postgres"
+	dialectSQLite   dialect = "sqlite"
+)
+
+// SQLiteURLPrefix selects the SQLite backend when DATABASE_URL starts with it,
+// e.g. sqlite://typer.db or sqlite:///var/lib/typer/typer.db
+const SQLiteURLPrefix = "sqlite://"
+
+// parseDatabaseURL returns the dialect, driver name and driver DSN for a
+// DATABASE_URL value
+func parseDatabaseURL(databaseURL string) (dialect, string, string) {
+	if strings.HasPrefix(databaseURL, SQLiteURLPrefix) {
+		path := strings.TrimPrefix(databaseURL, SQLiteURLPrefix)
+		params := "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+		if strings.Contains(path, "?") {
+			return dialectSQLite, "sqlite", "file:" + path + "&" + params
+		}
+		return dialectSQLite, "sqlite", "file:" + path + "?" + params
+	}
+	return dialectPostgres, "postgres", databaseURL
+}
+
+// Queries in this package are written with PostgreSQL-style $N placeholders;
+// for SQLite they are rewritten to the equivalent ?N form.
+var placeholderRe = regexp.MustCompile(`\$(\d+)`)
+
+func (db *DB) rebind(query string) string {
+	if db.dialect != dialectSQLite {
+		return query
+	}
+	return placeholderRe.ReplaceAllString(query, "?$1")
+}
+
+// bindArgs normalizes arguments for the dialect. SQLite stores timestamps as
+// text, so they are converted to UTC to keep range comparisons correct.
+func (db *DB) bindArgs(args []interface{}) []interface{} {
+	if db.dialect != dialectSQLite {
+		return args
+	}
+	bound := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case time.Time:
+			bound[i] = v.UTC()
+		case *time.Time:
+			if v != nil {
+				bound[i] = v.UTC()
+			} else {
+				bound[i] = nil
+			}
+		default:
+			bound[i] = arg
+		}
+	}
+	return bound
+}
+
+// Exec executes a query written for PostgreSQL on any supported dialect
+func (db *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return db.DB.Exec(db.rebind(query), db.bindArgs(args)...)
+}
+
+// Query runs a query written for PostgreSQL on any supported dialect
+func (db *DB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return db.DB.Query(db.rebind(query), db.bindArgs(args)...)
+}
+
+// QueryRow runs a single-row query written for PostgreSQL on any supported dialect
+func (db *DB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return db.DB.QueryRow(db.rebind(query), db.bindArgs(args)...)
+}
@@ -0,0 +1,1498 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+// ErrDuplicate is returned by the in-memory store when a unique constraint
+// would be violated
+var ErrDuplicate = errors.New("duplicate key")
+
+// memoryUser is a user record together with its credentials
+type memoryUser struct {
+	user             models.User
+	passwordHash     string
+	githubUsername   string
+	githubVerifiedAt *time.Time
+	failedLogins     int
+	lockedUntil      *time.Time
+	totpSecret       string
+	totpEnabledAt    *time.Time
+	totpLastStep     int64
+	recoveryCodes    map[string]bool // hash -> used
+}
+
+// record returns a copy of the user with the fields kept alongside it
+func (u *memoryUser) record() models.User {
+	user := u.user
+	if u.githubUsername != "" {
+		github := u.githubUsername
+		user.GitHubUsername = &github
+		user.GitHubVerified = u.githubVerifiedAt != nil
+	}
+	return user
+}
+
+// MemoryDB is a thread-safe, non-persistent Store used for demos and tests
+type MemoryDB struct {
+	mu             sync.RWMutex
+	users          map[string]*memoryUser
+	progress       map[string]*models.Progress // keyed by userID + "/" + lessonID
+	metrics        []models.TypingMetrics
+	sessions       map[string]*models.TypingSession
+	authSessions   map[string]*models.AuthSession
+	revokedTokens  map[string]time.Time // jti -> expiry
+	userTokens     map[string]*models.UserToken
+	identities     map[string]*models.UserIdentity // keyed by provider + "/" + subject
+	personalTokens map[string]*models.PersonalAccessToken
+	points         []models.PointTransaction
+	tierEvents     []models.TierEvent
+	badges         map[string]*models.Badge
+	userBadges     map[string]map[string]time.Time // userID -> badgeID -> assignedAt
+	badgeAudit     []models.BadgeAuditEntry
+}
+
+// NewMemoryDB creates an empty in-memory store
+func NewMemoryDB() *MemoryDB {
+	return &MemoryDB{
+		users:          make(map[string]*memoryUser),
+		progress:       make(map[string]*models.Progress),
+		sessions:       make(map[string]*models.TypingSession),
+		authSessions:   make(map[string]*models.AuthSession),
+		revokedTokens:  make(map[string]time.Time),
+		userTokens:     make(map[string]*models.UserToken),
+		identities:     make(map[string]*models.UserIdentity),
+		personalTokens: make(map[string]*models.PersonalAccessToken),
+		badges:         make(map[string]*models.Badge),
+		userBadges:     make(map[string]map[string]time.Time),
+	}
+}
+
+// Close implements Store; there is nothing to release
+func (m *MemoryDB) Close() error {
+	return nil
+}
+
+// --- Users ---
+
+// CreateGuestUser creates a new guest user
+func (m *MemoryDB) CreateGuestUser() (*models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	username := fmt.Sprintf("guest_%07d", now.UnixNano()%10000000)
+	if m.findByUsername(username) != nil {
+		return nil, ErrDuplicate
+	}
+
+	u := &memoryUser{user: models.User{
+		ID:          uuid.New().String(),
+		Username:    username,
+		DisplayName: username,
+		IsGuest:     true,
+		Role:        models.RoleUser,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}}
+	m.users[u.user.ID] = u
+
+	user := u.user
+	return &user, nil
+}
+
+// CreateRegisteredUser creates a new registered user
+func (m *MemoryDB) CreateRegisteredUser(username, email, passwordHash, displayName, githubUsername string) (*models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkUnique("", username, email, githubUsername); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	u := &memoryUser{
+		user: models.User{
+			ID:          uuid.New().String(),
+			Username:    username,
+			Email:       &email,
+			DisplayName: displayName,
+			IsGuest:     false,
+			Role:        models.RoleUser,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		},
+		passwordHash:   passwordHash,
+		githubUsername: githubUsername,
+	}
+	m.users[u.user.ID] = u
+
+	user := u.user
+	user.GitHubUsername = &githubUsername
+	return &user, nil
+}
+
+// GetUserByID returns a user by ID, including badges
+func (m *MemoryDB) GetUserByID(id string) (*models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	u, ok := m.users[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	user := u.record()
+	user.Badges = m.userBadgeDetails(id)
+	return &user, nil
+}
+
+// GetUserByUsername returns a user by username
+func (m *MemoryDB) GetUserByUsername(username string) (*models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	u := m.findByUsername(username)
+	if u == nil {
+		return nil, sql.ErrNoRows
+	}
+	user := u.record()
+	return &user, nil
+}
+
+// GetUserByEmail returns a user by email
+func (m *MemoryDB) GetUserByEmail(email string) (*models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, u := range m.users {
+		if u.user.Email != nil && *u.user.Email == email {
+			user := u.record()
+			return &user, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// GetPasswordHash returns the password hash for a user
+func (m *MemoryDB) GetPasswordHash(username string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	u := m.findByUsername(username)
+	if u == nil || u.passwordHash == "" {
+		return "", sql.ErrNoRows
+	}
+	return u.passwordHash, nil
+}
+
+// SetPasswordHash replaces a user's password hash
+func (m *MemoryDB) SetPasswordHash(userID, passwordHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	u.passwordHash = passwordHash
+	u.user.UpdatedAt = time.Now()
+	return nil
+}
+
+// GetLockedUntil returns the end of a user's login lockout, nil if none was set
+func (m *MemoryDB) GetLockedUntil(userID string) (*time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	u, ok := m.users[userID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return u.lockedUntil, nil
+}
+
+// RecordFailedLogin counts a failed login and, if lockedUntil is set, locks
+// the account until then
+func (m *MemoryDB) RecordFailedLogin(userID string, lockedUntil *time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if u, ok := m.users[userID]; ok {
+		u.failedLogins++
+		if lockedUntil != nil {
+			u.lockedUntil = lockedUntil
+		}
+	}
+	return nil
+}
+
+// ResetFailedLogins clears a user's failed logins and lockout
+func (m *MemoryDB) ResetFailedLogins(userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if u, ok := m.users[userID]; ok {
+		u.failedLogins = 0
+		u.lockedUntil = nil
+	}
+	return nil
+}
+
+// MarkEmailVerified records that a user verified their current email address
+func (m *MemoryDB) MarkEmailVerified(userID string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok || u.user.Email == nil || *u.user.Email == "" {
+		return sql.ErrNoRows
+	}
+	u.user.EmailVerifiedAt = &at
+	u.user.UpdatedAt = at
+	return nil
+}
+
+// ReleaseUnverifiedEmail removes an email from the account holding it if that
+// account never verified it and its verification link has expired
+func (m *MemoryDB) ReleaseUnverifiedEmail(email string, now time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, u := range m.users {
+		if u.user.Email == nil || *u.user.Email != email || u.user.EmailVerifiedAt != nil {
+			continue
+		}
+		for _, t := range m.userTokens {
+			if t.UserID == u.user.ID && t.Purpose == models.TokenPurposeEmailVerification &&
+				t.UsedAt == nil && !t.ExpiresAt.After(now) {
+				u.user.Email = nil
+				u.user.UpdatedAt = now
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// ConvertGuestToRegistered converts a guest user to a registered user
+func (m *MemoryDB) ConvertGuestToRegistered(guestID, username, email, passwordHash, displayName, githubUsername string) (*models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[guestID]
+	if !ok || !u.user.IsGuest {
+		return nil, sql.ErrNoRows
+	}
+	if err := m.checkUnique(guestID, username, email, githubUsername); err != nil {
+		return nil, err
+	}
+
+	u.user.Username = username
+	u.user.Email = &email
+	u.user.DisplayName = displayName
+	u.user.IsGuest = false
+	u.user.UpdatedAt = time.Now()
+	u.passwordHash = passwordHash
+	u.githubUsername = githubUsername
+
+	user := u.user
+	user.GitHubUsername = &githubUsername
+	return &user, nil
+}
+
+// SetUserRole changes the role of a user
+func (m *MemoryDB) SetUserRole(userID, role string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	u.user.Role = role
+	u.user.UpdatedAt = time.Now()
+	return nil
+}
+
+// UpdateUserStreak updates the user's daily streak
+func (m *MemoryDB) UpdateUserStreak(userID string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+
+	now := time.Now().UTC()
+	streak, changed := nextStreak(u.user.CurrentStreak, u.user.LastStreakAt, now)
+	if !changed {
+		return streak, nil
+	}
+	u.user.CurrentStreak = streak
+	u.user.LastStreakAt = &now
+	u.user.UpdatedAt = now
+	return streak, nil
+}
+
+func (m *MemoryDB) findByUsername(username string) *memoryUser {
+	for _, u := range m.users {
+		if u.user.Username == username {
+			return u
+		}
+	}
+	return nil
+}
+
+// checkUnique mirrors the UNIQUE constraints of the users table
+func (m *MemoryDB) checkUnique(selfID, username, email, githubUsername string) error {
+	for id, u := range m.users {
+		if id == selfID {
+			continue
+		}
+		if u.user.Username == username {
+			return fmt.Errorf("%w: username %q", ErrDuplicate, username)
+		}
+		if email != "" && u.user.Email != nil && *u.user.Email == email {
+			return fmt.Errorf("%w: email %q", ErrDuplicate, email)
+		}
+		if githubUsername != "" && u.githubUsername == githubUsername {
+			return fmt.Errorf("%w: github username %q", ErrDuplicate, githubUsername)
+		}
+	}
+	return nil
+}
+
+// --- Progress ---
+
+// SaveProgress saves or updates a user's progress on a lesson
+func (m *MemoryDB) SaveProgress(req models.ProgressRequest) (*models.Progress, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	key := req.UserID + "/" + req.LessonID
+	p, ok := m.progress[key]
+	if !ok {
+		p = &models.Progress{
+			ID:           uuid.New().String(),
+			UserID:       req.UserID,
+			LessonID:     req.LessonID,
+			Completed:    req.Completed,
+			BestWPM:      req.WPM,
+			BestAccuracy: req.Accuracy,
+			Attempts:     1,
+			LastAttempt:  now,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+		m.progress[key] = p
+		result := *p
+		return &result, nil
+	}
+
+	if req.WPM > p.BestWPM {
+		p.BestWPM = req.WPM
+	}
+	if req.Accuracy > p.BestAccuracy {
+		p.BestAccuracy = req.Accuracy
+	}
+	p.Completed = p.Completed || req.Completed
+	p.Attempts++
+	p.LastAttempt = now
+	p.UpdatedAt = now
+
+	result := *p
+	return &result, nil
+}
+
+// GetUserProgress returns all progress records for a user
+func (m *MemoryDB) GetUserProgress(userID string) ([]models.Progress, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var results []models.Progress
+	for _, p := range m.progress {
+		if p.UserID == userID {
+			results = append(results, *p)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].UpdatedAt.After(results[j].UpdatedAt)
+	})
+	return results, nil
+}
+
+// GetLessonProgress returns progress for a specific user and lesson
+func (m *MemoryDB) GetLessonProgress(userID, lessonID string) (*models.Progress, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	p, ok := m.progress[userID+"/"+lessonID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	result := *p
+	return &result, nil
+}
+
+// --- Metrics ---
+
+// SaveMetrics saves typing metrics for a session
+func (m *MemoryDB) SaveMetrics(req models.MetricsRequest) (*models.TypingMetrics, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := models.ReviewStatusOK
+	if req.FlagReason != "" {
+		status = models.ReviewStatusFlagged
+	}
+
+	metrics := models.TypingMetrics{
+		ID:             uuid.New().String(),
+		UserID:         req.UserID,
+		LessonID:       req.LessonID,
+		WPM:            req.WPM,
+		Accuracy:       req.Accuracy,
+		TotalTime:      req.TotalTime,
+		TotalChars:     req.TotalChars,
+		CorrectChars:   req.CorrectChars,
+		IncorrectChars: req.IncorrectChars,
+		CommonErrors:   append([]models.ErrorEntry(nil), req.CommonErrors...),
+		ReviewStatus:   status,
+		FlagReason:     req.FlagReason,
+		SessionID:      req.SessionID,
+		CreatedAt:      time.Now(),
+	}
+	m.metrics = append(m.metrics, metrics)
+	return &metrics, nil
+}
+
+// GetUserMetrics returns aggregated metrics for a user
+func (m *MemoryDB) GetUserMetrics(userID string) (*models.UserMetricsSummary, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	summary := &models.UserMetricsSummary{UserID: userID}
+	var totalWPM, totalAccuracy float64
+	for _, tm := range m.metrics {
+		if tm.UserID != userID {
+			continue
+		}
+		summary.TotalSessions++
+		totalWPM += tm.WPM
+		totalAccuracy += tm.Accuracy
+		summary.TotalTime += tm.TotalTime
+		if tm.WPM > summary.BestWPM {
+			summary.BestWPM = tm.WPM
+		}
+	}
+	if summary.TotalSessions > 0 {
+		summary.AverageWPM = totalWPM / float64(summary.TotalSessions)
+		summary.AverageAccuracy = totalAccuracy / float64(summary.TotalSessions)
+	}
+	return summary, nil
+}
+
+// GetRecentMetrics returns a user's metrics created since the given time
+func (m *MemoryDB) GetRecentMetrics(userID string, since time.Time) ([]models.TypingMetrics, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var result []models.TypingMetrics
+	for i := len(m.metrics) - 1; i >= 0; i-- {
+		tm := m.metrics[i]
+		if tm.UserID == userID && !tm.CreatedAt.Before(since) {
+			result = append(result, tm)
+		}
+	}
+	return result, nil
+}
+
+// GetMetricsHistory returns all metrics of a user, oldest first
+func (m *MemoryDB) GetMetricsHistory(userID string) ([]models.TypingMetrics, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var result []models.TypingMetrics
+	for _, tm := range m.metrics {
+		if tm.UserID == userID {
+			result = append(result, tm)
+		}
+	}
+	return result, nil
+}
+
+// GetUserIDsWithMetrics returns the IDs of all users who have recorded metrics
+func (m *MemoryDB) GetUserIDsWithMetrics() ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var userIDs []string
+	for _, tm := range m.metrics {
+		if !seen[tm.UserID] {
+			seen[tm.UserID] = true
+			userIDs = append(userIDs, tm.UserID)
+		}
+	}
+	sort.Strings(userIDs)
+	return userIDs, nil
+}
+
+// GetMetricsByReviewStatus returns the most recent metrics in a review state
+func (m *MemoryDB) GetMetricsByReviewStatus(status string, limit int) ([]models.TypingMetrics, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var result []models.TypingMetrics
+	for i := len(m.metrics) - 1; i >= 0 && len(result) < limit; i-- {
+		if m.metrics[i].ReviewStatus == status {
+			result = append(result, m.metrics[i])
+		}
+	}
+	return result, nil
+}
+
+// ReviewMetrics records an admin decision on flagged metrics
+func (m *MemoryDB) ReviewMetrics(id, status, reviewerID string) (*models.TypingMetrics, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.metrics {
+		if m.metrics[i].ID != id {
+			continue
+		}
+		now := time.Now()
+		m.metrics[i].ReviewStatus = status
+		m.metrics[i].ReviewedBy = reviewerID
+		m.metrics[i].ReviewedAt = &now
+		result := m.metrics[i]
+		return &result, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+// --- Typing sessions ---
+
+// CreateTypingSession starts a new typing session for a user and lesson
+func (m *MemoryDB) CreateTypingSession(userID, lessonID string) (*models.TypingSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session := &models.TypingSession{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		LessonID:  lessonID,
+		StartedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+	m.sessions[session.ID] = session
+
+	copied := *session
+	return &copied, nil
+}
+
+// GetTypingSession returns a typing session by ID
+func (m *MemoryDB) GetTypingSession(id string) (*models.TypingSession, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, ok := m.sessions[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *session
+	return &copied, nil
+}
+
+// FinishTypingSession marks a session as finished, failing with
+// sql.ErrNoRows if it does not exist or was already finished
+func (m *MemoryDB) FinishTypingSession(id string, finishedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.sessions[id]
+	if !ok || session.FinishedAt != nil {
+		return sql.ErrNoRows
+	}
+	session.FinishedAt = &finishedAt
+	return nil
+}
+
+// --- Login sessions ---
+
+// CreateAuthSession stores a new login session
+func (m *MemoryDB) CreateAuthSession(session models.AuthSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.authSessions[session.ID]; exists {
+		return ErrDuplicate
+	}
+	m.authSessions[session.ID] = &session
+	return nil
+}
+
+// GetAuthSession returns a login session by ID
+func (m *MemoryDB) GetAuthSession(id string) (*models.AuthSession, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, ok := m.authSessions[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *session
+	return &copied, nil
+}
+
+// ListAuthSessions returns a user's live login sessions, most recently used first
+func (m *MemoryDB) ListAuthSessions(userID string) ([]models.AuthSession, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now().UTC()
+	sessions := []models.AuthSession{}
+	for _, session := range m.authSessions {
+		if session.UserID == userID && session.RevokedAt == nil && session.ExpiresAt.After(now) {
+			sessions = append(sessions, *session)
+		}
+	}
+	lastUsed := func(s models.AuthSession) time.Time {
+		if s.LastUsedAt != nil {
+			return *s.LastUsedAt
+		}
+		return s.CreatedAt
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return lastUsed(sessions[i]).After(lastUsed(sessions[j]))
+	})
+	return sessions, nil
+}
+
+// TouchAuthSession records that a login session was used from the given
+// user agent and IP address
+func (m *MemoryDB) TouchAuthSession(id, userAgent, ipAddress string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.authSessions[id]
+	if !ok || session.RevokedAt != nil {
+		return nil
+	}
+	session.LastUsedAt = &at
+	session.UserAgent = userAgent
+	session.IPAddress = ipAddress
+	return nil
+}
+
+// RotateAuthSession replaces a session's refresh token, failing with
+// sql.ErrNoRows unless oldHash is the current token of a live session
+func (m *MemoryDB) RotateAuthSession(id, oldHash, newHash, accessTokenID string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.authSessions[id]
+	if !ok || session.RevokedAt != nil || session.RefreshTokenHash != oldHash {
+		return sql.ErrNoRows
+	}
+	session.RefreshTokenHash = newHash
+	session.AccessTokenID = accessTokenID
+	session.ExpiresAt = expiresAt
+	return nil
+}
+
+// RevokeAuthSession revokes a login session and denylists its latest access
+// token until denyUntil
+func (m *MemoryDB) RevokeAuthSession(id string, denyUntil time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.authSessions[id]
+	if !ok || session.RevokedAt != nil {
+		return sql.ErrNoRows
+	}
+	now := time.Now().UTC()
+	session.RevokedAt = &now
+	if session.AccessTokenID != "" {
+		m.revokedTokens[session.AccessTokenID] = denyUntil
+	}
+
+	for jti, expiresAt := range m.revokedTokens {
+		if expiresAt.Before(now) {
+			delete(m.revokedTokens, jti)
+		}
+	}
+	return nil
+}
+
+// IsTokenRevoked reports whether an access token is on the denylist
+func (m *MemoryDB) IsTokenRevoked(jti string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, revoked := m.revokedTokens[jti]
+	return revoked, nil
+}
+
+// --- User tokens ---
+
+// CreateUserToken stores a user token, replacing the user's unused tokens
+// for the same purpose
+func (m *MemoryDB) CreateUserToken(token models.UserToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for hash, t := range m.userTokens {
+		if t.UserID == token.UserID && t.Purpose == token.Purpose && t.UsedAt == nil {
+			delete(m.userTokens, hash)
+		}
+	}
+	m.userTokens[token.Hash] = &token
+	return nil
+}
+
+// ConsumeUserToken marks a token as used and returns it, failing with
+// sql.ErrNoRows unless it is unused, unexpired and for that purpose
+func (m *MemoryDB) ConsumeUserToken(hash, purpose string, now time.Time) (*models.UserToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	token, ok := m.userTokens[hash]
+	if !ok || token.Purpose != purpose || token.UsedAt != nil || !token.ExpiresAt.After(now) {
+		return nil, sql.ErrNoRows
+	}
+	token.UsedAt = &now
+	copied := *token
+	return &copied, nil
+}
+
+// --- Second factors ---
+
+// GetTOTP returns a user's TOTP second factor, enabled or pending
+func (m *MemoryDB) GetTOTP(userID string) (*models.TOTPConfig, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	u, ok := m.users[userID]
+	if !ok || u.totpSecret == "" {
+		return nil, sql.ErrNoRows
+	}
+	return &models.TOTPConfig{Secret: u.totpSecret, EnabledAt: u.totpEnabledAt, LastStep: u.totpLastStep}, nil
+}
+
+// SetTOTPSecret starts a TOTP enrollment, replacing any pending one
+func (m *MemoryDB) SetTOTPSecret(userID, secret string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok || u.totpEnabledAt != nil {
+		return sql.ErrNoRows
+	}
+	u.totpSecret = secret
+	u.totpLastStep = 0
+	return nil
+}
+
+// EnableTOTP completes a pending enrollment once a code for step was accepted
+func (m *MemoryDB) EnableTOTP(userID string, step int64, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok || u.totpSecret == "" || u.totpEnabledAt != nil {
+		return sql.ErrNoRows
+	}
+	u.totpEnabledAt = &at
+	u.totpLastStep = step
+	u.user.MFAEnabled = true
+	return nil
+}
+
+// UseTOTPStep records that a code for step was accepted, failing with
+// sql.ErrNoRows if that step or a later one was already used
+func (m *MemoryDB) UseTOTPStep(userID string, step int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok || u.totpEnabledAt == nil || u.totpLastStep >= step {
+		return sql.ErrNoRows
+	}
+	u.totpLastStep = step
+	return nil
+}
+
+// DisableTOTP removes a user's second factor and recovery codes
+func (m *MemoryDB) DisableTOTP(userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if u, ok := m.users[userID]; ok {
+		u.totpSecret = ""
+		u.totpEnabledAt = nil
+		u.totpLastStep = 0
+		u.recoveryCodes = nil
+		u.user.MFAEnabled = false
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes stores a new set of recovery codes, invalidating the
+// previous ones
+func (m *MemoryDB) ReplaceRecoveryCodes(userID string, hashes []string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	u.recoveryCodes = make(map[string]bool, len(hashes))
+	for _, hash := range hashes {
+		u.recoveryCodes[hash] = false
+	}
+	return nil
+}
+
+// UseRecoveryCode marks a recovery code as used, failing with sql.ErrNoRows
+// unless it belongs to the user and is unused
+func (m *MemoryDB) UseRecoveryCode(userID, hash string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if used, exists := u.recoveryCodes[hash]; !exists || used {
+		return sql.ErrNoRows
+	}
+	u.recoveryCodes[hash] = true
+	return nil
+}
+
+// CountRecoveryCodes returns how many unused recovery codes a user has left
+func (m *MemoryDB) CountRecoveryCodes(userID string) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	count := 0
+	if u, ok := m.users[userID]; ok {
+		for _, used := range u.recoveryCodes {
+			if !used {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+// --- Identities ---
+
+// GetUserIdentity returns the identity a provider knows by subject
+func (m *MemoryDB) GetUserIdentity(provider, subject string) (*models.UserIdentity, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	identity, ok := m.identities[provider+"/"+subject]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	cp := *identity
+	return &cp, nil
+}
+
+// ListUserIdentities returns the identities linked to a user
+func (m *MemoryDB) ListUserIdentities(userID string) ([]models.UserIdentity, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	identities := []models.UserIdentity{}
+	for _, identity := range m.identities {
+		if identity.UserID == userID {
+			identities = append(identities, *identity)
+		}
+	}
+	sort.Slice(identities, func(i, j int) bool { return identities[i].Provider < identities[j].Provider })
+	return identities, nil
+}
+
+// CreateUserIdentity links an identity to a user. A user has at most one
+// identity per provider.
+func (m *MemoryDB) CreateUserIdentity(identity models.UserIdentity) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[identity.UserID]; !ok {
+		return sql.ErrNoRows
+	}
+	key := identity.Provider + "/" + identity.Subject
+	if _, ok := m.identities[key]; ok {
+		return fmt.Errorf("%w: identity %q", ErrDuplicate, key)
+	}
+	for _, existing := range m.identities {
+		if existing.UserID == identity.UserID && existing.Provider == identity.Provider {
+			return fmt.Errorf("%w: %s identity of user %s", ErrDuplicate, identity.Provider, identity.UserID)
+		}
+	}
+	m.identities[key] = &identity
+	return nil
+}
+
+// DeleteUserIdentity unlinks a user's identity at a provider
+func (m *MemoryDB) DeleteUserIdentity(userID, provider string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, identity := range m.identities {
+		if identity.UserID == userID && identity.Provider == provider {
+			delete(m.identities, key)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+// SetVerifiedGitHubUsername stores a GitHub username that came from a GitHub
+// login, taking it away from whoever else claims it
+func (m *MemoryDB) SetVerifiedGitHubUsername(userID, username string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	for id, other := range m.users {
+		if id != userID && strings.EqualFold(other.githubUsername, username) {
+			other.githubUsername = ""
+			other.githubVerifiedAt = nil
+			other.user.UpdatedAt = at
+		}
+	}
+	u.githubUsername = username
+	u.githubVerifiedAt = &at
+	u.user.UpdatedAt = at
+	return nil
+}
+
+// ClearGitHubVerification marks a user's GitHub username as merely claimed
+func (m *MemoryDB) ClearGitHubVerification(userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if u, ok := m.users[userID]; ok {
+		u.githubVerifiedAt = nil
+	}
+	return nil
+}
+
+// --- Personal access tokens ---
+
+// CreatePersonalAccessToken stores a new personal access token
+func (m *MemoryDB) CreatePersonalAccessToken(token models.PersonalAccessToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[token.UserID]; !ok {
+		return sql.ErrNoRows
+	}
+	for _, t := range m.personalTokens {
+		if t.Hash == token.Hash {
+			return fmt.Errorf("%w: personal access token", ErrDuplicate)
+		}
+	}
+	token.Scopes = append([]string(nil), token.Scopes...)
+	m.personalTokens[token.ID] = &token
+	return nil
+}
+
+// ListPersonalAccessTokens returns a user's personal access tokens, newest
+// first
+func (m *MemoryDB) ListPersonalAccessTokens(userID string) ([]models.PersonalAccessToken, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tokens := []models.PersonalAccessToken{}
+	for _, t := range m.personalTokens {
+		if t.UserID == userID {
+			tokens = append(tokens, *t)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].CreatedAt.After(tokens[j].CreatedAt) })
+	return tokens, nil
+}
+
+// GetPersonalAccessTokenByHash returns the personal access token with a hash
+func (m *MemoryDB) GetPersonalAccessTokenByHash(hash string) (*models.PersonalAccessToken, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, t := range m.personalTokens {
+		if t.Hash == hash {
+			token := *t
+			return &token, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// TouchPersonalAccessToken records when a personal access token was last used
+func (m *MemoryDB) TouchPersonalAccessToken(id string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if t, ok := m.personalTokens[id]; ok {
+		t.LastUsedAt = &at
+	}
+	return nil
+}
+
+// DeletePersonalAccessToken revokes one of a user's personal access tokens
+func (m *MemoryDB) DeletePersonalAccessToken(userID, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.personalTokens[id]
+	if !ok || t.UserID != userID {
+		return sql.ErrNoRows
+	}
+	delete(m.personalTokens, id)
+	return nil
+}
+
+// DeletePersonalAccessTokens revokes all of a user's personal access tokens
+func (m *MemoryDB) DeletePersonalAccessTokens(userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, t := range m.personalTokens {
+		if t.UserID == userID {
+			delete(m.personalTokens, id)
+		}
+	}
+	return nil
+}
+
+// --- Points ---
+
+// SavePointTransaction saves a point earning event
+func (m *MemoryDB) SavePointTransaction(pt models.PointTransaction) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.points = append(m.points, pt)
+	return nil
+}
+
+// GetUserPointTransactions returns all point transactions of a user, oldest
+// first
+func (m *MemoryDB) GetUserPointTransactions(userID string) ([]models.PointTransaction, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var result []models.PointTransaction
+	for _, pt := range m.points {
+		if pt.UserID == userID {
+			result = append(result, pt)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+// GetLeaderboard returns the leaderboard for a specific period, leaving out
+// users without a verified email when verifiedOnly is set
+func (m *MemoryDB) GetLeaderboard(startDate, endDate time.Time, limit int, verifiedOnly bool) ([]models.LeaderboardEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	totals := m.rankedTotals(startDate, endDate, verifiedOnly)
+
+	var entries []models.LeaderboardEntry
+	for userID, total := range totals {
+		u, ok := m.users[userID]
+		if !ok {
+			continue
+		}
+		entry := models.LeaderboardEntry{
+			UserID:   userID,
+			Username: u.user.Username,
+			Points:   total,
+		}
+		if u.githubUsername != "" {
+			github := u.githubUsername
+			entry.GitHubUsername = &github
+			entry.GitHubVerified = u.githubVerifiedAt != nil
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Points != entries[j].Points {
+			return entries[i].Points > entries[j].Points
+		}
+		return entries[i].Username < entries[j].Username
+	})
+	if limit >= 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	for i := range entries {
+		entries[i].Rank = i + 1
+		entries[i].Badges = m.userBadgeDetails(entries[i].UserID)
+	}
+	return entries, nil
+}
+
+// GetUserPoints returns total points for a user in a period
+func (m *MemoryDB) GetUserPoints(userID string, startDate, endDate time.Time) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.pointTotals(startDate, endDate)[userID], nil
+}
+
+// GetUserRank returns the rank of a user in a specific period among the users
+// GetLeaderboard would list with the same verifiedOnly
+func (m *MemoryDB) GetUserRank(userID string, startDate, endDate time.Time, verifiedOnly bool) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	totals := m.rankedTotals(startDate, endDate, verifiedOnly)
+	userPoints := totals[userID]
+
+	rank := 1
+	for id, total := range totals {
+		if id != userID && total > userPoints {
+			rank++
+		}
+	}
+	return rank, nil
+}
+
+// rankedTotals is pointTotals limited to users with a verified email when
+// verifiedOnly is set
+func (m *MemoryDB) rankedTotals(startDate, endDate time.Time, verifiedOnly bool) map[string]int {
+	totals := m.pointTotals(startDate, endDate)
+	if verifiedOnly {
+		for userID := range totals {
+			if u, ok := m.users[userID]; !ok || u.user.EmailVerifiedAt == nil {
+				delete(totals, userID)
+			}
+		}
+	}
+	return totals
+}
+
+// pointTotals sums points per user for transactions within [startDate, endDate],
+// leaving out points earned by flagged or rejected metrics
+func (m *MemoryDB) pointTotals(startDate, endDate time.Time) map[string]int {
+	excluded := make(map[string]bool)
+	for _, tm := range m.metrics {
+		if tm.ReviewStatus == models.ReviewStatusFlagged || tm.ReviewStatus == models.ReviewStatusRejected {
+			excluded[tm.ID] = true
+		}
+	}
+
+	totals := make(map[string]int)
+	for _, pt := range m.points {
+		if pt.CreatedAt.Before(startDate) || pt.CreatedAt.After(endDate) || excluded[pt.MetricsID] {
+			continue
+		}
+		totals[pt.UserID] += pt.Points
+	}
+	return totals
+}
+
+// --- Tiers ---
+
+// RecordTierEvent saves a promotion and reports false if the user already
+// reached the tier
+func (m *MemoryDB) RecordTierEvent(event models.TierEvent) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, e := range m.tierEvents {
+		if e.UserID == event.UserID && e.Tier == event.Tier {
+			return false, nil
+		}
+	}
+	m.tierEvents = append(m.tierEvents, event)
+	return true, nil
+}
+
+// GetTierEvents returns the promotions of a user, oldest first
+func (m *MemoryDB) GetTierEvents(userID string) ([]models.TierEvent, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var result []models.TierEvent
+	for _, e := range m.tierEvents {
+		if e.UserID == userID {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+// --- Badges ---
+
+// CreateBadge creates a new badge from the given fields; ID and timestamps
+// are set here
+func (m *MemoryDB) CreateBadge(badge models.Badge) (*models.Badge, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.findBadgeByName(badge.Name) != nil {
+		return nil, fmt.Errorf("%w: badge %q", ErrDuplicate, badge.Name)
+	}
+
+	badge.ID = uuid.New().String()
+	badge.CreatedAt = time.Now()
+	badge.UpdatedAt = badge.CreatedAt
+	if badge.Rarity == "" {
+		badge.Rarity = models.RarityCommon
+	}
+	stored := badge
+	m.badges[badge.ID] = &stored
+
+	return &badge, nil
+}
+
+// UpdateBadge replaces the editable fields of a badge
+func (m *MemoryDB) UpdateBadge(badge models.Badge) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, ok := m.badges[badge.ID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if other := m.findBadgeByName(badge.Name); other != nil && other.ID != badge.ID {
+		return fmt.Errorf("%w: badge %q", ErrDuplicate, badge.Name)
+	}
+
+	badge.CreatedAt = b.CreatedAt
+	badge.UpdatedAt = time.Now()
+	*b = badge
+	return nil
+}
+
+// DeleteBadge deletes a badge; its assignments go with it
+func (m *MemoryDB) DeleteBadge(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.badges[id]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(m.badges, id)
+	for _, badges := range m.userBadges {
+		delete(badges, id)
+	}
+	return nil
+}
+
+// GetBadgeByID returns a badge by ID
+func (m *MemoryDB) GetBadgeByID(id string) (*models.Badge, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b, ok := m.badges[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	result := *b
+	return &result, nil
+}
+
+// GetBadgeByName returns a badge by name
+func (m *MemoryDB) GetBadgeByName(name string) (*models.Badge, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b := m.findBadgeByName(name)
+	if b == nil {
+		return nil, sql.ErrNoRows
+	}
+	result := *b
+	return &result, nil
+}
+
+// GetAllBadges returns all badges ordered by creation time
+func (m *MemoryDB) GetAllBadges() ([]models.Badge, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var badges []models.Badge
+	for _, b := range m.badges {
+		badges = append(badges, *b)
+	}
+	sort.Slice(badges, func(i, j int) bool {
+		return badges[i].CreatedAt.Before(badges[j].CreatedAt)
+	})
+	return badges, nil
+}
+
+// AssignBadgeToUser assigns a badge to a user; assigning twice is a no-op
+func (m *MemoryDB) AssignBadgeToUser(userID, badgeID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.assignBadge(userID, badgeID)
+}
+
+// RemoveBadgeFromUser removes a badge from a user
+func (m *MemoryDB) RemoveBadgeFromUser(userID, badgeID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.userBadges[userID], badgeID)
+	return nil
+}
+
+// GetUserBadges returns all badges for a user
+func (m *MemoryDB) GetUserBadges(userID string) ([]models.BadgeWithDetails, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.userBadgeDetails(userID), nil
+}
+
+// GetUsersWithBadge returns all users who have a specific badge
+func (m *MemoryDB) GetUsersWithBadge(badgeID string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var userIDs []string
+	for userID, badges := range m.userBadges {
+		if _, ok := badges[badgeID]; ok {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	sort.Strings(userIDs)
+	return userIDs, nil
+}
+
+// InitializeBadges creates default badges if they don't exist
+func (m *MemoryDB) InitializeBadges() error {
+	for _, b := range defaultBadges {
+		if _, err := m.GetBadgeByName(b.Name); err == sql.ErrNoRows {
+			if _, err := m.CreateBadge(b); err != nil {
+				return fmt.Errorf("failed to create badge %s: %w", b.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// RecordBadgeAudit stores an audit log entry for a badge mutation
+func (m *MemoryDB) RecordBadgeAudit(entry models.BadgeAuditEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	m.badgeAudit = append(m.badgeAudit, entry)
+	return nil
+}
+
+// GetBadgeAuditLog returns the most recent badge audit log entries
+func (m *MemoryDB) GetBadgeAuditLog(limit int) ([]models.BadgeAuditEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var entries []models.BadgeAuditEntry
+	for i := len(m.badgeAudit) - 1; i >= 0 && len(entries) < limit; i-- {
+		entries = append(entries, m.badgeAudit[i])
+	}
+	return entries, nil
+}
+
+func (m *MemoryDB) findBadgeByName(name string) *models.Badge {
+	for _, b := range m.badges {
+		if b.Name == name {
+			return b
+		}
+	}
+	return nil
+}
+
+func (m *MemoryDB) assignBadge(userID, badgeID string) error {
+	if _, ok := m.users[userID]; !ok {
+		return fmt.Errorf("unknown user %s", userID)
+	}
+	if _, ok := m.badges[badgeID]; !ok {
+		return fmt.Errorf("unknown badge %s", badgeID)
+	}
+	if m.userBadges[userID] == nil {
+		m.userBadges[userID] = make(map[string]time.Time)
+	}
+	if _, ok := m.userBadges[userID][badgeID]; !ok {
+		m.userBadges[userID][badgeID] = time.Now()
+	}
+	return nil
+}
+
+func (m *MemoryDB) userBadgeDetails(userID string) []models.BadgeWithDetails {
+	var badges []models.BadgeWithDetails
+	for badgeID, assignedAt := range m.userBadges[userID] {
+		b, ok := m.badges[badgeID]
+		if !ok {
+			continue
+		}
+		badges = append(badges, models.BadgeWithDetails{Badge: *b, AssignedAt: assignedAt})
+	}
+	sort.Slice(badges, func(i, j int) bool {
+		return badges[i].AssignedAt.Before(badges[j].AssignedAt)
+	})
+	return badges
+}
+
+// AssignAutomaticBadges assigns badges based on user registration order
+func (m *MemoryDB) AssignAutomaticBadges(userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	count := 0
+	for _, other := range m.users {
+		if !other.user.IsGuest && other.user.CreatedAt.Before(u.user.CreatedAt) {
+			count++
+		}
+	}
+
+	if count < 100 {
+		if b := m.findBadgeByName("Beta Tester"); b != nil {
+			_ = m.assignBadge(u.user.ID, b.ID)
+		}
+	}
+	if count < 1000 {
+		if b := m.findBadgeByName("Early Access"); b != nil {
+			_ = m.assignBadge(u.user.ID, b.ID)
+		}
+	}
+	return nil
+}
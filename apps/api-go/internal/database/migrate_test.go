@@ -0,0 +1,143 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/typing-code-learn/api-go/internal/gamification"
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+// noLessons is a lesson lookup that finds nothing, so every run is scored
+// with the default strategy
+type noLessons struct{}
+
+func (noLessons) Get(string) (*models.Lesson, bool) { return nil, false }
+
+// TestMigrationLinksLegacyPoints writes points the way the API did before
+// they were linked to metrics, then checks a recompute can replay them
+func TestMigrationLinksLegacyPoints(t *testing.T) {
+	db, err := Open(SQLiteURLPrefix + filepath.Join(t.TempDir(), "typer.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	migrations, err := loadMigrations(db.dialect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.MigrateUp(); err != nil {
+		t.Fatal(err)
+	}
+	// Back to before the points ledger migration
+	steps := 0
+	for _, m := range migrations {
+		if m.Version >= 15 {
+			steps++
+		}
+	}
+	if _, err := db.MigrateDown(steps); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec(`INSERT INTO users (id, username, display_name) VALUES ('u1', 'ada', 'Ada')`); err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	runs := []struct {
+		id     string
+		at     time.Time
+		points int
+	}{
+		{"m1", day, 150},
+		{"m2", day.Add(time.Hour), 150},
+		// Too inaccurate to earn anything
+		{"m3", day.Add(2 * time.Hour), 0},
+	}
+	for _, run := range runs {
+		accuracy := 100.0
+		if run.points == 0 {
+			accuracy = 50
+		}
+		if _, err := db.Exec(
+			`INSERT INTO typing_metrics (id, user_id, lesson_id, wpm, accuracy, total_time, total_chars, correct_chars, incorrect_chars, created_at)
+			VALUES ($1, 'u1', 'go-basics-01', 50, $2, 60, 100, 100, 0, $3)`,
+			run.id, accuracy, run.at,
+		); err != nil {
+			t.Fatal(err)
+		}
+		if run.points == 0 {
+			continue
+		}
+		if _, err := db.Exec(
+			`INSERT INTO point_transactions (id, user_id, source_id, points, reason, created_at)
+			VALUES ($1, 'u1', 'go-basics-01', $2, 'lesson_complete', $3)`,
+			"pt-"+run.id, run.points, run.at,
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Points that no run earned stay unlinked
+	if _, err := db.Exec(
+		`INSERT INTO point_transactions (id, user_id, source_id, points, reason, created_at)
+		VALUES ('pt-badge', 'u1', 'badge', 10, 'badge_awarded', $1)`,
+		day,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.MigrateUp(); err != nil {
+		t.Fatal(err)
+	}
+
+	ledger, err := db.GetUserPointTransactions("u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	linked := make(map[string]string)
+	for _, pt := range ledger {
+		linked[pt.ID] = pt.MetricsID
+	}
+	want := map[string]string{"pt-m1": "m1", "pt-m2": "m2", "pt-badge": ""}
+	for id, metricsID := range want {
+		if linked[id] != metricsID {
+			t.Errorf("transaction %s is linked to %q, want %q", id, linked[id], metricsID)
+		}
+	}
+
+	history, err := db.GetMetricsHistory("u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The legacy formula is what recorded the points, so it has nothing to correct
+	legacy, err := gamification.BuiltinRegistry(gamification.StrategyVersionLegacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if corrections := gamification.Recompute(legacy, noLessons{}, history, ledger); len(corrections) != 0 {
+		t.Errorf("legacy recompute wrote %+v, want nothing", corrections)
+	}
+
+	// The current strategies correct both runs that earned points
+	corrections := gamification.Recompute(gamification.DefaultRegistry(), noLessons{}, history, ledger)
+	corrected := make(map[string]bool)
+	for _, pt := range corrections {
+		corrected[pt.MetricsID] = true
+		if err := db.SavePointTransaction(pt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !corrected["m1"] || !corrected["m2"] || len(corrected) != 2 {
+		t.Errorf("got corrections for runs %v, want m1 and m2", corrected)
+	}
+
+	if ledger, err = db.GetUserPointTransactions("u1"); err != nil {
+		t.Fatal(err)
+	}
+	if again := gamification.Recompute(gamification.DefaultRegistry(), noLessons{}, history, ledger); len(again) != 0 {
+		t.Errorf("second recompute wrote %+v, want nothing", again)
+	}
+}
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS user_badges;
+DROP TABLE IF EXISTS badges;
+DROP TABLE IF EXISTS point_transactions;
+DROP TABLE IF EXISTS typing_metrics;
+DROP TABLE IF EXISTS progress;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,50 @@
+-- Baseline schema. Uses IF NOT EXISTS so deployments created before
+-- versioned migrations adopt it without changes.
+CREATE TABLE IF NOT EXISTS users (
+	id TEXT PRIMARY KEY, username TEXT UNIQUE NOT NULL, email TEXT UNIQUE,
+	password_hash TEXT, display_name TEXT NOT NULL, github_username TEXT UNIQUE,
+	is_guest BOOLEAN DEFAULT FALSE, current_streak INTEGER DEFAULT 0,
+	last_streak_at TIMESTAMPTZ, created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);
+CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
+
+CREATE TABLE IF NOT EXISTS progress (
+	id TEXT PRIMARY KEY, user_id TEXT NOT NULL, lesson_id TEXT NOT NULL,
+	completed BOOLEAN DEFAULT FALSE, best_wpm REAL DEFAULT 0,
+	best_accuracy REAL DEFAULT 0, attempts INTEGER DEFAULT 0,
+	last_attempt TIMESTAMPTZ, created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(), UNIQUE(user_id, lesson_id)
+);
+
+CREATE TABLE IF NOT EXISTS typing_metrics (
+	id TEXT PRIMARY KEY, user_id TEXT NOT NULL, lesson_id TEXT NOT NULL,
+	wpm REAL NOT NULL, accuracy REAL NOT NULL, total_time REAL NOT NULL,
+	total_chars INTEGER NOT NULL, correct_chars INTEGER NOT NULL,
+	incorrect_chars INTEGER NOT NULL, common_errors TEXT DEFAULT '[]',
+	created_at TIMESTAMPTZ DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_progress_user ON progress(user_id);
+CREATE INDEX IF NOT EXISTS idx_metrics_user ON typing_metrics(user_id);
+
+CREATE TABLE IF NOT EXISTS point_transactions (
+	id TEXT PRIMARY KEY, user_id TEXT NOT NULL, source_id TEXT,
+	points INTEGER NOT NULL, reason TEXT NOT NULL, created_at TIMESTAMPTZ DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_points_user ON point_transactions(user_id);
+CREATE INDEX IF NOT EXISTS idx_points_created_at ON point_transactions(created_at);
+
+CREATE TABLE IF NOT EXISTS badges (
+	id TEXT PRIMARY KEY, name TEXT UNIQUE NOT NULL, color TEXT NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT NOW(), updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS user_badges (
+	user_id TEXT NOT NULL, badge_id TEXT NOT NULL, assigned_at TIMESTAMPTZ DEFAULT NOW(),
+	PRIMARY KEY (user_id, badge_id),
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (badge_id) REFERENCES badges(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_user_badges_user ON user_badges(user_id);
+CREATE INDEX IF NOT EXISTS idx_user_badges_badge ON user_badges(badge_id);
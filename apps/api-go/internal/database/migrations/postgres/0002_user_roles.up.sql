@@ -0,0 +1,7 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user';
+
+CREATE TABLE IF NOT EXISTS badge_audit_log (
+	id TEXT PRIMARY KEY, actor_id TEXT NOT NULL, action TEXT NOT NULL,
+	badge_id TEXT NOT NULL, target_user_id TEXT, created_at TIMESTAMPTZ DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_badge_audit_created_at ON badge_audit_log(created_at);
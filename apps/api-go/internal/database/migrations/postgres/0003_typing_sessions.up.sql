@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS typing_sessions (
+	id TEXT PRIMARY KEY, user_id TEXT NOT NULL, lesson_id TEXT NOT NULL,
+	started_at TIMESTAMPTZ NOT NULL, finished_at TIMESTAMPTZ,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_typing_sessions_user ON typing_sessions(user_id);
@@ -0,0 +1,6 @@
+ALTER TABLE point_transactions DROP COLUMN IF EXISTS metrics_id;
+DROP INDEX IF EXISTS idx_metrics_review_status;
+ALTER TABLE typing_metrics DROP COLUMN IF EXISTS reviewed_at;
+ALTER TABLE typing_metrics DROP COLUMN IF EXISTS reviewed_by;
+ALTER TABLE typing_metrics DROP COLUMN IF EXISTS flag_reason;
+ALTER TABLE typing_metrics DROP COLUMN IF EXISTS review_status;
@@ -0,0 +1,9 @@
+ALTER TABLE typing_metrics ADD COLUMN IF NOT EXISTS review_status TEXT NOT NULL DEFAULT 'ok';
+ALTER TABLE typing_metrics ADD COLUMN IF NOT EXISTS flag_reason TEXT;
+ALTER TABLE typing_metrics ADD COLUMN IF NOT EXISTS reviewed_by TEXT;
+ALTER TABLE typing_metrics ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_metrics_review_status ON typing_metrics(review_status);
+
+-- Links points to the metrics that earned them, so flagged sessions can be
+-- left out of rankings
+ALTER TABLE point_transactions ADD COLUMN IF NOT EXISTS metrics_id TEXT;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS sessions (
+	id TEXT PRIMARY KEY, user_id TEXT NOT NULL,
+	refresh_token_hash TEXT NOT NULL, access_token_id TEXT,
+	created_at TIMESTAMPTZ NOT NULL, expires_at TIMESTAMPTZ NOT NULL, revoked_at TIMESTAMPTZ,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+	jti TEXT PRIMARY KEY, expires_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,4 @@
+-- Failed logins since the last successful one, and the temporary lockout
+-- they triggered
+ALTER TABLE users ADD COLUMN IF NOT EXISTS failed_login_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ;
@@ -0,0 +1,3 @@
+-- Marks metrics replayed from a typing session, whose numbers the server
+-- computed
+ALTER TABLE typing_metrics ADD COLUMN IF NOT EXISTS session_id TEXT;
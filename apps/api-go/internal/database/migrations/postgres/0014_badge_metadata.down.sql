@@ -0,0 +1,8 @@
+ALTER TABLE badges DROP COLUMN IF EXISTS criteria_en;
+ALTER TABLE badges DROP COLUMN IF EXISTS criteria;
+ALTER TABLE badges DROP COLUMN IF EXISTS hidden;
+ALTER TABLE badges DROP COLUMN IF EXISTS category;
+ALTER TABLE badges DROP COLUMN IF EXISTS rarity;
+ALTER TABLE badges DROP COLUMN IF EXISTS icon;
+ALTER TABLE badges DROP COLUMN IF EXISTS description_en;
+ALTER TABLE badges DROP COLUMN IF EXISTS description;
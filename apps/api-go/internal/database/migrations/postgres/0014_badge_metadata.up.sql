@@ -0,0 +1,9 @@
+-- Localized descriptions and criteria, display metadata and secret badges
+ALTER TABLE badges ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+ALTER TABLE badges ADD COLUMN IF NOT EXISTS description_en TEXT NOT NULL DEFAULT '';
+ALTER TABLE badges ADD COLUMN IF NOT EXISTS icon TEXT NOT NULL DEFAULT '';
+ALTER TABLE badges ADD COLUMN IF NOT EXISTS rarity TEXT NOT NULL DEFAULT 'common';
+ALTER TABLE badges ADD COLUMN IF NOT EXISTS category TEXT NOT NULL DEFAULT '';
+ALTER TABLE badges ADD COLUMN IF NOT EXISTS hidden BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE badges ADD COLUMN IF NOT EXISTS criteria TEXT NOT NULL DEFAULT '';
+ALTER TABLE badges ADD COLUMN IF NOT EXISTS criteria_en TEXT NOT NULL DEFAULT '';
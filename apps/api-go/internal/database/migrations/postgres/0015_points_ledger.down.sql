@@ -0,0 +1,2 @@
+ALTER TABLE point_transactions DROP COLUMN IF EXISTS strategy_version;
+ALTER TABLE point_transactions DROP COLUMN IF EXISTS strategy;
@@ -0,0 +1,11 @@
+-- Records which point strategy calculated each transaction. Points recorded
+-- before this migration all came from the original formula, version v1.
+ALTER TABLE point_transactions ADD COLUMN IF NOT EXISTS strategy TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE point_transactions ADD COLUMN IF NOT EXISTS strategy_version TEXT NOT NULL DEFAULT 'v1';
+
+-- Links points recorded before 0004 to the metrics that earned them, so that
+-- a recompute can replay them. They were written with the metrics' created_at.
+UPDATE point_transactions pt SET metrics_id = tm.id
+FROM typing_metrics tm
+WHERE pt.metrics_id IS NULL AND pt.reason = 'lesson_complete'
+	AND tm.user_id = pt.user_id AND tm.lesson_id = pt.source_id AND tm.created_at = pt.created_at;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS badge_audit_log;
+ALTER TABLE users DROP COLUMN role;
@@ -0,0 +1,6 @@
+ALTER TABLE point_transactions DROP COLUMN metrics_id;
+DROP INDEX IF EXISTS idx_metrics_review_status;
+ALTER TABLE typing_metrics DROP COLUMN reviewed_at;
+ALTER TABLE typing_metrics DROP COLUMN reviewed_by;
+ALTER TABLE typing_metrics DROP COLUMN flag_reason;
+ALTER TABLE typing_metrics DROP COLUMN review_status;
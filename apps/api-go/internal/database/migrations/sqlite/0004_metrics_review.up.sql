@@ -0,0 +1,9 @@
+ALTER TABLE typing_metrics ADD COLUMN review_status TEXT NOT NULL DEFAULT 'ok';
+ALTER TABLE typing_metrics ADD COLUMN flag_reason TEXT;
+ALTER TABLE typing_metrics ADD COLUMN reviewed_by TEXT;
+ALTER TABLE typing_metrics ADD COLUMN reviewed_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_metrics_review_status ON typing_metrics(review_status);
+
+-- Links points to the metrics that earned them, so flagged sessions can be
+-- left out of rankings
+ALTER TABLE point_transactions ADD COLUMN metrics_id TEXT;
@@ -0,0 +1,4 @@
+-- Where and when each login session was last used, for the sessions list
+ALTER TABLE sessions ADD COLUMN user_agent TEXT;
+ALTER TABLE sessions ADD COLUMN ip_address TEXT;
+ALTER TABLE sessions ADD COLUMN last_used_at TIMESTAMP;
@@ -0,0 +1,4 @@
+-- Failed logins since the last successful one, and the temporary lockout
+-- they triggered
+ALTER TABLE users ADD COLUMN failed_login_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN locked_until TIMESTAMP;
@@ -0,0 +1,3 @@
+-- When the user proved they own their email address. Accounts created
+-- before verification existed stay unverified until they verify.
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP;
@@ -0,0 +1,13 @@
+-- TOTP second factor: the shared secret (pending until enabled_at is set)
+-- and the last time step used, so a code cannot be replayed
+ALTER TABLE users ADD COLUMN totp_secret TEXT;
+ALTER TABLE users ADD COLUMN totp_enabled_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN totp_last_step INTEGER NOT NULL DEFAULT 0;
+
+-- Single-use codes for logging in without the authenticator
+CREATE TABLE IF NOT EXISTS recovery_codes (
+	code_hash TEXT PRIMARY KEY, user_id TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL, used_at TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user ON recovery_codes(user_id);
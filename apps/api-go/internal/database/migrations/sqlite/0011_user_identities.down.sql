@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN github_verified_at;
+DROP TABLE IF EXISTS user_identities;
@@ -0,0 +1,11 @@
+-- Accounts at external identity providers (OAuth2 / OpenID Connect) that
+-- users log in with, keyed by the provider's stable subject
+CREATE TABLE IF NOT EXISTS user_identities (
+	provider TEXT NOT NULL, subject TEXT NOT NULL, user_id TEXT NOT NULL,
+	username TEXT, email TEXT, created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (provider, subject), UNIQUE (user_id, provider),
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Set when github_username came from a GitHub login rather than being typed
+ALTER TABLE users ADD COLUMN github_verified_at TIMESTAMP;
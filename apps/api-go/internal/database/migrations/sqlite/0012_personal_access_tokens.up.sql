@@ -0,0 +1,7 @@
+-- Named, scoped tokens users create for scripts and editor plugins
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+	id TEXT PRIMARY KEY, user_id TEXT NOT NULL, name TEXT NOT NULL, token_hash TEXT NOT NULL UNIQUE,
+	scopes TEXT NOT NULL, created_at TIMESTAMP NOT NULL, expires_at TIMESTAMP, last_used_at TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_personal_access_tokens_user ON personal_access_tokens(user_id);
@@ -0,0 +1 @@
+ALTER TABLE typing_metrics DROP COLUMN session_id;
@@ -0,0 +1,8 @@
+ALTER TABLE badges DROP COLUMN criteria_en;
+ALTER TABLE badges DROP COLUMN criteria;
+ALTER TABLE badges DROP COLUMN hidden;
+ALTER TABLE badges DROP COLUMN category;
+ALTER TABLE badges DROP COLUMN rarity;
+ALTER TABLE badges DROP COLUMN icon;
+ALTER TABLE badges DROP COLUMN description_en;
+ALTER TABLE badges DROP COLUMN description;
@@ -0,0 +1,9 @@
+-- Localized descriptions and criteria, display metadata and secret badges
+ALTER TABLE badges ADD COLUMN description TEXT NOT NULL DEFAULT '';
+ALTER TABLE badges ADD COLUMN description_en TEXT NOT NULL DEFAULT '';
+ALTER TABLE badges ADD COLUMN icon TEXT NOT NULL DEFAULT '';
+ALTER TABLE badges ADD COLUMN rarity TEXT NOT NULL DEFAULT 'common';
+ALTER TABLE badges ADD COLUMN category TEXT NOT NULL DEFAULT '';
+ALTER TABLE badges ADD COLUMN hidden BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE badges ADD COLUMN criteria TEXT NOT NULL DEFAULT '';
+ALTER TABLE badges ADD COLUMN criteria_en TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE point_transactions DROP COLUMN strategy_version;
+ALTER TABLE point_transactions DROP COLUMN strategy;
@@ -0,0 +1,14 @@
+-- Records which point strategy calculated each transaction. Points recorded
+-- before this migration all came from the original formula, version v1.
+ALTER TABLE point_transactions ADD COLUMN strategy TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE point_transactions ADD COLUMN strategy_version TEXT NOT NULL DEFAULT 'v1';
+
+-- Links points recorded before 0004 to the metrics that earned them, so that
+-- a recompute can replay them. They were written with the metrics' created_at.
+UPDATE point_transactions SET metrics_id = (
+	SELECT tm.id FROM typing_metrics tm
+	WHERE tm.user_id = point_transactions.user_id AND tm.lesson_id = point_transactions.source_id
+		AND tm.created_at = point_transactions.created_at
+	LIMIT 1
+)
+WHERE metrics_id IS NULL AND reason = 'lesson_complete';
@@ -0,0 +1,7 @@
+-- The first time each user reached each tier
+CREATE TABLE IF NOT EXISTS tier_events (
+	id TEXT PRIMARY KEY, user_id TEXT NOT NULL, tier TEXT NOT NULL,
+	points INTEGER NOT NULL, created_at TIMESTAMP NOT NULL,
+	UNIQUE (user_id, tier),
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,180 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+// Repository implementations return sql.ErrNoRows when a requested record does
+// not exist, regardless of the backing store.
+
+// UserRepository manages user accounts
+type UserRepository interface {
+	CreateGuestUser() (*models.User, error)
+	CreateRegisteredUser(username, email, passwordHash, displayName, githubUsername string) (*models.User, error)
+	GetUserByID(id string) (*models.User, error)
+	GetUserByUsername(username string) (*models.User, error)
+	GetUserByEmail(email string) (*models.User, error)
+	GetPasswordHash(username string) (string, error)
+	ConvertGuestToRegistered(guestID, username, email, passwordHash, displayName, githubUsername string) (*models.User, error)
+	SetUserRole(userID, role string) error
+	SetPasswordHash(userID, passwordHash string) error
+	MarkEmailVerified(userID string, at time.Time) error
+	ReleaseUnverifiedEmail(email string, now time.Time) (bool, error)
+	GetLockedUntil(userID string) (*time.Time, error)
+	RecordFailedLogin(userID string, lockedUntil *time.Time) error
+	ResetFailedLogins(userID string) error
+	UpdateUserStreak(userID string) (int, error)
+}
+
+// ProgressRepository manages per-lesson progress
+type ProgressRepository interface {
+	SaveProgress(req models.ProgressRequest) (*models.Progress, error)
+	GetUserProgress(userID string) ([]models.Progress, error)
+	GetLessonProgress(userID, lessonID string) (*models.Progress, error)
+}
+
+// MetricsRepository manages typing session metrics
+type MetricsRepository interface {
+	SaveMetrics(req models.MetricsRequest) (*models.TypingMetrics, error)
+	GetUserMetrics(userID string) (*models.UserMetricsSummary, error)
+	GetRecentMetrics(userID string, since time.Time) ([]models.TypingMetrics, error)
+	GetMetricsHistory(userID string) ([]models.TypingMetrics, error)
+	GetUserIDsWithMetrics() ([]string, error)
+	GetMetricsByReviewStatus(status string, limit int) ([]models.TypingMetrics, error)
+	ReviewMetrics(id, status, reviewerID string) (*models.TypingMetrics, error)
+}
+
+// TypingSessionRepository manages server-issued typing sessions
+type TypingSessionRepository interface {
+	CreateTypingSession(userID, lessonID string) (*models.TypingSession, error)
+	GetTypingSession(id string) (*models.TypingSession, error)
+	FinishTypingSession(id string, finishedAt time.Time) error
+}
+
+// AuthSessionRepository manages login sessions, their refresh tokens and the
+// denylist of revoked access tokens
+type AuthSessionRepository interface {
+	CreateAuthSession(session models.AuthSession) error
+	GetAuthSession(id string) (*models.AuthSession, error)
+	ListAuthSessions(userID string) ([]models.AuthSession, error)
+	TouchAuthSession(id, userAgent, ipAddress string, at time.Time) error
+	RotateAuthSession(id, oldHash, newHash, accessTokenID string, expiresAt time.Time) error
+	RevokeAuthSession(id string, denyUntil time.Time) error
+	IsTokenRevoked(jti string) (bool, error)
+}
+
+// UserTokenRepository manages single-use tokens emailed to users
+type UserTokenRepository interface {
+	CreateUserToken(token models.UserToken) error
+	ConsumeUserToken(hash, purpose string, now time.Time) (*models.UserToken, error)
+}
+
+// MFARepository manages TOTP second factors and their recovery codes
+type MFARepository interface {
+	GetTOTP(userID string) (*models.TOTPConfig, error)
+	SetTOTPSecret(userID, secret string) error
+	EnableTOTP(userID string, step int64, at time.Time) error
+	UseTOTPStep(userID string, step int64) error
+	DisableTOTP(userID string) error
+	ReplaceRecoveryCodes(userID string, hashes []string, at time.Time) error
+	UseRecoveryCode(userID, hash string, at time.Time) error
+	CountRecoveryCodes(userID string) (int, error)
+}
+
+// IdentityRepository manages the external identities users log in with
+type IdentityRepository interface {
+	GetUserIdentity(provider, subject string) (*models.UserIdentity, error)
+	ListUserIdentities(userID string) ([]models.UserIdentity, error)
+	CreateUserIdentity(identity models.UserIdentity) error
+	DeleteUserIdentity(userID, provider string) error
+	SetVerifiedGitHubUsername(userID, username string, at time.Time) error
+	ClearGitHubVerification(userID string) error
+}
+
+// PersonalTokenRepository manages personal access tokens
+type PersonalTokenRepository interface {
+	CreatePersonalAccessToken(token models.PersonalAccessToken) error
+	ListPersonalAccessTokens(userID string) ([]models.PersonalAccessToken, error)
+	GetPersonalAccessTokenByHash(hash string) (*models.PersonalAccessToken, error)
+	TouchPersonalAccessToken(id string, at time.Time) error
+	DeletePersonalAccessToken(userID, id string) error
+	DeletePersonalAccessTokens(userID string) error
+}
+
+// PointsRepository manages the points ledger and rankings
+type PointsRepository interface {
+	SavePointTransaction(pt models.PointTransaction) error
+	GetUserPointTransactions(userID string) ([]models.PointTransaction, error)
+	GetLeaderboard(startDate, endDate time.Time, limit int, verifiedOnly bool) ([]models.LeaderboardEntry, error)
+	GetUserPoints(userID string, startDate, endDate time.Time) (int, error)
+	GetUserRank(userID string, startDate, endDate time.Time, verifiedOnly bool) (int, error)
+}
+
+// BadgeRepository manages badges and their assignment to users
+type BadgeRepository interface {
+	CreateBadge(badge models.Badge) (*models.Badge, error)
+	UpdateBadge(badge models.Badge) error
+	DeleteBadge(id string) error
+	GetBadgeByID(id string) (*models.Badge, error)
+	GetBadgeByName(name string) (*models.Badge, error)
+	GetAllBadges() ([]models.Badge, error)
+	AssignBadgeToUser(userID, badgeID string) error
+	AssignAutomaticBadges(userID string) error
+	RemoveBadgeFromUser(userID, badgeID string) error
+	GetUserBadges(userID string) ([]models.BadgeWithDetails, error)
+	GetUsersWithBadge(badgeID string) ([]string, error)
+	InitializeBadges() error
+	RecordBadgeAudit(entry models.BadgeAuditEntry) error
+	GetBadgeAuditLog(limit int) ([]models.BadgeAuditEntry, error)
+}
+
+// TierRepository records tier promotions
+type TierRepository interface {
+	// RecordTierEvent saves a promotion and reports false if the user
+	// already reached the tier
+	RecordTierEvent(event models.TierEvent) (bool, error)
+	GetTierEvents(userID string) ([]models.TierEvent, error)
+}
+
+// Store is the complete data layer used by the API
+type Store interface {
+	UserRepository
+	ProgressRepository
+	MetricsRepository
+	TypingSessionRepository
+	AuthSessionRepository
+	UserTokenRepository
+	MFARepository
+	IdentityRepository
+	PersonalTokenRepository
+	PointsRepository
+	BadgeRepository
+	TierRepository
+	Close() error
+}
+
+var (
+	_ Store = (*DB)(nil)
+	_ Store = (*MemoryDB)(nil)
+)
+
+// MemoryURL selects the in-memory backend when passed as DATABASE_URL
+const MemoryURL = "memory://"
+
+// Connect opens the store selected by the database URL scheme, applies
+// migrations and seeds default data. memory:// selects the in-memory backend;
+// anything else is treated as a PostgreSQL connection string.
+func Connect(databaseURL string) (Store, error) {
+	if strings.HasPrefix(databaseURL, MemoryURL) {
+		mem := NewMemoryDB()
+		if err := mem.InitializeBadges(); err != nil {
+			return nil, fmt.Errorf("failed to initialize badges: %w", err)
+		}
+		return mem, nil
+	}
+	return InitDB(databaseURL)
+}
@@ -0,0 +1,241 @@
+// Package engine is the Go port of the web client's TypingEngineService. It
+// turns a lesson into the characters to type, applies keystrokes in strict or
+// practice mode and computes the resulting metrics, so that session
+// verification, tooling and the frontend share one definition of "correct".
+package engine
+
+import (
+	"math"
+	"time"
+
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+// Mode controls how wrong keys and backspace are handled
+type Mode string
+
+const (
+	// ModeStrict does not advance on a wrong key and ignores backspace
+	ModeStrict Mode = "strict"
+	// ModePractice advances on every key and allows free backspace
+	ModePractice Mode = "practice"
+)
+
+// LessonMode returns the mode a lesson is typed in (strict unless it says practice)
+func LessonMode(lesson *models.Lesson) Mode {
+	if lesson.Mode == string(ModePractice) {
+		return ModePractice
+	}
+	return ModeStrict
+}
+
+// CharStatus mirrors CharState.status in the frontend
+type CharStatus string
+
+const (
+	StatusPending   CharStatus = "pending"
+	StatusCorrect   CharStatus = "correct"
+	StatusIncorrect CharStatus = "incorrect"
+)
+
+// CharState is the state of a single expected character
+type CharState struct {
+	Char   string     `json:"char"`
+	Status CharStatus `json:"status"`
+	Hidden bool       `json:"isHidden,omitempty"`
+}
+
+// State is a snapshot of an engine, shaped like the frontend's TypingState
+type State struct {
+	CurrentIndex int                 `json:"currentIndex"`
+	Chars        []CharState         `json:"chars"`
+	Started      bool                `json:"started"`
+	Finished     bool                `json:"finished"`
+	Errors       []models.ErrorEntry `json:"errors"`
+}
+
+// Metrics are the results of a typing session, computed like the frontend's
+// getMetrics()
+type Metrics struct {
+	WPM             float64             `json:"wpm"`
+	Accuracy        float64             `json:"accuracy"`
+	TotalTime       float64             `json:"totalTime"` // seconds
+	TotalChars      int                 `json:"totalChars"`
+	CorrectChars    int                 `json:"correctChars"`
+	IncorrectChars  int                 `json:"incorrectChars"`
+	TotalKeystrokes int                 `json:"totalKeystrokes"`
+	CommonErrors    []models.ErrorEntry `json:"commonErrors"`
+}
+
+// Engine is the typing state of a single attempt at a lesson. It is not safe
+// for concurrent use.
+type Engine struct {
+	chars  []Char
+	status []CharStatus
+	mode   Mode
+
+	current  int
+	started  bool
+	finished bool
+	start    time.Duration
+	end      time.Duration
+
+	errorAttempts int
+	errors        []models.ErrorEntry
+	errorIndex    map[string]int // "expected->typed" -> index in errors
+}
+
+// New creates an engine for a lesson in the lesson's own mode
+func New(lesson *models.Lesson) *Engine {
+	return NewWithMode(lesson, LessonMode(lesson))
+}
+
+// NewWithMode creates an engine for a lesson in the given mode
+func NewWithMode(lesson *models.Lesson, mode Mode) *Engine {
+	chars := Tokenize(lesson)
+	status := make([]CharStatus, len(chars))
+	for i := range status {
+		status[i] = StatusPending
+	}
+	return &Engine{
+		chars:      chars,
+		status:     status,
+		mode:       mode,
+		errorIndex: make(map[string]int),
+	}
+}
+
+// Chars returns the expected characters with their hidden flags
+func (e *Engine) Chars() []Char {
+	return append([]Char(nil), e.chars...)
+}
+
+// Finished reports whether every character has been typed
+func (e *Engine) Finished() bool {
+	return e.finished
+}
+
+// Apply feeds a keystroke to the engine, dispatching backspace
+func (e *Engine) Apply(k Keystroke) {
+	if k.Key == Backspace {
+		e.Backspace()
+		return
+	}
+	e.Type(k.Key, k.At)
+}
+
+// Type applies a typed character at the given offset from the session start.
+// In strict mode a wrong key does not advance; in practice mode it does.
+// Keys typed after the lesson is finished are ignored.
+func (e *Engine) Type(typed rune, at time.Duration) {
+	if e.finished || e.current >= len(e.chars) {
+		return
+	}
+
+	// The timer starts on the first typed character
+	if !e.started {
+		e.started = true
+		e.start = at
+	}
+	e.end = at
+
+	expected := e.chars[e.current].Char
+	if typed == expected {
+		e.status[e.current] = StatusCorrect
+		e.current++
+	} else {
+		e.recordError(expected, typed)
+		if e.mode == ModeStrict {
+			// The frontend flashes the char as incorrect and then resets it to
+			// pending; the engine keeps the settled state
+			return
+		}
+		e.status[e.current] = StatusIncorrect
+		e.current++
+	}
+
+	if e.current >= len(e.chars) {
+		e.finished = true
+	}
+}
+
+// Backspace moves back one character in practice mode. Strict mode never
+// moves back.
+func (e *Engine) Backspace() {
+	if e.finished || e.current <= 0 || e.mode == ModeStrict {
+		return
+	}
+	e.current--
+	e.status[e.current] = StatusPending
+}
+
+func (e *Engine) recordError(expected, typed rune) {
+	e.errorAttempts++
+	key := string(expected) + "->" + string(typed)
+	if i, ok := e.errorIndex[key]; ok {
+		e.errors[i].Count++
+		return
+	}
+	e.errorIndex[key] = len(e.errors)
+	e.errors = append(e.errors, models.ErrorEntry{Expected: string(expected), Typed: string(typed), Count: 1})
+}
+
+// State returns a snapshot of the engine state
+func (e *Engine) State() State {
+	chars := make([]CharState, len(e.chars))
+	for i, c := range e.chars {
+		chars[i] = CharState{Char: string(c.Char), Status: e.status[i], Hidden: c.Hidden}
+	}
+	return State{
+		CurrentIndex: e.current,
+		Chars:        chars,
+		Started:      e.started,
+		Finished:     e.finished,
+		Errors:       e.commonErrors(),
+	}
+}
+
+// Metrics computes the session metrics. Time runs from the first typed
+// character to the last one applied (the finishing key once finished).
+func (e *Engine) Metrics() Metrics {
+	correct := 0
+	for _, st := range e.status {
+		if st == StatusCorrect {
+			correct++
+		}
+	}
+
+	totalSeconds := (e.end - e.start).Seconds()
+	totalMinutes := totalSeconds / 60
+	totalKeystrokes := correct + e.errorAttempts
+
+	wpm := 0.0
+	if totalMinutes > 0 {
+		wpm = float64(correct) / 5 / totalMinutes
+	}
+	accuracy := 100.0
+	if totalKeystrokes > 0 {
+		accuracy = float64(correct) / float64(totalKeystrokes) * 100
+	}
+
+	return Metrics{
+		WPM:             round1(wpm),
+		Accuracy:        round1(accuracy),
+		TotalTime:       round1(totalSeconds),
+		TotalChars:      len(e.chars),
+		CorrectChars:    correct,
+		IncorrectChars:  e.errorAttempts,
+		TotalKeystrokes: totalKeystrokes,
+		CommonErrors:    e.commonErrors(),
+	}
+}
+
+// commonErrors returns a copy of the error tally in first-seen order
+func (e *Engine) commonErrors() []models.ErrorEntry {
+	return append([]models.ErrorEntry{}, e.errors...)
+}
+
+// round1 rounds to one decimal place like Math.round(x * 10) / 10
+func round1(x float64) float64 {
+	return math.Round(x*10) / 10
+}
@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// fixturesPath is the golden cases shared with the web client's engine
+var fixturesPath = filepath.Join("..", "..", "..", "..", "packages", "engine-fixtures", "fixtures.json")
+
+func loadFixtures(t *testing.T) []Fixture {
+	t.Helper()
+	fixtures, err := LoadFixtures(fixturesPath)
+	if err != nil {
+		t.Fatalf("failed to load fixtures: %v", err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatalf("no fixtures in %s", fixturesPath)
+	}
+	return fixtures
+}
+
+func TestFixtures(t *testing.T) {
+	names := make(map[string]bool)
+	for _, f := range loadFixtures(t) {
+		if f.Name == "" || names[f.Name] {
+			t.Errorf("fixture %q needs a unique name", f.Name)
+		}
+		names[f.Name] = true
+
+		t.Run(f.Name, func(t *testing.T) {
+			for _, problem := range f.Check() {
+				t.Error(problem)
+			}
+		})
+	}
+}
+
+func TestReplayMatchesFixtures(t *testing.T) {
+	for _, f := range loadFixtures(t) {
+		t.Run(f.Name, func(t *testing.T) {
+			keystrokes, err := DecodeLog(f.Keystrokes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := Replay(&f.Lesson, keystrokes)
+			if result.Finished != f.Expected.Finished {
+				t.Errorf("finished: got %v, want %v", result.Finished, f.Expected.Finished)
+			}
+			got, want := result.Metrics, f.Expected.Metrics
+			if got.WPM != want.WPM || got.Accuracy != want.Accuracy || got.TotalTime != want.TotalTime {
+				t.Errorf("metrics: got %.1f WPM %.1f%% in %.1fs, want %.1f WPM %.1f%% in %.1fs",
+					got.WPM, got.Accuracy, got.TotalTime, want.WPM, want.Accuracy, want.TotalTime)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+// Fixture is a golden case shared with the web client's engine: a lesson, a
+// keystroke log and the state and metrics both engines must arrive at
+type Fixture struct {
+	Name       string              `json:"name"`
+	Lesson     models.Lesson       `json:"lesson"`
+	Keystrokes models.KeystrokeLog `json:"keystrokes"`
+	Expected   Expectation         `json:"expected"`
+}
+
+// Expectation is the expected outcome of a fixture
+type Expectation struct {
+	Text         string  `json:"text"`
+	Hidden       string  `json:"hidden"` // one "1" (hidden) or "0" per character
+	CurrentIndex int     `json:"currentIndex"`
+	Finished     bool    `json:"finished"`
+	Metrics      Metrics `json:"metrics"`
+}
+
+// LoadFixtures reads a JSON array of fixtures
+func LoadFixtures(path string) ([]Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixtures []Fixture
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return fixtures, nil
+}
+
+// Check runs the fixture through the engine and describes every mismatch
+func (f Fixture) Check() []string {
+	var problems []string
+	mismatch := func(field string, got, want interface{}) {
+		problems = append(problems, fmt.Sprintf("%s: got %v, want %v", field, got, want))
+	}
+
+	keystrokes, err := DecodeLog(f.Keystrokes)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	e := New(&f.Lesson)
+	for _, k := range keystrokes {
+		e.Apply(k)
+	}
+
+	chars := e.Chars()
+	if got := Text(chars); got != f.Expected.Text {
+		mismatch("text", fmt.Sprintf("%q", got), fmt.Sprintf("%q", f.Expected.Text))
+	}
+	if got := hiddenMask(chars); got != f.Expected.Hidden {
+		mismatch("hidden", got, f.Expected.Hidden)
+	}
+
+	state := e.State()
+	if state.CurrentIndex != f.Expected.CurrentIndex {
+		mismatch("currentIndex", state.CurrentIndex, f.Expected.CurrentIndex)
+	}
+	if state.Finished != f.Expected.Finished {
+		mismatch("finished", state.Finished, f.Expected.Finished)
+	}
+
+	got, want := e.Metrics(), f.Expected.Metrics
+	if got.WPM != want.WPM {
+		mismatch("wpm", got.WPM, want.WPM)
+	}
+	if got.Accuracy != want.Accuracy {
+		mismatch("accuracy", got.Accuracy, want.Accuracy)
+	}
+	if got.TotalTime != want.TotalTime {
+		mismatch("totalTime", got.TotalTime, want.TotalTime)
+	}
+	if got.TotalChars != want.TotalChars {
+		mismatch("totalChars", got.TotalChars, want.TotalChars)
+	}
+	if got.CorrectChars != want.CorrectChars {
+		mismatch("correctChars", got.CorrectChars, want.CorrectChars)
+	}
+	if got.IncorrectChars != want.IncorrectChars {
+		mismatch("incorrectChars", got.IncorrectChars, want.IncorrectChars)
+	}
+	if got.TotalKeystrokes != want.TotalKeystrokes {
+		mismatch("totalKeystrokes", got.TotalKeystrokes, want.TotalKeystrokes)
+	}
+	if formatErrors(got.CommonErrors) != formatErrors(want.CommonErrors) {
+		mismatch("commonErrors", formatErrors(got.CommonErrors), formatErrors(want.CommonErrors))
+	}
+
+	return problems
+}
+
+func hiddenMask(chars []Char) string {
+	var b strings.Builder
+	for _, c := range chars {
+		if c.Hidden {
+			b.WriteByte('1')
+		} else {
+			b.WriteByte('0')
+		}
+	}
+	return b.String()
+}
+
+func formatErrors(entries []models.ErrorEntry) string {
+	parts := make([]string, len(entries))
+	for i, e := range entries {
+		parts[i] = fmt.Sprintf("%q->%q x%d", e.Expected, e.Typed, e.Count)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+// Backspace is the key recorded in keystroke logs for a backspace press
+const Backspace = '\b'
+
+// Keystroke is a single key press, timed from the start of the session
+type Keystroke struct {
+	Key rune
+	At  time.Duration
+}
+
+// Result is the outcome of replaying a keystroke log
+type Result struct {
+	Finished bool
+	Metrics  Metrics
+}
+
+// DecodeLog expands a compact keystroke log into individual keystrokes
+func DecodeLog(log models.KeystrokeLog) ([]Keystroke, error) {
+	keys := []rune(log.Keys)
+	if len(keys) != len(log.Times) {
+		return nil, fmt.Errorf("keystroke log has %d keys but %d timings", len(keys), len(log.Times))
+	}
+
+	keystrokes := make([]Keystroke, len(keys))
+	var at time.Duration
+	for i, key := range keys {
+		if log.Times[i] < 0 {
+			return nil, fmt.Errorf("keystroke %d has a negative timing", i)
+		}
+		at += time.Duration(log.Times[i]) * time.Millisecond
+		keystrokes[i] = Keystroke{Key: key, At: at}
+	}
+	return keystrokes, nil
+}
+
+// Replay applies keystrokes to a lesson in its own mode and computes the
+// resulting metrics. Keystrokes after the lesson is finished are ignored.
+func Replay(lesson *models.Lesson, keystrokes []Keystroke) Result {
+	e := New(lesson)
+	for _, k := range keystrokes {
+		if e.Finished() {
+			break
+		}
+		e.Apply(k)
+	}
+	return Result{Finished: e.Finished(), Metrics: e.Metrics()}
+}
@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+// Char is a single expected character of a lesson
+type Char struct {
+	Char   rune
+	Hidden bool // fill-in-the-blank: shown only once typed
+}
+
+// MarkerRegexp matches manual [[hidden]] markers in lesson code
+var MarkerRegexp = regexp.MustCompile(`\[\[(.*?)\]\]`)
+
+// wordCharRe matches a single word character, as JavaScript's \w does
+var wordCharRe = regexp.MustCompile(`^\w$`)
+
+// Tokenize splits lesson code into the characters a user has to type,
+// removing [[ ]] markers and flagging hidden characters. Exclude words are
+// only matched outside manual markers.
+func Tokenize(lesson *models.Lesson) []Char {
+	code := lesson.Code
+	var chars []Char
+
+	excludeRe := ExcludeRegexp(lesson.Exclude)
+	appendText := func(text string, hidden bool) {
+		for _, c := range text {
+			chars = append(chars, Char{Char: c, Hidden: hidden})
+		}
+	}
+
+	lastIndex := 0
+	for _, m := range MarkerRegexp.FindAllStringSubmatchIndex(code, -1) {
+		if m[0] > lastIndex {
+			appendVisible(code[lastIndex:m[0]], excludeRe, appendText)
+		}
+		appendText(code[m[2]:m[3]], true)
+		lastIndex = m[1]
+	}
+	if lastIndex < len(code) {
+		appendVisible(code[lastIndex:], excludeRe, appendText)
+	}
+
+	return chars
+}
+
+// appendVisible emits a segment outside manual markers, hiding exclude words
+func appendVisible(text string, excludeRe *regexp.Regexp, appendText func(string, bool)) {
+	if excludeRe == nil {
+		appendText(text, false)
+		return
+	}
+	lastIndex := 0
+	for _, m := range excludeRe.FindAllStringIndex(text, -1) {
+		appendText(text[lastIndex:m[0]], false)
+		appendText(text[m[0]:m[1]], true)
+		lastIndex = m[1]
+	}
+	appendText(text[lastIndex:], false)
+}
+
+// ExcludeRegexp builds a single alternation of exclude words, only using word
+// boundaries on sides that start or end with a word character. It returns nil
+// when there is nothing to exclude.
+func ExcludeRegexp(words []string) *regexp.Regexp {
+	var parts []string
+	for _, w := range words {
+		if w == "" {
+			continue
+		}
+		part := regexp.QuoteMeta(w)
+		if wordCharRe.MatchString(w[:1]) {
+			part = `\b` + part
+		}
+		if wordCharRe.MatchString(w[len(w)-1:]) {
+			part += `\b`
+		}
+		parts = append(parts, part)
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	return regexp.MustCompile("(" + strings.Join(parts, "|") + ")")
+}
+
+// Text returns the characters to type as a plain string
+func Text(chars []Char) string {
+	var b strings.Builder
+	for _, c := range chars {
+		b.WriteRune(c.Char)
+	}
+	return b.String()
+}
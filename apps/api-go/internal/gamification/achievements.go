@@ -0,0 +1,340 @@
+package gamification
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+// LessonFilter selects lessons by language, level and difficulty; empty
+// fields match any lesson
+type LessonFilter struct {
+	Language   string
+	Level      string
+	Difficulty string
+}
+
+// Matches reports whether a lesson passes the filter
+func (f LessonFilter) Matches(l *models.Lesson) bool {
+	return (f.Language == "" || f.Language == l.Language) &&
+		(f.Level == "" || f.Level == l.Level) &&
+		(f.Difficulty == "" || f.Difficulty == l.Difficulty)
+}
+
+// RunTarget is a result to reach in a single run
+type RunTarget struct {
+	MinWPM      float64
+	MinAccuracy float64
+}
+
+// Rule awards a badge once all of its criteria hold. At least one criterion
+// must be set.
+type Rule struct {
+	ID string
+	// Badge is created from this template when it doesn't exist; it is
+	// looked up by name
+	Badge models.Badge
+
+	// CompleteAll requires every lesson matching the filter to be completed;
+	// it never holds while no lesson matches
+	CompleteAll *LessonFilter
+	// CompleteAny requires at least one lesson matching the filter to be
+	// completed
+	CompleteAny *LessonFilter
+	// Run requires a single run at or above the target
+	Run *RunTarget
+	// StreakDays requires practising on that many consecutive days
+	StreakDays int
+}
+
+// DefaultRules are the achievements awarded out of the box
+var DefaultRules = []Rule{
+	{
+		ID: "go-basics",
+		Badge: models.Badge{
+			Name: "Go Basics", Color: "#00ADD8", Icon: "gopher", Rarity: models.RarityUncommon, Category: "progress",
+			Description: "Domina los fundamentos de Go", DescriptionEn: "Mastered the fundamentals of Go",
+			Criteria: "Completa todas las lecciones básicas de Go", CriteriaEn: "Complete every basic Go lesson",
+		},
+		CompleteAll: &LessonFilter{Language: "go", Level: "basic"},
+	},
+	{
+		ID: "speed-100",
+		Badge: models.Badge{
+			Name: "100 WPM Club", Color: "#FF4500", Icon: "zap", Rarity: models.RarityLegendary, Category: "speed",
+			Description: "Teclea código a 100 palabras por minuto", DescriptionEn: "Types code at 100 words per minute",
+			Criteria: "Termina una lección a 100 WPM con un 98% de precisión", CriteriaEn: "Finish a lesson at 100 WPM with 98% accuracy",
+		},
+		Run: &RunTarget{MinWPM: 100, MinAccuracy: 98},
+	},
+	{
+		ID: "streak-30",
+		Badge: models.Badge{
+			Name: "30-Day Streak", Color: "#8A2BE2", Icon: "flame", Rarity: models.RarityEpic, Category: "streak",
+			Description: "Practica sin faltar un día", DescriptionEn: "Practises without missing a day",
+			Criteria: "Practica 30 días seguidos", CriteriaEn: "Practise on 30 consecutive days",
+		},
+		StreakDays: 30,
+	},
+	{
+		ID: "first-advanced",
+		Badge: models.Badge{
+			Name: "Advanced Explorer", Color: "#1E90FF", Icon: "compass", Rarity: models.RarityRare, Category: "progress",
+			Description: "Se atrevió con el nivel avanzado", DescriptionEn: "Took on the advanced level",
+			Criteria: "Completa tu primera lección avanzada", CriteriaEn: "Complete your first advanced lesson",
+		},
+		CompleteAny: &LessonFilter{Level: "advanced"},
+	},
+}
+
+// Facts is what rules are evaluated against
+type Facts struct {
+	Completed map[string]bool // lesson IDs
+	Runs      []models.TypingMetrics
+	Streak    int // longest run of consecutive practice days
+}
+
+// Holds reports whether every criterion of the rule is met
+func (r Rule) Holds(facts Facts, lessons []*models.Lesson) bool {
+	if r.CompleteAll == nil && r.CompleteAny == nil && r.Run == nil && r.StreakDays == 0 {
+		return false
+	}
+
+	if r.CompleteAll != nil {
+		matched := 0
+		for _, l := range lessons {
+			if !r.CompleteAll.Matches(l) {
+				continue
+			}
+			if !facts.Completed[l.ID] {
+				return false
+			}
+			matched++
+		}
+		if matched == 0 {
+			return false
+		}
+	}
+
+	if r.CompleteAny != nil {
+		found := false
+		for _, l := range lessons {
+			if r.CompleteAny.Matches(l) && facts.Completed[l.ID] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if r.Run != nil {
+		found := false
+		for _, m := range facts.Runs {
+			if m.WPM >= r.Run.MinWPM && m.Accuracy >= r.Run.MinAccuracy {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return facts.Streak >= r.StreakDays
+}
+
+// AchievementStore is the data the achievement engine reads and writes
+type AchievementStore interface {
+	GetUserByID(id string) (*models.User, error)
+	GetMetricsHistory(userID string) ([]models.TypingMetrics, error)
+	CreateBadge(badge models.Badge) (*models.Badge, error)
+	GetBadgeByName(name string) (*models.Badge, error)
+	AssignBadgeToUser(userID, badgeID string) error
+	GetUserBadges(userID string) ([]models.BadgeWithDetails, error)
+}
+
+// LessonCatalog lists the lessons rules can refer to
+type LessonCatalog interface {
+	All() []*models.Lesson
+}
+
+// Achievements evaluates rules for users and awards their badges
+type Achievements struct {
+	store   AchievementStore
+	lessons LessonCatalog
+	rules   []Rule
+
+	// RequireVerifiedEmail withholds badges from users without a verified
+	// email address
+	RequireVerifiedEmail bool
+}
+
+// NewAchievements creates an engine for the given rules
+func NewAchievements(store AchievementStore, lessons LessonCatalog, rules []Rule) *Achievements {
+	return &Achievements{store: store, lessons: lessons, rules: rules}
+}
+
+// EnsureBadges creates the badges of all rules that don't exist yet
+func (a *Achievements) EnsureBadges() error {
+	for _, r := range a.rules {
+		if _, err := a.badgeFor(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pending returns the rules a user meets whose badges they don't hold yet
+func (a *Achievements) Pending(userID string) ([]Rule, error) {
+	user, err := a.store.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	// Like the registration badges, achievements need an account
+	if user.IsGuest || (a.RequireVerifiedEmail && user.EmailVerifiedAt == nil) {
+		return nil, nil
+	}
+
+	held, err := a.store.GetUserBadges(userID)
+	if err != nil {
+		return nil, err
+	}
+	heldNames := make(map[string]bool, len(held))
+	for _, b := range held {
+		heldNames[b.Badge.Name] = true
+	}
+
+	var facts *Facts
+	lessons := a.lessons.All()
+	var pending []Rule
+	for _, r := range a.rules {
+		if heldNames[r.Badge.Name] {
+			continue
+		}
+		// Facts are only gathered once a rule needs them
+		if facts == nil {
+			if facts, err = a.facts(user); err != nil {
+				return nil, err
+			}
+		}
+		if r.Holds(*facts, lessons) {
+			heldNames[r.Badge.Name] = true
+			pending = append(pending, r)
+		}
+	}
+	return pending, nil
+}
+
+// Evaluate awards a user the badges of all pending rules and returns them.
+// Running it again awards nothing.
+func (a *Achievements) Evaluate(userID string) ([]models.Badge, error) {
+	pending, err := a.Pending(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var awarded []models.Badge
+	for _, r := range pending {
+		badge, err := a.badgeFor(r)
+		if err != nil {
+			return awarded, err
+		}
+		if err := a.store.AssignBadgeToUser(userID, badge.ID); err != nil {
+			return awarded, err
+		}
+		awarded = append(awarded, *badge)
+	}
+	return awarded, nil
+}
+
+// facts gathers a user's completed lessons, runs and longest streak from
+// verified runs only: those recorded by typing sessions, whose numbers the
+// server computed, and those an admin approved. Client-reported progress and
+// metrics can't be trusted, and flagged and rejected runs don't count.
+func (a *Achievements) facts(user *models.User) (*Facts, error) {
+	facts := &Facts{Completed: make(map[string]bool)}
+
+	history, err := a.store.GetMetricsHistory(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	var days []time.Time
+	for _, m := range history {
+		if !verifiedRun(m) {
+			continue
+		}
+		// Runs are only recorded for finished lessons
+		facts.Completed[m.LessonID] = true
+		facts.Runs = append(facts.Runs, m)
+		days = append(days, m.CreatedAt)
+	}
+	facts.Streak = LongestStreak(days)
+	return facts, nil
+}
+
+// verifiedRun reports whether a run's numbers can be trusted
+func verifiedRun(m models.TypingMetrics) bool {
+	switch m.ReviewStatus {
+	case models.ReviewStatusApproved:
+		return true
+	case models.ReviewStatusFlagged, models.ReviewStatusRejected:
+		return false
+	}
+	return m.SessionID != ""
+}
+
+// badgeFor returns the badge a rule awards, creating it if needed
+func (a *Achievements) badgeFor(r Rule) (*models.Badge, error) {
+	badge, err := ensureBadge(a.store, r.Badge)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get badge %s for rule %s: %w", r.Badge.Name, r.ID, err)
+	}
+	return badge, nil
+}
+
+// badgeStore finds and creates the badges the engines award
+type badgeStore interface {
+	CreateBadge(badge models.Badge) (*models.Badge, error)
+	GetBadgeByName(name string) (*models.Badge, error)
+}
+
+// ensureBadge returns the badge named like the template, creating it from
+// the template when it doesn't exist
+func ensureBadge(store badgeStore, template models.Badge) (*models.Badge, error) {
+	badge, err := store.GetBadgeByName(template.Name)
+	if err == sql.ErrNoRows {
+		badge, err = store.CreateBadge(template)
+	}
+	return badge, err
+}
+
+// LongestStreak returns the longest run of consecutive UTC days among times
+func LongestStreak(times []time.Time) int {
+	days := make([]time.Time, 0, len(times))
+	for _, t := range times {
+		t = t.UTC()
+		days = append(days, time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC))
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	longest, current := 0, 0
+	for i, d := range days {
+		switch {
+		case i > 0 && d.Equal(days[i-1]):
+			continue
+		case i > 0 && d.Equal(days[i-1].AddDate(0, 0, 1)):
+			current++
+		default:
+			current = 1
+		}
+		if current > longest {
+			longest = current
+		}
+	}
+	return longest
+}
@@ -0,0 +1,258 @@
+package gamification
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+// achievementStore keeps one user's runs and badges in memory
+type achievementStore struct {
+	user    models.User
+	history []models.TypingMetrics
+	badges  map[string]*models.Badge // by name
+	held    []string                 // badge IDs
+}
+
+func newAchievementStore() *achievementStore {
+	return &achievementStore{
+		user:   models.User{ID: "u1", Username: "ada"},
+		badges: make(map[string]*models.Badge),
+	}
+}
+
+func (s *achievementStore) GetUserByID(id string) (*models.User, error) {
+	if id != s.user.ID {
+		return nil, sql.ErrNoRows
+	}
+	user := s.user
+	return &user, nil
+}
+
+func (s *achievementStore) GetMetricsHistory(userID string) ([]models.TypingMetrics, error) {
+	return s.history, nil
+}
+
+func (s *achievementStore) CreateBadge(badge models.Badge) (*models.Badge, error) {
+	badge.ID = "badge-" + badge.Name
+	s.badges[badge.Name] = &badge
+	return &badge, nil
+}
+
+func (s *achievementStore) GetBadgeByName(name string) (*models.Badge, error) {
+	if b, ok := s.badges[name]; ok {
+		return b, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (s *achievementStore) AssignBadgeToUser(userID, badgeID string) error {
+	s.held = append(s.held, badgeID)
+	return nil
+}
+
+func (s *achievementStore) GetUserBadges(userID string) ([]models.BadgeWithDetails, error) {
+	var held []models.BadgeWithDetails
+	for _, id := range s.held {
+		for _, b := range s.badges {
+			if b.ID == id {
+				held = append(held, models.BadgeWithDetails{Badge: *b})
+			}
+		}
+	}
+	return held, nil
+}
+
+// catalog is a fixed list of lessons
+type catalog []*models.Lesson
+
+func (c catalog) All() []*models.Lesson { return c }
+
+var testCatalog = catalog{
+	{ID: "go-basics-01", Language: "go", Level: "basic"},
+	{ID: "go-basics-02", Language: "go", Level: "basic"},
+	{ID: "go-advanced-01", Language: "go", Level: "advanced"},
+	{ID: "py-basics-01", Language: "python", Level: "basic"},
+}
+
+// day returns noon UTC of a day in June 2024
+func day(d int) time.Time {
+	return time.Date(2024, 6, d, 12, 0, 0, 0, time.UTC)
+}
+
+// sessionRun is a run recorded by a typing session
+func sessionRun(lessonID string, wpm float64, at time.Time) models.TypingMetrics {
+	return models.TypingMetrics{
+		ID: lessonID + at.String(), UserID: "u1", LessonID: lessonID,
+		WPM: wpm, Accuracy: 100, ReviewStatus: models.ReviewStatusOK,
+		SessionID: "s-" + lessonID, CreatedAt: at,
+	}
+}
+
+func TestRuleHolds(t *testing.T) {
+	facts := Facts{
+		Completed: map[string]bool{"go-basics-01": true, "go-basics-02": true},
+		Runs:      []models.TypingMetrics{{WPM: 100, Accuracy: 97}, {WPM: 90, Accuracy: 99}},
+		Streak:    6,
+	}
+	tests := []struct {
+		name string
+		rule Rule
+		want bool
+	}{
+		{"no criteria", Rule{}, false},
+		{"every matching lesson completed", Rule{CompleteAll: &LessonFilter{Language: "go", Level: "basic"}}, true},
+		{"a matching lesson missing", Rule{CompleteAll: &LessonFilter{Language: "go"}}, false},
+		{"no lesson matches", Rule{CompleteAll: &LessonFilter{Language: "rust"}}, false},
+		{"any matching lesson completed", Rule{CompleteAny: &LessonFilter{Level: "basic"}}, true},
+		{"no matching lesson completed", Rule{CompleteAny: &LessonFilter{Level: "advanced"}}, false},
+		{"both targets in one run", Rule{Run: &RunTarget{MinWPM: 90, MinAccuracy: 99}}, true},
+		{"targets only in different runs", Rule{Run: &RunTarget{MinWPM: 100, MinAccuracy: 98}}, false},
+		{"streak reached", Rule{StreakDays: 6}, true},
+		{"streak not reached", Rule{StreakDays: 7}, false},
+		{"every criterion must hold", Rule{CompleteAny: &LessonFilter{Level: "basic"}, StreakDays: 7}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Holds(facts, testCatalog); got != tt.want {
+				t.Errorf("Holds() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLongestStreak(t *testing.T) {
+	tests := []struct {
+		name  string
+		times []time.Time
+		want  int
+	}{
+		{"no runs", nil, 0},
+		{"one run", []time.Time{day(1)}, 1},
+		{"several runs a day count once", []time.Time{day(1), day(1).Add(time.Hour), day(2), day(2)}, 2},
+		{"a gap starts over", []time.Time{day(1), day(2), day(4), day(5), day(6)}, 3},
+		{"the longest run wins", []time.Time{day(1), day(2), day(3), day(5), day(6)}, 3},
+		{"order doesn't matter", []time.Time{day(3), day(1), day(2)}, 3},
+		{"days are UTC", []time.Time{
+			time.Date(2024, 6, 1, 23, 30, 0, 0, time.FixedZone("UTC-2", -2*3600)), // June 2 UTC
+			day(3),
+		}, 2},
+		{"across months", []time.Time{time.Date(2024, 5, 31, 8, 0, 0, 0, time.UTC), day(1)}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LongestStreak(tt.times); got != tt.want {
+				t.Errorf("LongestStreak() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAchievementsUseVerifiedRunsOnly(t *testing.T) {
+	rules := []Rule{
+		{ID: "basics", Badge: models.Badge{Name: "Basics"}, CompleteAny: &LessonFilter{Level: "basic"}},
+		{ID: "advanced", Badge: models.Badge{Name: "Advanced"}, CompleteAny: &LessonFilter{Level: "advanced"}},
+		{ID: "speed", Badge: models.Badge{Name: "Speed"}, Run: &RunTarget{MinWPM: 100}},
+		{ID: "streak", Badge: models.Badge{Name: "Streak"}, StreakDays: 3},
+	}
+
+	flagged := sessionRun("go-advanced-01", 150, day(2))
+	flagged.ReviewStatus = models.ReviewStatusFlagged
+	rejected := sessionRun("go-advanced-01", 150, day(3))
+	rejected.ReviewStatus = models.ReviewStatusRejected
+	clientOnly := sessionRun("go-advanced-01", 150, day(4))
+	clientOnly.SessionID = ""
+	approved := sessionRun("go-basics-01", 40, day(5))
+	approved.SessionID = ""
+	approved.ReviewStatus = models.ReviewStatusApproved
+
+	store := newAchievementStore()
+	store.history = []models.TypingMetrics{sessionRun("go-basics-01", 40, day(1)), flagged, rejected, clientOnly, approved}
+	a := NewAchievements(store, testCatalog, rules)
+
+	awarded, err := a.Evaluate("u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the basic lesson counts; days 2 to 4 don't, so there's no streak
+	if names := badgeNames(awarded); !reflect.DeepEqual(names, []string{"Basics"}) {
+		t.Errorf("awarded %v, want only Basics", names)
+	}
+
+	// An admin approving the flagged run makes it count
+	store.history[1].ReviewStatus = models.ReviewStatusApproved
+	if awarded, err = a.Evaluate("u1"); err != nil {
+		t.Fatal(err)
+	}
+	if names := badgeNames(awarded); !reflect.DeepEqual(names, []string{"Advanced", "Speed"}) {
+		t.Errorf("awarded %v after approval, want Advanced and Speed", names)
+	}
+}
+
+func TestEvaluateIsIdempotent(t *testing.T) {
+	store := newAchievementStore()
+	store.history = []models.TypingMetrics{
+		sessionRun("go-basics-01", 40, day(1)),
+		sessionRun("go-basics-02", 105, day(2)),
+		sessionRun("go-advanced-01", 40, day(3)),
+	}
+	a := NewAchievements(store, testCatalog, DefaultRules)
+
+	first, err := a.Evaluate("u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The streak is only 3 days
+	if names := badgeNames(first); !reflect.DeepEqual(names, []string{"Go Basics", "100 WPM Club", "Advanced Explorer"}) {
+		t.Errorf("first evaluation awarded %v", names)
+	}
+
+	second, err := a.Evaluate("u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second) != 0 {
+		t.Errorf("second evaluation awarded %v, want nothing", badgeNames(second))
+	}
+	if len(store.held) != 3 {
+		t.Errorf("user holds %d badges, want 3", len(store.held))
+	}
+	if len(store.badges) != 3 {
+		t.Errorf("%d badges were created, want 3", len(store.badges))
+	}
+}
+
+func TestAchievementsNeedAnAccount(t *testing.T) {
+	store := newAchievementStore()
+	store.history = []models.TypingMetrics{sessionRun("go-basics-01", 40, day(1))}
+	rules := []Rule{{ID: "basics", Badge: models.Badge{Name: "Basics"}, CompleteAny: &LessonFilter{Level: "basic"}}}
+	a := NewAchievements(store, testCatalog, rules)
+
+	store.user.IsGuest = true
+	if pending, err := a.Pending("u1"); err != nil || len(pending) != 0 {
+		t.Errorf("guest: got %v, %v", pending, err)
+	}
+
+	store.user.IsGuest = false
+	a.RequireVerifiedEmail = true
+	if pending, err := a.Pending("u1"); err != nil || len(pending) != 0 {
+		t.Errorf("unverified email: got %v, %v", pending, err)
+	}
+
+	verified := day(1)
+	store.user.EmailVerifiedAt = &verified
+	if pending, err := a.Pending("u1"); err != nil || len(pending) != 1 {
+		t.Errorf("verified email: got %v, %v", pending, err)
+	}
+}
+
+func badgeNames(badges []models.Badge) []string {
+	var names []string
+	for _, b := range badges {
+		names = append(names, b.Name)
+	}
+	return names
+}
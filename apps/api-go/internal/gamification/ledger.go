@@ -0,0 +1,131 @@
+package gamification
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+// ReasonRecomputePrefix starts the reason of a compensating transaction
+// written by Recompute; the rest is the reason it corrects, e.g.
+// "recompute:lesson_complete"
+const ReasonRecomputePrefix = "recompute:"
+
+// CorrectedReason returns the reason a transaction's points count towards,
+// looking through compensating transactions
+func CorrectedReason(reason string) string {
+	return strings.TrimPrefix(reason, ReasonRecomputePrefix)
+}
+
+// LessonLookup finds the lessons runs were typed on
+type LessonLookup interface {
+	Get(id string) (*models.Lesson, bool)
+}
+
+// PreviousRuns returns when a user earned points for a lesson before, one
+// time per run whose transactions add up to more than zero. The run in
+// exceptMetricsID is left out.
+func PreviousRuns(ledger []models.PointTransaction, lessonID, exceptMetricsID string) []time.Time {
+	net := make(map[string]int)
+	first := make(map[string]time.Time)
+	var order []string
+	for _, pt := range ledger {
+		if pt.SourceID != lessonID || pt.MetricsID == "" || pt.MetricsID == exceptMetricsID {
+			continue
+		}
+		if _, ok := first[pt.MetricsID]; !ok {
+			first[pt.MetricsID] = pt.CreatedAt
+			order = append(order, pt.MetricsID)
+		}
+		net[pt.MetricsID] += pt.Points
+	}
+
+	var previous []time.Time
+	for _, id := range order {
+		if net[id] > 0 {
+			previous = append(previous, first[id])
+		}
+	}
+	return previous
+}
+
+// Recompute replays a user's runs through the registry and returns the
+// compensating transactions that bring their ledger in line with it. history
+// must be oldest first. Runs count when they came from a typing session or
+// already have points, which includes runs from before typing sessions once
+// migration 0015 has linked their points; client-reported runs that earned
+// nothing stay out. Repeats decay as if the registry had always been in use.
+// Running it again on the corrected ledger returns nothing.
+func Recompute(registry *Registry, lessons LessonLookup, history []models.TypingMetrics, ledger []models.PointTransaction) []models.PointTransaction {
+	// Points currently recorded per run and reason
+	recorded := make(map[string]map[string]int)
+	for _, pt := range ledger {
+		if pt.MetricsID == "" {
+			continue
+		}
+		if recorded[pt.MetricsID] == nil {
+			recorded[pt.MetricsID] = make(map[string]int)
+		}
+		recorded[pt.MetricsID][CorrectedReason(pt.Reason)] += pt.Points
+	}
+
+	previous := make(map[string][]time.Time) // lesson ID -> runs that earned points
+	var corrections []models.PointTransaction
+	for _, m := range history {
+		if m.SessionID == "" && recorded[m.ID] == nil {
+			continue
+		}
+
+		run := Run{Metrics: m, Previous: previous[m.LessonID]}
+		if lesson, ok := lessons.Get(m.LessonID); ok {
+			run.Lesson = lesson
+		}
+		name, strategy := registry.For(run.Lesson)
+		awards := strategy.Calculate(run)
+
+		expected := make(map[string]int, len(awards))
+		reasons := make([]string, 0, len(awards))
+		earned := 0
+		for _, a := range awards {
+			if _, ok := expected[a.Reason]; !ok {
+				reasons = append(reasons, a.Reason)
+			}
+			expected[a.Reason] += a.Points
+			earned += a.Points
+		}
+		if earned > 0 {
+			previous[m.LessonID] = append(previous[m.LessonID], m.CreatedAt)
+		}
+
+		// Reasons the registry no longer pays are taken back
+		var dropped []string
+		for reason := range recorded[m.ID] {
+			if _, ok := expected[reason]; !ok {
+				dropped = append(dropped, reason)
+			}
+		}
+		sort.Strings(dropped)
+
+		for _, reason := range append(reasons, dropped...) {
+			delta := expected[reason] - recorded[m.ID][reason]
+			if delta == 0 {
+				continue
+			}
+			corrections = append(corrections, models.PointTransaction{
+				ID:              uuid.New().String(),
+				UserID:          m.UserID,
+				SourceID:        m.LessonID,
+				MetricsID:       m.ID,
+				Points:          delta,
+				Reason:          ReasonRecomputePrefix + reason,
+				Strategy:        name,
+				StrategyVersion: registry.Version(),
+				CreatedAt:       m.CreatedAt,
+			})
+		}
+	}
+	return corrections
+}
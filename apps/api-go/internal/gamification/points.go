@@ -1,25 +1,69 @@
 package gamification
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
 	"github.com/typing-code-learn/api-go/internal/models"
 )
 
+// Reasons recorded on the point transactions of a run
+const (
+	ReasonLessonComplete  = "lesson_complete"
+	ReasonFirstCompletion = "first_completion"
+)
+
+// Run is a finished typing run to be scored
+type Run struct {
+	Metrics models.TypingMetrics
+	// Lesson is nil when the lesson is no longer loaded
+	Lesson *models.Lesson
+	// Previous holds when the user earned points for the same lesson before
+	Previous []time.Time
+}
+
+// Award is a number of points and the reason they were given
+type Award struct {
+	Points int
+	Reason string
+}
+
 // PointStrategy defines how points are calculated
 type PointStrategy interface {
-	Calculate(metrics models.TypingMetrics) int
+	Calculate(run Run) []Award
 }
 
 // DefaultPointStrategy implements a standard point calculation
 type DefaultPointStrategy struct {
-	BasePointsPerChar float64
-	WPMValidation     float64 // Minimum WPM to count
-	AccuracyThreshold float64 // Minimum accuracy to count
+	BasePointsPerChar float64 `json:"basePointsPerChar"`
+	WPMValidation     float64 `json:"minWpm"`      // Minimum WPM to count
+	AccuracyThreshold float64 `json:"minAccuracy"` // Minimum accuracy to count
+
+	// Multiplier scales the points of every run
+	Multiplier float64 `json:"multiplier"`
+	// DifficultyMultipliers scale points by lesson difficulty; difficulties
+	// not listed count as 1
+	DifficultyMultipliers map[string]float64 `json:"difficultyMultipliers"`
+	// FirstCompletionBonus is paid the first time a user earns points for a
+	// lesson
+	FirstCompletionBonus int `json:"firstCompletionBonus"`
+	// RepeatDecay multiplies the points of a run once for every earlier run
+	// of the same lesson that earned points within RepeatWindowHours (0 for
+	// ever), but not below RepeatFloor
+	RepeatDecay       float64 `json:"repeatDecay"`
+	RepeatFloor       float64 `json:"repeatFloor"`
+	RepeatWindowHours float64 `json:"repeatWindowHours"`
 }
 
-// Calculate computes the points for a given session based on metrics
-func (s *DefaultPointStrategy) Calculate(metrics models.TypingMetrics) int {
-	if metrics.Accuracy < s.AccuracyThreshold {
-		return 0
+// Calculate computes the points for a given run based on metrics, the
+// lesson's difficulty and how often the lesson was repeated
+func (s *DefaultPointStrategy) Calculate(run Run) []Award {
+	metrics := run.Metrics
+	if metrics.Accuracy < s.AccuracyThreshold || metrics.WPM < s.WPMValidation {
+		return nil
 	}
 
 	// Base score: Correct characters typed
@@ -35,9 +79,50 @@ func (s *DefaultPointStrategy) Calculate(metrics models.TypingMetrics) int {
 	// Accuracy multiplier: (Accuracy / 100) ^ 2 to punish low accuracy heavily
 	accuracyMultiplier := (metrics.Accuracy / 100.0) * (metrics.Accuracy / 100.0)
 
-	finalScore := baseScore * speedMultiplier * accuracyMultiplier
+	finalScore := baseScore * speedMultiplier * accuracyMultiplier * s.Multiplier
+	if run.Lesson != nil {
+		if m, ok := s.DifficultyMultipliers[run.Lesson.Difficulty]; ok {
+			finalScore *= m
+		}
+	}
+	finalScore *= s.repeatFactor(run)
+
+	var awards []Award
+	if points := int(finalScore); points > 0 {
+		awards = append(awards, Award{Points: points, Reason: ReasonLessonComplete})
+	}
+	if len(run.Previous) == 0 && s.FirstCompletionBonus > 0 {
+		awards = append(awards, Award{Points: s.FirstCompletionBonus, Reason: ReasonFirstCompletion})
+	}
+	return awards
+}
+
+// repeatFactor is the share of points left after diminishing returns
+func (s *DefaultPointStrategy) repeatFactor(run Run) float64 {
+	repeats := 0
+	for _, at := range run.Previous {
+		if s.RepeatWindowHours == 0 || run.Metrics.CreatedAt.Sub(at).Hours() < s.RepeatWindowHours {
+			repeats++
+		}
+	}
+	return math.Max(math.Pow(s.RepeatDecay, float64(repeats)), s.RepeatFloor)
+}
 
-	return int(finalScore)
+// validate checks that the settings make sense
+func (s *DefaultPointStrategy) validate() error {
+	if s.BasePointsPerChar < 0 || s.WPMValidation < 0 || s.AccuracyThreshold < 0 || s.Multiplier < 0 ||
+		s.FirstCompletionBonus < 0 || s.RepeatWindowHours < 0 {
+		return fmt.Errorf("values must not be negative")
+	}
+	for difficulty, m := range s.DifficultyMultipliers {
+		if m < 0 {
+			return fmt.Errorf("multiplier of %s must not be negative", difficulty)
+		}
+	}
+	if s.RepeatDecay < 0 || s.RepeatDecay > 1 || s.RepeatFloor < 0 || s.RepeatFloor > 1 {
+		return fmt.Errorf("repeatDecay and repeatFloor must be between 0 and 1")
+	}
+	return nil
 }
 
 // NewDefaultStrategy creates a strategy with sensible defaults
@@ -46,32 +131,159 @@ func NewDefaultStrategy() *DefaultPointStrategy {
 		BasePointsPerChar: 1.0,
 		WPMValidation:     10.0,
 		AccuracyThreshold: 70.0,
+		Multiplier:        1.0,
+		DifficultyMultipliers: map[string]float64{
+			"beginner":     1.0,
+			"intermediate": 1.5,
+			"advanced":     2.0,
+		},
+		FirstCompletionBonus: 50,
+		RepeatDecay:          0.5,
+		RepeatFloor:          0.1,
+		RepeatWindowHours:    24,
 	}
 }
 
-// RankTier defines a level in the ranking system
-type RankTier string
+// DefaultStrategyName is the strategy used for lessons no rule matches
+const DefaultStrategyName = "default"
 
+// Versions of the built-in point strategies. Every point transaction records
+// the version that calculated it, so the ledger can be audited and replayed.
 const (
-	TierNovice     RankTier = "Novice"
-	TierApprentice RankTier = "Apprentice"
-	TierCoder      RankTier = "Coder"
-	TierHacker     RankTier = "Hacker"
-	TierGuru       RankTier = "Guru"
+	// StrategyVersionLegacy is the original formula: no minimum speed,
+	// difficulty multipliers, bonus or diminishing returns
+	StrategyVersionLegacy = "v1"
+	// StrategyVersionCurrent is the version of DefaultRegistry
+	StrategyVersionCurrent = "v2"
 )
 
-// GetTier returns the tier based on total points
-func GetTier(totalPoints int) RankTier {
-	switch {
-	case totalPoints >= 100000:
-		return TierGuru
-	case totalPoints >= 50000:
-		return TierHacker
-	case totalPoints >= 10000:
-		return TierCoder
-	case totalPoints >= 1000:
-		return TierApprentice
+// StrategyRule picks a strategy for lessons matching all of its non-empty
+// fields
+type StrategyRule struct {
+	Difficulty string `json:"difficulty,omitempty"`
+	Mode       string `json:"mode,omitempty"`
+	Level      string `json:"level,omitempty"`
+	Strategy   string `json:"strategy"`
+}
+
+// matches reports whether a lesson passes the rule
+func (r StrategyRule) matches(l *models.Lesson) bool {
+	return (r.Difficulty == "" || r.Difficulty == l.Difficulty) &&
+		(r.Mode == "" || r.Mode == l.Mode) &&
+		(r.Level == "" || r.Level == l.Level)
+}
+
+// Registry chooses the point strategy for a lesson
+type Registry struct {
+	version    string
+	strategies map[string]PointStrategy
+	rules      []StrategyRule
+}
+
+// NewRegistry creates a registry; the first matching rule picks a lesson's
+// strategy and strategies must include DefaultStrategyName
+func NewRegistry(version string, strategies map[string]PointStrategy, rules []StrategyRule) (*Registry, error) {
+	if version == "" {
+		return nil, fmt.Errorf("point strategies need a version")
+	}
+	if strategies[DefaultStrategyName] == nil {
+		return nil, fmt.Errorf("no %q point strategy", DefaultStrategyName)
+	}
+	for i, r := range rules {
+		if strategies[r.Strategy] == nil {
+			return nil, fmt.Errorf("rule %d refers to unknown point strategy %q", i+1, r.Strategy)
+		}
+	}
+	return &Registry{version: version, strategies: strategies, rules: rules}, nil
+}
+
+// DefaultRegistry pays practice mode lessons half the points of strict ones
+func DefaultRegistry() *Registry {
+	practice := NewDefaultStrategy()
+	practice.Multiplier = 0.5
+	r, _ := NewRegistry(
+		StrategyVersionCurrent,
+		map[string]PointStrategy{DefaultStrategyName: NewDefaultStrategy(), "practice": practice},
+		[]StrategyRule{{Mode: "practice", Strategy: "practice"}},
+	)
+	return r
+}
+
+// BuiltinRegistry returns the built-in strategies of a version
+func BuiltinRegistry(version string) (*Registry, error) {
+	switch version {
+	case StrategyVersionLegacy:
+		return NewRegistry(StrategyVersionLegacy, map[string]PointStrategy{DefaultStrategyName: &DefaultPointStrategy{
+			BasePointsPerChar: 1.0,
+			AccuracyThreshold: 70.0,
+			Multiplier:        1.0,
+			RepeatDecay:       1.0,
+			RepeatFloor:       1.0,
+		}}, nil)
+	case StrategyVersionCurrent:
+		return DefaultRegistry(), nil
 	default:
-		return TierNovice
+		return nil, fmt.Errorf("unknown point strategy version %q (built in: %s, %s)", version, StrategyVersionLegacy, StrategyVersionCurrent)
+	}
+}
+
+// Version identifies the strategies and rules of the registry
+func (r *Registry) Version() string {
+	return r.version
+}
+
+// For returns the name of the strategy for a lesson, and the strategy. A nil
+// lesson gets the default strategy.
+func (r *Registry) For(lesson *models.Lesson) (string, PointStrategy) {
+	if lesson != nil {
+		for _, rule := range r.rules {
+			if rule.matches(lesson) {
+				return rule.Strategy, r.strategies[rule.Strategy]
+			}
+		}
+	}
+	return DefaultStrategyName, r.strategies[DefaultStrategyName]
+}
+
+// registryFile is the JSON format LoadRegistry reads
+type registryFile struct {
+	Version    string                     `json:"version"`
+	Strategies map[string]json.RawMessage `json:"strategies"`
+	Rules      []StrategyRule             `json:"rules"`
+}
+
+// LoadRegistry reads a version, strategies and rules from a JSON file. Each
+// strategy starts from NewDefaultStrategy, so fields left out keep their
+// defaults; a "default" strategy with all defaults is added if the file has
+// none. The version must be new whenever the file changes, and may not be
+// one of the built-in versions.
+func LoadRegistry(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file registryFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid point strategies in %s: %w", path, err)
+	}
+	if file.Version == StrategyVersionLegacy || file.Version == StrategyVersionCurrent {
+		return nil, fmt.Errorf("point strategies in %s reuse the built-in version %q", path, file.Version)
+	}
+
+	strategies := map[string]PointStrategy{DefaultStrategyName: NewDefaultStrategy()}
+	for name, raw := range file.Strategies {
+		s := NewDefaultStrategy()
+		if err := json.Unmarshal(raw, s); err != nil {
+			return nil, fmt.Errorf("invalid point strategy %q in %s: %w", name, path, err)
+		}
+		if err := s.validate(); err != nil {
+			return nil, fmt.Errorf("invalid point strategy %q in %s: %w", name, path, err)
+		}
+		strategies[name] = s
+	}
+	r, err := NewRegistry(file.Version, strategies, file.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("invalid point strategies in %s: %w", path, err)
 	}
+	return r, nil
 }
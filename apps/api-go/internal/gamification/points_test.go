@@ -0,0 +1,327 @@
+package gamification
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+var now = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// run is a run of 100 correct characters at 100 WPM and full accuracy,
+// worth 200 points before multipliers
+func run(lesson *models.Lesson, previous ...time.Time) Run {
+	return Run{
+		Metrics:  models.TypingMetrics{WPM: 100, Accuracy: 100, CorrectChars: 100, CreatedAt: now},
+		Lesson:   lesson,
+		Previous: previous,
+	}
+}
+
+// lessonPoints returns the lesson_complete points of awards
+func lessonPoints(awards []Award) int {
+	for _, a := range awards {
+		if a.Reason == ReasonLessonComplete {
+			return a.Points
+		}
+	}
+	return 0
+}
+
+func TestDifficultyMultipliers(t *testing.T) {
+	tests := []struct {
+		name   string
+		lesson *models.Lesson
+		want   int
+	}{
+		{"beginner", &models.Lesson{Difficulty: "beginner"}, 200},
+		{"intermediate", &models.Lesson{Difficulty: "intermediate"}, 300},
+		{"advanced", &models.Lesson{Difficulty: "advanced"}, 400},
+		{"unlisted difficulty", &models.Lesson{Difficulty: "expert"}, 200},
+		{"lesson no longer loaded", nil, 200},
+	}
+	s := NewDefaultStrategy()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lessonPoints(s.Calculate(run(tt.lesson))); got != tt.want {
+				t.Errorf("got %d points, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFirstCompletionBonus(t *testing.T) {
+	s := NewDefaultStrategy()
+	lesson := &models.Lesson{Difficulty: "beginner"}
+
+	got := s.Calculate(run(lesson))
+	want := []Award{{Points: 200, Reason: ReasonLessonComplete}, {Points: 50, Reason: ReasonFirstCompletion}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("first run: got %v, want %v", got, want)
+	}
+
+	// Any earlier run, even outside the repeat window, rules the bonus out
+	got = s.Calculate(run(lesson, now.Add(-30*24*time.Hour)))
+	want = []Award{{Points: 200, Reason: ReasonLessonComplete}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("later run: got %v, want %v", got, want)
+	}
+
+	s.FirstCompletionBonus = 0
+	got = s.Calculate(run(lesson))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("without bonus: got %v, want %v", got, want)
+	}
+}
+
+func TestRepeatDecay(t *testing.T) {
+	lesson := &models.Lesson{Difficulty: "beginner"}
+	hoursAgo := func(hours ...int) []time.Time {
+		var times []time.Time
+		for _, h := range hours {
+			times = append(times, now.Add(-time.Duration(h)*time.Hour))
+		}
+		return times
+	}
+
+	tests := []struct {
+		name        string
+		windowHours float64
+		previous    []time.Time
+		want        int
+	}{
+		{"one repeat", 24, hoursAgo(1), 100},
+		{"two repeats", 24, hoursAgo(1, 2), 50},
+		{"three repeats", 24, hoursAgo(1, 2, 3), 25},
+		{"decays down to the floor", 24, hoursAgo(1, 2, 3, 4), 20},
+		{"stays at the floor", 24, hoursAgo(1, 2, 3, 4, 5, 6, 7, 8), 20},
+		{"runs outside the window don't count", 24, hoursAgo(1, 25, 48), 100},
+		{"no window counts every run", 0, hoursAgo(1, 25, 48), 25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewDefaultStrategy()
+			s.RepeatWindowHours = tt.windowHours
+			if got := lessonPoints(s.Calculate(run(lesson, tt.previous...))); got != tt.want {
+				t.Errorf("got %d points, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinimumsRejectRuns(t *testing.T) {
+	tests := []struct {
+		name     string
+		wpm      float64
+		accuracy float64
+		counts   bool
+	}{
+		{"at the minimums", 10, 70, true},
+		{"below the minimum WPM", 9.9, 100, false},
+		{"below the minimum accuracy", 100, 69.9, false},
+		{"no speed at all", 0, 100, false},
+	}
+	s := NewDefaultStrategy()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := run(&models.Lesson{Difficulty: "beginner"})
+			r.Metrics.WPM, r.Metrics.Accuracy = tt.wpm, tt.accuracy
+			awards := s.Calculate(r)
+			if counts := len(awards) > 0; counts != tt.counts {
+				t.Errorf("got %v, want awards: %v", awards, tt.counts)
+			}
+		})
+	}
+}
+
+func TestLegacyStrategyHasNoMinimumSpeed(t *testing.T) {
+	r, err := BuiltinRegistry(StrategyVersionLegacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, s := r.For(nil)
+	slow := run(&models.Lesson{Difficulty: "advanced"}, now.Add(-time.Hour))
+	slow.Metrics.WPM = 5
+	want := []Award{{Points: 105, Reason: ReasonLessonComplete}}
+	if got := s.Calculate(slow); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := BuiltinRegistry("v0"); err == nil {
+		t.Error("unknown built-in version was accepted")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(s *DefaultPointStrategy)
+		valid  bool
+	}{
+		{"defaults", func(s *DefaultPointStrategy) {}, true},
+		{"zero everything", func(s *DefaultPointStrategy) { *s = DefaultPointStrategy{} }, true},
+		{"negative points per char", func(s *DefaultPointStrategy) { s.BasePointsPerChar = -1 }, false},
+		{"negative minimum WPM", func(s *DefaultPointStrategy) { s.WPMValidation = -1 }, false},
+		{"negative minimum accuracy", func(s *DefaultPointStrategy) { s.AccuracyThreshold = -1 }, false},
+		{"negative multiplier", func(s *DefaultPointStrategy) { s.Multiplier = -0.5 }, false},
+		{"negative bonus", func(s *DefaultPointStrategy) { s.FirstCompletionBonus = -10 }, false},
+		{"negative window", func(s *DefaultPointStrategy) { s.RepeatWindowHours = -1 }, false},
+		{"negative difficulty multiplier", func(s *DefaultPointStrategy) { s.DifficultyMultipliers["advanced"] = -2 }, false},
+		{"decay above 1", func(s *DefaultPointStrategy) { s.RepeatDecay = 1.5 }, false},
+		{"negative decay", func(s *DefaultPointStrategy) { s.RepeatDecay = -0.5 }, false},
+		{"floor above 1", func(s *DefaultPointStrategy) { s.RepeatFloor = 2 }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewDefaultStrategy()
+			tt.change(s)
+			if err := s.validate(); (err == nil) != tt.valid {
+				t.Errorf("validate() = %v, want valid: %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestRegistryForRulePrecedence(t *testing.T) {
+	strategies := map[string]PointStrategy{
+		DefaultStrategyName: NewDefaultStrategy(),
+		"practice":          NewDefaultStrategy(),
+		"advanced":          NewDefaultStrategy(),
+		"exercises":         NewDefaultStrategy(),
+	}
+	r, err := NewRegistry("test-1", strategies, []StrategyRule{
+		{Mode: "practice", Strategy: "practice"},
+		{Difficulty: "advanced", Strategy: "advanced"},
+		{Level: "exercises", Mode: "strict", Strategy: "exercises"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		lesson *models.Lesson
+		want   string
+	}{
+		{"first matching rule wins", &models.Lesson{Mode: "practice", Difficulty: "advanced"}, "practice"},
+		{"second rule", &models.Lesson{Mode: "strict", Difficulty: "advanced"}, "advanced"},
+		{"all fields of a rule must match", &models.Lesson{Mode: "strict", Level: "exercises"}, "exercises"},
+		{"a partial match is no match", &models.Lesson{Mode: "", Level: "exercises"}, DefaultStrategyName},
+		{"no rule matches", &models.Lesson{Mode: "strict", Difficulty: "beginner"}, DefaultStrategyName},
+		{"lesson no longer loaded", nil, DefaultStrategyName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, s := r.For(tt.lesson)
+			if name != tt.want {
+				t.Errorf("got strategy %q, want %q", name, tt.want)
+			}
+			if s != strategies[tt.want] {
+				t.Errorf("strategy %q doesn't match its name", name)
+			}
+		})
+	}
+}
+
+func TestNewRegistryErrors(t *testing.T) {
+	ok := map[string]PointStrategy{DefaultStrategyName: NewDefaultStrategy()}
+	if _, err := NewRegistry("", ok, nil); err == nil {
+		t.Error("registry without a version was accepted")
+	}
+	if _, err := NewRegistry("test-1", map[string]PointStrategy{"other": NewDefaultStrategy()}, nil); err == nil {
+		t.Error("registry without a default strategy was accepted")
+	}
+	if _, err := NewRegistry("test-1", ok, []StrategyRule{{Mode: "practice", Strategy: "missing"}}); err == nil {
+		t.Error("rule with an unknown strategy was accepted")
+	}
+}
+
+// writeConfig writes a point strategies file and returns its path
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "points.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadRegistryExample(t *testing.T) {
+	r, err := LoadRegistry(filepath.Join("..", "..", "points.example.json"))
+	if err != nil {
+		t.Fatalf("failed to load the example: %v", err)
+	}
+	if r.Version() != "example-1" {
+		t.Errorf("got version %q, want example-1", r.Version())
+	}
+	if name, _ := r.For(&models.Lesson{Mode: "practice", Level: "exercises"}); name != "practice" {
+		t.Errorf("practice exercise got strategy %q, want practice", name)
+	}
+	if name, _ := r.For(&models.Lesson{Mode: "strict", Level: "exercises"}); name != "exercises" {
+		t.Errorf("strict exercise got strategy %q, want exercises", name)
+	}
+}
+
+func TestLoadRegistryDefaults(t *testing.T) {
+	r, err := LoadRegistry(writeConfig(t, `{
+		"version": "test-1",
+		"strategies": {"fast": {"multiplier": 2, "firstCompletionBonus": 0}},
+		"rules": [{"difficulty": "advanced", "strategy": "fast"}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A file without a default strategy gets the built-in one
+	name, s := r.For(&models.Lesson{Difficulty: "beginner"})
+	if name != DefaultStrategyName || !reflect.DeepEqual(s, NewDefaultStrategy()) {
+		t.Errorf("got %q %+v, want the built-in default strategy", name, s)
+	}
+
+	// Fields left out keep their defaults
+	name, s = r.For(&models.Lesson{Difficulty: "advanced"})
+	want := NewDefaultStrategy()
+	want.Multiplier = 2
+	want.FirstCompletionBonus = 0
+	if name != "fast" || !reflect.DeepEqual(s, want) {
+		t.Errorf("got %q %+v, want fast %+v", name, s, want)
+	}
+	if got := lessonPoints(s.Calculate(run(&models.Lesson{Difficulty: "advanced"}))); got != 800 {
+		t.Errorf("fast advanced run got %d points, want 800", got)
+	}
+}
+
+func TestLoadRegistryErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"invalid JSON", `{"version": "test-1",`, "invalid point strategies"},
+		{"no version", `{"strategies": {}}`, "need a version"},
+		{"legacy version", `{"version": "v1"}`, "built-in version"},
+		{"current version", `{"version": "v2"}`, "built-in version"},
+		{"strategy of the wrong type", `{"version": "test-1", "strategies": {"default": []}}`, `invalid point strategy "default"`},
+		{"field of the wrong type", `{"version": "test-1", "strategies": {"default": {"multiplier": "2"}}}`, `invalid point strategy "default"`},
+		{"invalid strategy", `{"version": "test-1", "strategies": {"fast": {"repeatDecay": 2}}}`, "between 0 and 1"},
+		{"negative value", `{"version": "test-1", "strategies": {"fast": {"minWpm": -1}}}`, "must not be negative"},
+		{"unknown strategy", `{"version": "test-1", "rules": [{"mode": "practice", "strategy": "missing"}]}`, `unknown point strategy "missing"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadRegistry(writeConfig(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := LoadRegistry(filepath.Join(t.TempDir(), "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("missing file: got error %v, want not exist", err)
+	}
+}
@@ -0,0 +1,370 @@
+package gamification
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+// RankTier defines a level in the ranking system
+type RankTier string
+
+const (
+	TierNovice     RankTier = "Novice"
+	TierApprentice RankTier = "Apprentice"
+	TierCoder      RankTier = "Coder"
+	TierHacker     RankTier = "Hacker"
+	TierGuru       RankTier = "Guru"
+)
+
+// Tier is a step of the tier ladder, reached at MinPoints total points
+type Tier struct {
+	Name      RankTier `json:"name"`
+	MinPoints int      `json:"minPoints"`
+	// Badge is a cosmetic badge granted on promotion; it is looked up by
+	// name and created from this template when it doesn't exist
+	Badge *models.Badge `json:"badge,omitempty"`
+	// Lessons lists the IDs of lessons only users who reached the tier can
+	// type
+	Lessons []string `json:"lessons,omitempty"`
+}
+
+// Tiers is a tier ladder, lowest tier first
+type Tiers []Tier
+
+// DefaultTiers is the ladder used when none is configured. Every promotion
+// earns a badge and no lessons are restricted.
+var DefaultTiers = Tiers{
+	{Name: TierNovice, MinPoints: 0},
+	{
+		Name: TierApprentice, MinPoints: 1000,
+		Badge: &models.Badge{
+			Name: "Apprentice Tier", Color: "#CD7F32", Icon: "tier-apprentice", Rarity: models.RarityCommon, Category: "tier",
+			Description: "Alcanzó el rango Aprendiz", DescriptionEn: "Reached the Apprentice tier",
+			Criteria: "Consigue 1.000 puntos", CriteriaEn: "Earn 1,000 points",
+		},
+	},
+	{
+		Name: TierCoder, MinPoints: 10000,
+		Badge: &models.Badge{
+			Name: "Coder Tier", Color: "#C0C0C0", Icon: "tier-coder", Rarity: models.RarityUncommon, Category: "tier",
+			Description: "Alcanzó el rango Coder", DescriptionEn: "Reached the Coder tier",
+			Criteria: "Consigue 10.000 puntos", CriteriaEn: "Earn 10,000 points",
+		},
+	},
+	{
+		Name: TierHacker, MinPoints: 50000,
+		Badge: &models.Badge{
+			Name: "Hacker Tier", Color: "#FFD700", Icon: "tier-hacker", Rarity: models.RarityRare, Category: "tier",
+			Description: "Alcanzó el rango Hacker", DescriptionEn: "Reached the Hacker tier",
+			Criteria: "Consigue 50.000 puntos", CriteriaEn: "Earn 50,000 points",
+		},
+	},
+	{
+		Name: TierGuru, MinPoints: 100000,
+		Badge: &models.Badge{
+			Name: "Guru Tier", Color: "#B9F2FF", Icon: "tier-guru", Rarity: models.RarityLegendary, Category: "tier",
+			Description: "Alcanzó el rango Gurú", DescriptionEn: "Reached the Guru tier",
+			Criteria: "Consigue 100.000 puntos", CriteriaEn: "Earn 100,000 points",
+		},
+	},
+}
+
+// GetTier returns the tier of the default ladder for total points
+func GetTier(totalPoints int) RankTier {
+	return DefaultTiers[DefaultTiers.index(totalPoints)].Name
+}
+
+// validate checks that the ladder starts at 0 points, rises strictly and
+// has unique names
+func (t Tiers) validate() error {
+	if len(t) == 0 {
+		return fmt.Errorf("no tiers")
+	}
+	if t[0].MinPoints != 0 {
+		return fmt.Errorf("the first tier must start at 0 points")
+	}
+	names := make(map[RankTier]bool, len(t))
+	for i, tier := range t {
+		if tier.Name == "" || names[tier.Name] {
+			return fmt.Errorf("tier %d needs a unique name", i+1)
+		}
+		names[tier.Name] = true
+		if i > 0 && tier.MinPoints <= t[i-1].MinPoints {
+			return fmt.Errorf("tier %s must need more points than %s", tier.Name, t[i-1].Name)
+		}
+		if tier.Badge != nil && (tier.Badge.Name == "" || tier.Badge.Color == "") {
+			return fmt.Errorf("the badge of tier %s needs a name and a color", tier.Name)
+		}
+	}
+	return nil
+}
+
+// LoadTiers reads a tier ladder from a JSON file of the form
+// {"tiers": [{"name": ..., "minPoints": ..., "badge": {...}, "lessons": [...]}]}
+func LoadTiers(path string) (Tiers, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Tiers Tiers `json:"tiers"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid tiers in %s: %w", path, err)
+	}
+	if err := file.Tiers.validate(); err != nil {
+		return nil, fmt.Errorf("invalid tiers in %s: %w", path, err)
+	}
+	return file.Tiers, nil
+}
+
+// index returns the position of the highest tier reached with points
+func (t Tiers) index(points int) int {
+	i := 0
+	for i+1 < len(t) && points >= t[i+1].MinPoints {
+		i++
+	}
+	return i
+}
+
+// Progress returns where total points stand on the ladder
+func (t Tiers) Progress(points int) models.TierProgress {
+	i := t.index(points)
+	progress := models.TierProgress{
+		Tier:       string(t[i].Name),
+		Points:     points,
+		TierPoints: t[i].MinPoints,
+		Progress:   1,
+	}
+	if i+1 < len(t) {
+		next := t[i+1]
+		progress.NextTier = string(next.Name)
+		progress.NextTierPoints = next.MinPoints
+		progress.PointsToNext = next.MinPoints - points
+		progress.Progress = float64(points-t[i].MinPoints) / float64(next.MinPoints-t[i].MinPoints)
+	}
+	return progress
+}
+
+// RequiredTier returns the lowest tier that unlocks a lesson; ok is false for
+// lessons open to everyone
+func (t Tiers) RequiredTier(lessonID string) (Tier, bool) {
+	for _, tier := range t {
+		for _, id := range tier.Lessons {
+			if id == lessonID {
+				return tier, true
+			}
+		}
+	}
+	return Tier{}, false
+}
+
+// Includes reports whether a tier is on the ladder
+func (t Tiers) Includes(name RankTier) bool {
+	for _, tier := range t {
+		if tier.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// TierStore is the data the promotion engine reads and writes
+type TierStore interface {
+	GetUserByID(id string) (*models.User, error)
+	GetUserPoints(userID string, startDate, endDate time.Time) (int, error)
+	RecordTierEvent(event models.TierEvent) (bool, error)
+	GetTierEvents(userID string) ([]models.TierEvent, error)
+	CreateBadge(badge models.Badge) (*models.Badge, error)
+	GetBadgeByName(name string) (*models.Badge, error)
+	AssignBadgeToUser(userID, badgeID string) error
+	GetUserBadges(userID string) ([]models.BadgeWithDetails, error)
+}
+
+// Promotions moves users up the tier ladder as their points grow
+type Promotions struct {
+	store TierStore
+	tiers Tiers
+
+	// RequireVerifiedEmail withholds tier badges from users without a
+	// verified email address
+	RequireVerifiedEmail bool
+}
+
+// NewPromotions creates a promotion engine for the ladder
+func NewPromotions(store TierStore, tiers Tiers) *Promotions {
+	return &Promotions{store: store, tiers: tiers}
+}
+
+// Tiers returns the ladder
+func (p *Promotions) Tiers() Tiers {
+	return p.tiers
+}
+
+// EnsureBadges creates the badges of all tiers that don't exist yet
+func (p *Promotions) EnsureBadges() error {
+	for _, tier := range p.tiers {
+		if tier.Badge == nil {
+			continue
+		}
+		if _, err := ensureBadge(p.store, *tier.Badge); err != nil {
+			return fmt.Errorf("failed to get badge %s for tier %s: %w", tier.Badge.Name, tier.Name, err)
+		}
+	}
+	return nil
+}
+
+// Points returns a user's total points that count towards tiers, the same
+// as the all-time leaderboard
+func (p *Promotions) Points(userID string) (int, error) {
+	return p.store.GetUserPoints(userID, time.Time{}, time.Now())
+}
+
+// Progress returns where a user stands on the ladder
+func (p *Promotions) Progress(userID string) (*models.TierProgress, error) {
+	points, err := p.Points(userID)
+	if err != nil {
+		return nil, err
+	}
+	progress := p.tiers.Progress(points)
+	return &progress, nil
+}
+
+// Reached returns the tiers a user has reached, lowest first: those their
+// points reach and every tier up to their highest recorded promotion, so
+// losing points never takes a tier back
+func (p *Promotions) Reached(userID string) (Tiers, error) {
+	points, err := p.Points(userID)
+	if err != nil {
+		return nil, err
+	}
+	highest := p.tiers.index(points)
+
+	events, err := p.store.GetTierEvents(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range events {
+		for i := highest + 1; i < len(p.tiers); i++ {
+			if string(p.tiers[i].Name) == e.Tier {
+				highest = i
+			}
+		}
+	}
+	return p.tiers[:highest+1], nil
+}
+
+// Events returns a user's promotions, oldest first. Tiers reached at once
+// follow the ladder; tiers no longer on it come last.
+func (p *Promotions) Events(userID string) ([]models.TierEvent, error) {
+	events, err := p.store.GetTierEvents(userID)
+	if err != nil {
+		return nil, err
+	}
+	rank := make(map[string]int, len(p.tiers))
+	for i, tier := range p.tiers {
+		rank[string(tier.Name)] = i + 1
+	}
+	position := func(tier string) int {
+		if r, ok := rank[tier]; ok {
+			return r
+		}
+		return len(p.tiers) + 1
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].CreatedAt.Equal(events[j].CreatedAt) {
+			return events[i].CreatedAt.Before(events[j].CreatedAt)
+		}
+		return position(events[i].Tier) < position(events[j].Tier)
+	})
+	return events, nil
+}
+
+// Promote records the tiers a user reached for the first time and grants
+// the badges of every tier they reached. It returns both; running it again
+// returns nothing. Losing points never takes a tier event or badge back.
+func (p *Promotions) Promote(userID string) ([]models.TierEvent, []models.Badge, error) {
+	points, err := p.Points(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	reached := p.tiers[:p.tiers.index(points)+1]
+
+	events, err := p.store.GetTierEvents(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	recorded := make(map[string]bool, len(events))
+	for _, e := range events {
+		recorded[e.Tier] = true
+	}
+
+	// Everyone starts in the first tier, so it is never a promotion
+	var promoted []models.TierEvent
+	now := time.Now()
+	for _, tier := range reached[1:] {
+		if recorded[string(tier.Name)] {
+			continue
+		}
+		event := models.TierEvent{
+			ID:        uuid.New().String(),
+			UserID:    userID,
+			Tier:      string(tier.Name),
+			Points:    points,
+			CreatedAt: now,
+		}
+		ok, err := p.store.RecordTierEvent(event)
+		if err != nil {
+			return promoted, nil, err
+		}
+		if ok {
+			promoted = append(promoted, event)
+		}
+	}
+
+	badges, err := p.grantBadges(userID, reached)
+	return promoted, badges, err
+}
+
+// grantBadges assigns the tier badges a user doesn't hold yet. Like
+// achievements, they need an account.
+func (p *Promotions) grantBadges(userID string, reached Tiers) ([]models.Badge, error) {
+	user, err := p.store.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsGuest || (p.RequireVerifiedEmail && user.EmailVerifiedAt == nil) {
+		return nil, nil
+	}
+
+	held, err := p.store.GetUserBadges(userID)
+	if err != nil {
+		return nil, err
+	}
+	heldNames := make(map[string]bool, len(held))
+	for _, b := range held {
+		heldNames[b.Badge.Name] = true
+	}
+
+	var granted []models.Badge
+	for _, tier := range reached {
+		if tier.Badge == nil || heldNames[tier.Badge.Name] {
+			continue
+		}
+		badge, err := ensureBadge(p.store, *tier.Badge)
+		if err != nil {
+			return granted, fmt.Errorf("failed to get badge %s for tier %s: %w", tier.Badge.Name, tier.Name, err)
+		}
+		if err := p.store.AssignBadgeToUser(userID, badge.ID); err != nil {
+			return granted, err
+		}
+		granted = append(granted, *badge)
+	}
+	return granted, nil
+}
@@ -0,0 +1,161 @@
+package gamification
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+var testTiers = Tiers{
+	{Name: TierNovice, MinPoints: 0},
+	{Name: TierApprentice, MinPoints: 100, Lessons: []string{"go-advanced-01"}},
+	{Name: TierCoder, MinPoints: 300},
+}
+
+func TestTiersProgress(t *testing.T) {
+	tests := []struct {
+		points int
+		want   models.TierProgress
+	}{
+		{0, models.TierProgress{Tier: "Novice", Points: 0, TierPoints: 0, NextTier: "Apprentice", NextTierPoints: 100, PointsToNext: 100, Progress: 0}},
+		{99, models.TierProgress{Tier: "Novice", Points: 99, TierPoints: 0, NextTier: "Apprentice", NextTierPoints: 100, PointsToNext: 1, Progress: 0.99}},
+		{100, models.TierProgress{Tier: "Apprentice", Points: 100, TierPoints: 100, NextTier: "Coder", NextTierPoints: 300, PointsToNext: 200, Progress: 0}},
+		{250, models.TierProgress{Tier: "Apprentice", Points: 250, TierPoints: 100, NextTier: "Coder", NextTierPoints: 300, PointsToNext: 50, Progress: 0.75}},
+		// The last tier has nothing left to reach
+		{300, models.TierProgress{Tier: "Coder", Points: 300, TierPoints: 300, Progress: 1}},
+		{5000, models.TierProgress{Tier: "Coder", Points: 5000, TierPoints: 300, Progress: 1}},
+	}
+	for _, tt := range tests {
+		if got := testTiers.Progress(tt.points); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Progress(%d) = %+v, want %+v", tt.points, got, tt.want)
+		}
+	}
+}
+
+func TestGetTierDefaults(t *testing.T) {
+	tests := map[int]RankTier{0: TierNovice, 999: TierNovice, 1000: TierApprentice, 10000: TierCoder, 50000: TierHacker, 100000: TierGuru, 1 << 30: TierGuru}
+	for points, want := range tests {
+		if got := GetTier(points); got != want {
+			t.Errorf("GetTier(%d) = %s, want %s", points, got, want)
+		}
+	}
+	if err := DefaultTiers.validate(); err != nil {
+		t.Errorf("default tiers are invalid: %v", err)
+	}
+}
+
+func TestLoadTiers(t *testing.T) {
+	tests := []struct {
+		name  string
+		json  string
+		valid bool
+	}{
+		{"valid", `{"tiers": [{"name": "Novice", "minPoints": 0}, {"name": "Pro", "minPoints": 10, "badge": {"name": "Pro", "color": "#fff"}, "lessons": ["x"]}]}`, true},
+		{"no tiers", `{"tiers": []}`, false},
+		{"first tier above 0", `{"tiers": [{"name": "Novice", "minPoints": 5}]}`, false},
+		{"points not rising", `{"tiers": [{"name": "Novice", "minPoints": 0}, {"name": "Pro", "minPoints": 0}]}`, false},
+		{"duplicate name", `{"tiers": [{"name": "Novice", "minPoints": 0}, {"name": "Novice", "minPoints": 10}]}`, false},
+		{"missing name", `{"tiers": [{"name": "Novice", "minPoints": 0}, {"minPoints": 10}]}`, false},
+		{"badge without color", `{"tiers": [{"name": "Novice", "minPoints": 0, "badge": {"name": "Novice"}}]}`, false},
+		{"invalid JSON", `{"tiers": `, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tiers.json")
+			if err := os.WriteFile(path, []byte(tt.json), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			tiers, err := LoadTiers(path)
+			if (err == nil) != tt.valid {
+				t.Fatalf("LoadTiers() error = %v, want valid: %v", err, tt.valid)
+			}
+			if tt.valid && (len(tiers) != 2 || tiers[1].Badge == nil || !reflect.DeepEqual(tiers[1].Lessons, []string{"x"})) {
+				t.Errorf("unexpected tiers %+v", tiers)
+			}
+		})
+	}
+
+	if _, err := LoadTiers(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("missing file was accepted")
+	}
+}
+
+// tierStore adds points and tier events to the achievement store
+type tierStore struct {
+	*achievementStore
+	points int
+	events []models.TierEvent
+}
+
+func (s *tierStore) GetUserPoints(userID string, startDate, endDate time.Time) (int, error) {
+	return s.points, nil
+}
+
+func (s *tierStore) RecordTierEvent(event models.TierEvent) (bool, error) {
+	for _, e := range s.events {
+		if e.Tier == event.Tier {
+			return false, nil
+		}
+	}
+	s.events = append(s.events, event)
+	return true, nil
+}
+
+func (s *tierStore) GetTierEvents(userID string) ([]models.TierEvent, error) {
+	return s.events, nil
+}
+
+func tierNames(tiers Tiers) []RankTier {
+	var names []RankTier
+	for _, tier := range tiers {
+		names = append(names, tier.Name)
+	}
+	return names
+}
+
+func TestPromotionsKeepReachedTiers(t *testing.T) {
+	store := &tierStore{achievementStore: newAchievementStore()}
+	p := NewPromotions(store, testTiers)
+	reached := func(want ...RankTier) {
+		t.Helper()
+		got, err := p.Reached("u1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(tierNames(got), want) {
+			t.Errorf("reached %v, want %v", tierNames(got), want)
+		}
+	}
+
+	reached(TierNovice)
+
+	store.points = 150
+	events, _, err := p.Promote("u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Tier != string(TierApprentice) {
+		t.Fatalf("got promotions %+v, want Apprentice", events)
+	}
+	reached(TierNovice, TierApprentice)
+
+	// Points taken back keep the tier, and promoting again records nothing
+	store.points = 20
+	reached(TierNovice, TierApprentice)
+	if events, _, err := p.Promote("u1"); err != nil || len(events) != 0 {
+		t.Errorf("promoting again: got %+v, %v", events, err)
+	}
+
+	// Points reach tiers before they are promoted to
+	store.points = 300
+	reached(TierNovice, TierApprentice, TierCoder)
+
+	// Tiers that left the ladder are ignored
+	store.points = 0
+	store.events = append(store.events, models.TierEvent{Tier: "Retired"})
+	reached(TierNovice, TierApprentice)
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/typing-code-learn/api-go/internal/gamification"
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+// SetAchievements sets the engine that awards badges for achievements
+func (h *Handler) SetAchievements(a *gamification.Achievements) {
+	h.achievements = a
+}
+
+// awardAchievements evaluates the achievement rules for a user and returns
+// the badges they just earned. Failures are logged and never fail a request.
+func (h *Handler) awardAchievements(userID string) []models.Badge {
+	if h.achievements == nil {
+		return []models.Badge{}
+	}
+	awarded, err := h.achievements.Evaluate(userID)
+	if err != nil {
+		fmt.Printf("Error evaluating achievements for user %s: %v\n", userID, err)
+	}
+	if awarded == nil {
+		awarded = []models.Badge{}
+	}
+	return awarded
+}
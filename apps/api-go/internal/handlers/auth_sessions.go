@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/typing-code-learn/api-go/internal/auth"
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+const (
+	refreshCookieName = "refresh_token"
+	// The refresh token is only ever needed by the auth endpoints
+	refreshCookiePath = "/api/v1/auth"
+)
+
+// startAuthSession opens a login session for a user, issues its first access
+// and refresh tokens and sets them as cookies
+func (h *Handler) startAuthSession(w http.ResponseWriter, r *http.Request, user models.User) (*models.AuthResponse, error) {
+	sessionID := uuid.New().String()
+	refreshToken, refreshHash, err := auth.NewRefreshToken(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	token, jti, err := h.authService.GenerateToken(user, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	err = h.db.CreateAuthSession(models.AuthSession{
+		ID:               sessionID,
+		UserID:           user.ID,
+		RefreshTokenHash: refreshHash,
+		AccessTokenID:    jti,
+		UserAgent:        r.UserAgent(),
+		IPAddress:        auth.ClientIP(r),
+		CreatedAt:        now,
+		LastUsedAt:       &now,
+		ExpiresAt:        now.Add(auth.RefreshTokenDuration),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	h.setAuthCookie(w, token, refreshToken)
+	return &models.AuthResponse{User: user, Token: token, RefreshToken: refreshToken}, nil
+}
+
+// revokeAuthSession revokes a login session and denylists its access token
+// for as long as it could still be valid
+func (h *Handler) revokeAuthSession(sessionID string) error {
+	err := h.db.RevokeAuthSession(sessionID, time.Now().UTC().Add(auth.AccessTokenDuration))
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	return err
+}
+
+// endAuthSession revokes the login session a request belongs to, if any
+func (h *Handler) endAuthSession(r *http.Request) {
+	var sessionID string
+	if userCtx, ok := auth.GetUserFromContext(r.Context()); ok {
+		sessionID = userCtx.SessionID
+	}
+	if sessionID == "" {
+		// The access token may already have expired
+		sessionID = h.refreshCookieSession(r)
+	}
+	if sessionID == "" {
+		return
+	}
+	if err := h.revokeAuthSession(sessionID); err != nil {
+		fmt.Printf("Error revoking session %s: %v\n", sessionID, err)
+	}
+}
+
+// refreshCookieSession returns the ID of the login session whose current
+// refresh token is in the request's cookie, if any. The session ID alone is
+// not secret, so the token must match the one the session was last issued.
+func (h *Handler) refreshCookieSession(r *http.Request) string {
+	cookie, err := r.Cookie(refreshCookieName)
+	if err != nil {
+		return ""
+	}
+	sessionID, hash, err := auth.ParseRefreshToken(cookie.Value)
+	if err != nil {
+		return ""
+	}
+	session, err := h.db.GetAuthSession(sessionID)
+	if err != nil || !sameHash(session.RefreshTokenHash, hash) {
+		return ""
+	}
+	return session.ID
+}
+
+// sameHash compares token hashes in constant time
+func sameHash(stored, presented string) bool {
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(presented)) == 1
+}
+
+// RefreshToken rotates the refresh token of a login session and issues a new
+// access token. Presenting a refresh token that was already rotated means it
+// leaked, so the whole session is revoked.
+func (h *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20) // 1 MB limit
+
+	var req models.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.RefreshToken == "" {
+		if cookie, err := r.Cookie(refreshCookieName); err == nil {
+			req.RefreshToken = cookie.Value
+		}
+	}
+	if req.RefreshToken == "" {
+		respondError(w, http.StatusUnauthorized, "Refresh token required")
+		return
+	}
+
+	sessionID, hash, err := auth.ParseRefreshToken(req.RefreshToken)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "Invalid refresh token")
+		return
+	}
+
+	session, err := h.db.GetAuthSession(sessionID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.clearAuthCookie(w)
+			respondError(w, http.StatusUnauthorized, "Invalid refresh token")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to get session")
+		return
+	}
+
+	now := time.Now().UTC()
+	if session.RevokedAt != nil || now.After(session.ExpiresAt) {
+		h.clearAuthCookie(w)
+		respondError(w, http.StatusUnauthorized, "Session expired")
+		return
+	}
+	if !sameHash(session.RefreshTokenHash, hash) {
+		h.rejectReusedToken(w, session.ID)
+		return
+	}
+
+	user, err := h.db.GetUserByID(session.UserID)
+	if err != nil {
+		h.clearAuthCookie(w)
+		respondError(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	refreshToken, refreshHash, err := auth.NewRefreshToken(session.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+	token, jti, err := h.authService.GenerateToken(*user, session.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+
+	if err := h.db.RotateAuthSession(session.ID, hash, refreshHash, jti, now.Add(auth.RefreshTokenDuration)); err != nil {
+		if err == sql.ErrNoRows {
+			// Another request rotated the same token first
+			h.rejectReusedToken(w, session.ID)
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to refresh session")
+		return
+	}
+
+	if err := h.db.TouchAuthSession(session.ID, r.UserAgent(), auth.ClientIP(r), now); err != nil {
+		fmt.Printf("Error recording use of session %s: %v\n", session.ID, err)
+	}
+
+	h.setAuthCookie(w, token, refreshToken)
+	respondJSON(w, http.StatusOK, models.AuthResponse{
+		User:         *user,
+		Token:        token,
+		RefreshToken: refreshToken,
+	})
+}
+
+// rejectReusedToken revokes a session whose refresh token was used twice
+func (h *Handler) rejectReusedToken(w http.ResponseWriter, sessionID string) {
+	fmt.Printf("Refresh token reuse detected, revoking session %s\n", sessionID)
+	if err := h.revokeAuthSession(sessionID); err != nil {
+		fmt.Printf("Error revoking session %s: %v\n", sessionID, err)
+	}
+	h.clearAuthCookie(w)
+	respondError(w, http.StatusUnauthorized, "Invalid refresh token")
+}
+
+// ListSessions returns the login sessions of the current user
+func (h *Handler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userCtx, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+	h.respondSessions(w, userCtx.UserID, userCtx.SessionID)
+}
+
+// RevokeSession logs the current user out of one of their sessions
+func (h *Handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userCtx, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	sessionID := chi.URLParam(r, "id")
+	if !h.revokeUserSession(w, userCtx.UserID, sessionID) {
+		return
+	}
+	if sessionID == userCtx.SessionID {
+		h.clearAuthCookie(w)
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Session revoked"})
+}
+
+// RevokeOtherSessions logs the current user out everywhere except the
+// session making the request
+func (h *Handler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	userCtx, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+	h.revokeAllSessions(w, userCtx.UserID, userCtx.SessionID)
+}
+
+// ListUserSessions returns the login sessions of any user (admin only)
+func (h *Handler) ListUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userId")
+	if _, err := h.db.GetUserByID(userID); err != nil {
+		respondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	h.respondSessions(w, userID, "")
+}
+
+// RevokeUserSession revokes one login session of any user (admin only)
+func (h *Handler) RevokeUserSession(w http.ResponseWriter, r *http.Request) {
+	if !h.revokeUserSession(w, chi.URLParam(r, "userId"), chi.URLParam(r, "id")) {
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Session revoked"})
+}
+
+// RevokeUserSessions logs a user out of every session and revokes their
+// personal access tokens, e.g. when their account is compromised (admin only)
+func (h *Handler) RevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userId")
+	if _, err := h.db.GetUserByID(userID); err != nil {
+		respondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	revoked, err := h.revokeAccess(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to revoke sessions")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]int{"revoked": revoked})
+}
+
+// respondSessions writes a user's live sessions, marking currentID
+func (h *Handler) respondSessions(w http.ResponseWriter, userID, currentID string) {
+	sessions, err := h.db.ListAuthSessions(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get sessions")
+		return
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+	respondJSON(w, http.StatusOK, sessions)
+}
+
+// revokeUserSession revokes a live session if it belongs to userID, writing
+// an error response and returning false otherwise
+func (h *Handler) revokeUserSession(w http.ResponseWriter, userID, sessionID string) bool {
+	session, err := h.db.GetAuthSession(sessionID)
+	if err != nil && err != sql.ErrNoRows {
+		respondError(w, http.StatusInternalServerError, "Failed to get session")
+		return false
+	}
+	if err == sql.ErrNoRows || session.UserID != userID || session.RevokedAt != nil {
+		respondError(w, http.StatusNotFound, "Session not found")
+		return false
+	}
+	if err := h.revokeAuthSession(session.ID); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to revoke session")
+		return false
+	}
+	return true
+}
+
+// revokeAllSessions revokes every live session of a user except keepID and
+// reports how many were revoked
+func (h *Handler) revokeAllSessions(w http.ResponseWriter, userID, keepID string) {
+	revoked, err := h.revokeSessions(userID, keepID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to revoke sessions")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]int{"revoked": revoked})
+}
+
+// revokeAccess revokes every live session and personal access token of a
+// user, returning how many sessions were revoked. Account recovery uses it so
+// that nothing issued before keeps working.
+func (h *Handler) revokeAccess(userID string) (int, error) {
+	revoked, err := h.revokeSessions(userID, "")
+	if err != nil {
+		return revoked, err
+	}
+	return revoked, h.db.DeletePersonalAccessTokens(userID)
+}
+
+// revokeSessions revokes every live session of a user except keepID
+func (h *Handler) revokeSessions(userID, keepID string) (int, error) {
+	sessions, err := h.db.ListAuthSessions(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	revoked := 0
+	for _, session := range sessions {
+		if session.ID == keepID {
+			continue
+		}
+		if err := h.revokeAuthSession(session.ID); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/typing-code-learn/api-go/internal/auth"
+	"github.com/typing-code-learn/api-go/internal/mail"
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+// emailVerificationDuration is how long a verification link stays valid. An
+// unverified address is released to other registrations once it expires.
+const emailVerificationDuration = 24 * time.Hour
+
+// VerifiedEmailPolicy selects what requires a verified email address
+type VerifiedEmailPolicy struct {
+	Leaderboard bool // appearing in leaderboards and ranks
+	Badges      bool // receiving automatic badges
+}
+
+// ParseVerifiedEmailPolicy parses a comma-separated list of "leaderboard" and
+// "badges". An empty string or "none" requires verification for nothing.
+func ParseVerifiedEmailPolicy(s string) (VerifiedEmailPolicy, error) {
+	var p VerifiedEmailPolicy
+	for _, item := range strings.Split(s, ",") {
+		switch strings.TrimSpace(item) {
+		case "", "none":
+		case "leaderboard":
+			p.Leaderboard = true
+		case "badges":
+			p.Badges = true
+		default:
+			return p, fmt.Errorf("unknown verified email requirement %q", item)
+		}
+	}
+	return p, nil
+}
+
+// SetVerifiedEmailPolicy replaces what requires a verified email address
+func (h *Handler) SetVerifiedEmailPolicy(p VerifiedEmailPolicy) {
+	h.verifiedEmail = p
+}
+
+// emailAvailable reports whether an email can be registered, first releasing
+// it from an account that never verified it in time
+func (h *Handler) emailAvailable(email string) (bool, error) {
+	existing, err := h.db.GetUserByEmail(email)
+	if err == sql.ErrNoRows {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if existing.EmailVerifiedAt != nil {
+		return false, nil
+	}
+	return h.db.ReleaseUnverifiedEmail(email, time.Now().UTC())
+}
+
+// sendVerificationEmail emails a link that verifies the user's address
+func (h *Handler) sendVerificationEmail(user models.User) error {
+	if user.Email == nil || *user.Email == "" {
+		return nil
+	}
+
+	token, hash, err := auth.NewOneTimeToken()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	err = h.db.CreateUserToken(models.UserToken{
+		Hash:      hash,
+		UserID:    user.ID,
+		Purpose:   models.TokenPurposeEmailVerification,
+		CreatedAt: now,
+		ExpiresAt: now.Add(emailVerificationDuration),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := appURL() + "/verify-email?token=" + url.QueryEscape(token)
+	h.sendMail(mail.Message{
+		To:      *user.Email,
+		Subject: "Verify your Typer email",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Open this link within %d hours to confirm this is your email address:\n\n%s\n\n"+
+			"If you didn't create a Typer account, ignore this email.\n",
+			user.Username, int(emailVerificationDuration.Hours()), link),
+	})
+	return nil
+}
+
+// awardAutomaticBadges assigns the registration and achievement badges a
+// user is eligible for
+func (h *Handler) awardAutomaticBadges(user models.User) {
+	if h.verifiedEmail.Badges && user.EmailVerifiedAt == nil {
+		return
+	}
+	if err := h.db.AssignAutomaticBadges(user.ID); err != nil {
+		fmt.Printf("Error assigning automatic badges to user %s: %v\n", user.ID, err)
+	}
+	// Guests who register keep their progress and points, which may already
+	// qualify
+	h.promote(user.ID)
+	h.awardAchievements(user.ID)
+}
+
+// VerifyEmail marks the email a verification token was sent to as verified
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20) // 1 MB limit
+
+	var req models.VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Token == "" {
+		respondError(w, http.StatusBadRequest, "Verification token is required")
+		return
+	}
+
+	now := time.Now().UTC()
+	token, err := h.db.ConsumeUserToken(auth.HashToken(req.Token), models.TokenPurposeEmailVerification, now)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondError(w, http.StatusBadRequest, "Invalid or expired verification token")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to check verification token")
+		return
+	}
+
+	if err := h.db.MarkEmailVerified(token.UserID, now); err != nil {
+		if err == sql.ErrNoRows {
+			respondError(w, http.StatusBadRequest, "Invalid or expired verification token")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to verify email")
+		return
+	}
+
+	user, err := h.db.GetUserByID(token.UserID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get user")
+		return
+	}
+	h.awardAutomaticBadges(*user)
+	if badges, err := h.db.GetUserBadges(user.ID); err == nil {
+		user.Badges = badges
+	}
+
+	respondJSON(w, http.StatusOK, user)
+}
+
+// ResendVerificationEmail sends the current user a new verification link
+func (h *Handler) ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	userCtx, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	user, err := h.db.GetUserByID(userCtx.UserID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get user")
+		return
+	}
+	if user.Email == nil || *user.Email == "" {
+		respondError(w, http.StatusBadRequest, "Account has no email address")
+		return
+	}
+	if user.EmailVerifiedAt != nil {
+		respondError(w, http.StatusConflict, "Email already verified")
+		return
+	}
+
+	if err := h.sendVerificationEmail(*user); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create verification token")
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, map[string]string{"message": "Verification email sent"})
+}
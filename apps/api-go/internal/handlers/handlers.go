@@ -12,26 +12,46 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/typing-code-learn/api-go/internal/anticheat"
 	"github.com/typing-code-learn/api-go/internal/auth"
 	"github.com/typing-code-learn/api-go/internal/database"
+	"github.com/typing-code-learn/api-go/internal/engine"
 	"github.com/typing-code-learn/api-go/internal/gamification"
 	"github.com/typing-code-learn/api-go/internal/lessons"
+	"github.com/typing-code-learn/api-go/internal/mail"
 	"github.com/typing-code-learn/api-go/internal/models"
 )
 
 // Handler holds dependencies for HTTP handlers
 type Handler struct {
-	db          *database.DB
+	db          database.Store
 	lessonStore *lessons.Store
 	authService *auth.Service
+	guard       *anticheat.Guard
+	loginLimits LoginLimits
+	mailer      mail.Mailer
+
+	verifiedEmail   VerifiedEmailPolicy
+	achievements    *gamification.Achievements
+	pointStrategies *gamification.Registry
+	tiers           *gamification.Promotions
+
+	oauthProviders    map[string]*auth.OAuthProvider
+	oauthRedirectBase string
 }
 
 // New creates a new Handler
-func New(db *database.DB, lessonStore *lessons.Store, authService *auth.Service) *Handler {
+func New(db database.Store, lessonStore *lessons.Store, authService *auth.Service) *Handler {
 	return &Handler{
 		db:          db,
 		lessonStore: lessonStore,
 		authService: authService,
+		guard:       anticheat.NewGuard(),
+		loginLimits: NewLoginLimits(),
+		mailer:      mail.LogMailer{},
+
+		pointStrategies: gamification.DefaultRegistry(),
+		tiers:           gamification.NewPromotions(db, gamification.DefaultTiers),
 	}
 }
 
@@ -47,44 +67,55 @@ func respondError(w http.ResponseWriter, status int, message string) {
 	respondJSON(w, status, map[string]string{"error": message})
 }
 
-func (h *Handler) setAuthCookie(w http.ResponseWriter, token string) {
+// authCookie builds an auth cookie honouring COOKIE_SECURE
+func authCookie(name, value, path string, maxAge int) *http.Cookie {
 	secure := os.Getenv("COOKIE_SECURE") != "false" // default true
 	sameSite := http.SameSiteLaxMode
 	if secure {
 		sameSite = http.SameSiteNoneMode
 	}
-	http.SetCookie(w, &http.Cookie{
-		Name:     "token",
-		Value:    token,
-		Path:     "/",
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
 		HttpOnly: true,
 		Secure:   secure,
 		SameSite: sameSite,
-		MaxAge:   int(auth.TokenDuration.Seconds()),
-	})
+		MaxAge:   maxAge,
+	}
+	if maxAge < 0 {
+		cookie.Expires = time.Unix(0, 0)
+	}
+	return cookie
+}
+
+func (h *Handler) setAuthCookie(w http.ResponseWriter, token, refreshToken string) {
+	http.SetCookie(w, authCookie("token", token, "/", int(auth.AccessTokenDuration.Seconds())))
+	http.SetCookie(w, authCookie(refreshCookieName, refreshToken, refreshCookiePath, int(auth.RefreshTokenDuration.Seconds())))
 }
 
 func (h *Handler) clearAuthCookie(w http.ResponseWriter) {
-	secure := os.Getenv("COOKIE_SECURE") != "false" // default true
-	sameSite := http.SameSiteLaxMode
-	if secure {
-		sameSite = http.SameSiteNoneMode
+	http.SetCookie(w, authCookie("token", "", "/", -1))
+	http.SetCookie(w, authCookie(refreshCookieName, "", refreshCookiePath, -1))
+}
+
+// requestUserID returns the ID of the user a request is authenticated as, or
+// "" for anonymous requests
+func requestUserID(r *http.Request) string {
+	if userCtx, ok := auth.GetUserFromContext(r.Context()); ok {
+		return userCtx.UserID
 	}
-	http.SetCookie(w, &http.Cookie{
-		Name:     "token",
-		Value:    "",
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   secure,
-		SameSite: sameSite,
-		MaxAge:   -1,
-		Expires:  time.Unix(0, 0),
-	})
+	return ""
 }
 
+// ListLessons lists the lessons the user has unlocked
 func (h *Handler) ListLessons(w http.ResponseWriter, r *http.Request) {
 	lang := r.URL.Query().Get("lang")
-	allLessons := h.lessonStore.All()
+	allLessons, err := h.unlockedLessons(requestUserID(r), h.lessonStore.All())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get tier")
+		return
+	}
 	summaries := make([]models.LessonSummary, len(allLessons))
 	for i, l := range allLessons {
 		s := l.ToSummary()
@@ -94,6 +125,7 @@ func (h *Handler) ListLessons(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, summaries)
 }
 
+// GetLesson returns a lesson the user has unlocked
 func (h *Handler) GetLesson(w http.ResponseWriter, r *http.Request) {
 	lang := r.URL.Query().Get("lang")
 	id := chi.URLParam(r, "id")
@@ -102,18 +134,23 @@ func (h *Handler) GetLesson(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusNotFound, "Lesson not found")
 		return
 	}
+	if !h.allowLesson(w, requestUserID(r), lesson.ID) {
+		return
+	}
 
 	l := *lesson
 	h.localizeLesson(&l, lang)
 	respondJSON(w, http.StatusOK, l)
 }
 
+// GetLessonsByLanguage lists the lessons of a language the user has unlocked
 func (h *Handler) GetLessonsByLanguage(w http.ResponseWriter, r *http.Request) {
 	lang := r.URL.Query().Get("lang")
 	language := chi.URLParam(r, "language")
-	lessonList := h.lessonStore.GetByLanguage(language)
-	if lessonList == nil {
-		lessonList = make([]*models.Lesson, 0)
+	lessonList, err := h.unlockedLessons(requestUserID(r), h.lessonStore.GetByLanguage(language))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get tier")
+		return
 	}
 
 	summaries := make([]models.LessonSummary, len(lessonList))
@@ -125,6 +162,15 @@ func (h *Handler) GetLessonsByLanguage(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, summaries)
 }
 
+// GetContentStatus reports the state of the last lesson content (re)load
+func (h *Handler) GetContentStatus(w http.ResponseWriter, r *http.Request) {
+	status := h.lessonStore.Status()
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"healthy": status.LastError == "",
+		"status":  status,
+	})
+}
+
 func (h *Handler) localizeSummary(s *models.LessonSummary, lang string) {
 	if lang == "en" && s.TitleEn != "" {
 		s.Title = s.TitleEn
@@ -162,6 +208,9 @@ func (h *Handler) SaveProgress(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "userId and lessonId are required")
 		return
 	}
+	if !h.allowLesson(w, req.UserID, req.LessonID) {
+		return
+	}
 
 	progress, err := h.db.SaveProgress(req)
 	if err != nil {
@@ -227,6 +276,8 @@ func (h *Handler) GetLessonProgress(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, progress)
 }
 
+// SaveMetrics records client-reported metrics. The numbers cannot be
+// verified, so no points are awarded; use typing sessions for that.
 func (h *Handler) SaveMetrics(w http.ResponseWriter, r *http.Request) {
 	userCtx, ok := auth.GetUserFromContext(r.Context())
 	if !ok {
@@ -249,6 +300,14 @@ func (h *Handler) SaveMetrics(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "userId and lessonId are required")
 		return
 	}
+	if !h.allowLesson(w, req.UserID, req.LessonID) {
+		return
+	}
+
+	if !h.screenMetrics(&req) {
+		respondError(w, http.StatusUnprocessableEntity, "Metrics are not plausible")
+		return
+	}
 
 	metrics, err := h.db.SaveMetrics(req)
 	if err != nil {
@@ -256,21 +315,6 @@ func (h *Handler) SaveMetrics(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pointStrategy := gamification.NewDefaultStrategy()
-	points := pointStrategy.Calculate(*metrics)
-
-	if points > 0 {
-		pt := models.PointTransaction{
-			ID:        uuid.New().String(),
-			UserID:    metrics.UserID,
-			SourceID:  metrics.LessonID,
-			Points:    points,
-			Reason:    "lesson_complete",
-			CreatedAt: metrics.CreatedAt,
-		}
-		_ = h.db.SavePointTransaction(pt)
-	}
-
 	streak, err := h.db.UpdateUserStreak(metrics.UserID)
 	if err != nil {
 		fmt.Printf("Error updating streak for user %s: %v\n", metrics.UserID, err)
@@ -278,11 +322,77 @@ func (h *Handler) SaveMetrics(w http.ResponseWriter, r *http.Request) {
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"metrics":       metrics,
-		"pointsEarned":  points,
+		"pointsEarned":  0,
 		"currentStreak": streak,
 	})
 }
 
+// screenMetrics runs the anti-cheat guard over a submission and marks it as
+// flagged when it looks suspicious. It returns false if the submission is
+// impossible and must be rejected.
+func (h *Handler) screenMetrics(req *models.MetricsRequest) bool {
+	lessonChars := 0
+	if lesson, ok := h.lessonStore.Get(req.LessonID); ok {
+		lessonChars = len(engine.Tokenize(lesson))
+	}
+
+	recent, err := h.db.GetRecentMetrics(req.UserID, time.Now().Add(-h.guard.BurstWindow))
+	if err != nil {
+		fmt.Printf("Error getting recent metrics for user %s: %v\n", req.UserID, err)
+	}
+
+	verdict := h.guard.Check(*req, lessonChars, recent)
+	if verdict.Reject {
+		return false
+	}
+	req.FlagReason = verdict.String()
+	return true
+}
+
+// SetPointStrategies replaces the strategies points are calculated with
+func (h *Handler) SetPointStrategies(r *gamification.Registry) {
+	h.pointStrategies = r
+}
+
+// awardPoints converts server-computed metrics into points using the strategy
+// for the lesson and records them. Points of flagged metrics are kept but do
+// not count until approved.
+func (h *Handler) awardPoints(metrics models.TypingMetrics) int {
+	run := gamification.Run{Metrics: metrics}
+	if lesson, ok := h.lessonStore.Get(metrics.LessonID); ok {
+		run.Lesson = lesson
+	}
+
+	ledger, err := h.db.GetUserPointTransactions(metrics.UserID)
+	if err != nil {
+		fmt.Printf("Error getting points of user %s: %v\n", metrics.UserID, err)
+		return 0
+	}
+	run.Previous = gamification.PreviousRuns(ledger, metrics.LessonID, metrics.ID)
+
+	name, strategy := h.pointStrategies.For(run.Lesson)
+	total := 0
+	for _, award := range strategy.Calculate(run) {
+		pt := models.PointTransaction{
+			ID:              uuid.New().String(),
+			UserID:          metrics.UserID,
+			SourceID:        metrics.LessonID,
+			MetricsID:       metrics.ID,
+			Points:          award.Points,
+			Reason:          award.Reason,
+			Strategy:        name,
+			StrategyVersion: h.pointStrategies.Version(),
+			CreatedAt:       metrics.CreatedAt,
+		}
+		if err := h.db.SavePointTransaction(pt); err != nil {
+			fmt.Printf("Error saving points for metrics %s: %v\n", metrics.ID, err)
+			continue
+		}
+		total += award.Points
+	}
+	return total
+}
+
 // GetLeaderboard returns the leaderboard
 func (h *Handler) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
 	period := r.URL.Query().Get("period")
@@ -314,7 +424,7 @@ func (h *Handler) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
 		startDate = time.Time{} // Zero time
 	}
 
-	leaderboard, err := h.db.GetLeaderboard(startDate, endDate, limit)
+	leaderboard, err := h.db.GetLeaderboard(startDate, endDate, limit, h.verifiedEmail.Leaderboard)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to get leaderboard")
 		return
@@ -335,12 +445,28 @@ func (h *Handler) GetUserRank(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Users left out of leaderboards have no rank
+	if h.verifiedEmail.Leaderboard {
+		user, err := h.db.GetUserByID(userCtx.UserID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to get user")
+			return
+		}
+		if user.EmailVerifiedAt == nil {
+			respondJSON(w, http.StatusOK, map[string]interface{}{
+				"dailyRank":  0,
+				"weeklyRank": 0,
+			})
+			return
+		}
+	}
+
 	now := time.Now().UTC()
 
 	// Daily rank
 	dailyStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
 	dailyEnd := now.Add(1 * time.Minute)
-	dailyRank, err := h.db.GetUserRank(userCtx.UserID, dailyStart, dailyEnd)
+	dailyRank, err := h.db.GetUserRank(userCtx.UserID, dailyStart, dailyEnd, h.verifiedEmail.Leaderboard)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to get daily rank")
 		return
@@ -354,7 +480,7 @@ func (h *Handler) GetUserRank(w http.ResponseWriter, r *http.Request) {
 	weeklyStart := now.AddDate(0, 0, -offset+1)
 	weeklyStart = time.Date(weeklyStart.Year(), weeklyStart.Month(), weeklyStart.Day(), 0, 0, 0, 0, time.UTC)
 	weeklyEnd := now.Add(1 * time.Minute)
-	weeklyRank, err := h.db.GetUserRank(userCtx.UserID, weeklyStart, weeklyEnd)
+	weeklyRank, err := h.db.GetUserRank(userCtx.UserID, weeklyStart, weeklyEnd, h.verifiedEmail.Leaderboard)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to get weekly rank")
 		return
@@ -366,6 +492,57 @@ func (h *Handler) GetUserRank(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// GetPointsLedger lists every point transaction of a user with the run that
+// earned it, so users can see where their points came from. Admins may read
+// any user's ledger.
+func (h *Handler) GetPointsLedger(w http.ResponseWriter, r *http.Request) {
+	userCtx, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+	userID := chi.URLParam(r, "userId")
+	if userID != userCtx.UserID && userCtx.Role != models.RoleAdmin {
+		respondError(w, http.StatusForbidden, "Cannot read points for another user")
+		return
+	}
+
+	transactions, err := h.db.GetUserPointTransactions(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get points")
+		return
+	}
+	history, err := h.db.GetMetricsHistory(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get metrics")
+		return
+	}
+	runs := make(map[string]models.TypingMetrics, len(history))
+	for _, m := range history {
+		runs[m.ID] = m
+	}
+
+	ledger := models.PointsLedger{UserID: userID, Entries: []models.PointsLedgerEntry{}}
+	for _, pt := range transactions {
+		entry := models.PointsLedgerEntry{PointTransaction: pt, Counted: true}
+		if lesson, ok := h.lessonStore.Get(pt.SourceID); ok {
+			entry.LessonTitle = lesson.Title
+		}
+		if m, ok := runs[pt.MetricsID]; ok {
+			entry.WPM = m.WPM
+			entry.Accuracy = m.Accuracy
+			entry.ReviewStatus = m.ReviewStatus
+			entry.Counted = m.ReviewStatus != models.ReviewStatusFlagged && m.ReviewStatus != models.ReviewStatusRejected
+		}
+		if entry.Counted {
+			ledger.TotalPoints += pt.Points
+		}
+		ledger.Entries = append(ledger.Entries, entry)
+	}
+
+	respondJSON(w, http.StatusOK, ledger)
+}
+
 // GetUserMetrics returns aggregated metrics for a user
 func (h *Handler) GetUserMetrics(w http.ResponseWriter, r *http.Request) {
 	userCtx, ok := auth.GetUserFromContext(r.Context())
@@ -386,6 +563,75 @@ func (h *Handler) GetUserMetrics(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, summary)
 }
 
+// GetFlaggedMetrics lists metrics awaiting or past anti-cheat review (admin only)
+func (h *Handler) GetFlaggedMetrics(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = models.ReviewStatusFlagged
+	}
+	switch status {
+	case models.ReviewStatusFlagged, models.ReviewStatusApproved, models.ReviewStatusRejected:
+	default:
+		respondError(w, http.StatusBadRequest, "status must be flagged, approved or rejected")
+		return
+	}
+
+	limit := 100
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		fmt.Sscanf(limitStr, "%d", &limit)
+	}
+
+	metrics, err := h.db.GetMetricsByReviewStatus(status, limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get flagged metrics")
+		return
+	}
+	if metrics == nil {
+		metrics = []models.TypingMetrics{}
+	}
+
+	respondJSON(w, http.StatusOK, metrics)
+}
+
+// ReviewMetrics approves or rejects flagged metrics (admin only). Approved
+// metrics count towards rankings again; rejected ones stay excluded.
+func (h *Handler) ReviewMetrics(w http.ResponseWriter, r *http.Request) {
+	userCtx, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+	metricsID := chi.URLParam(r, "id")
+
+	var req models.MetricsReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Status != models.ReviewStatusApproved && req.Status != models.ReviewStatusRejected {
+		respondError(w, http.StatusBadRequest, "status must be approved or rejected")
+		return
+	}
+
+	metrics, err := h.db.ReviewMetrics(metricsID, req.Status, userCtx.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondError(w, http.StatusNotFound, "Metrics not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to review metrics")
+		return
+	}
+	// Approved runs count again, so they may complete an achievement or
+	// reach a tier
+	if metrics.ReviewStatus == models.ReviewStatusApproved {
+		h.promote(metrics.UserID)
+		h.awardAchievements(metrics.UserID)
+	}
+
+	respondJSON(w, http.StatusOK, metrics)
+}
+
 func (h *Handler) GetLanguages(w http.ResponseWriter, r *http.Request) {
 	langs := h.lessonStore.GetLanguages()
 	if langs == nil {
@@ -401,18 +647,13 @@ func (h *Handler) CreateGuestUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.authService.GenerateToken(*user)
+	resp, err := h.startAuthSession(w, r, *user)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to generate token")
 		return
 	}
 
-	h.setAuthCookie(w, token)
-
-	respondJSON(w, http.StatusOK, models.AuthResponse{
-		User:  *user,
-		Token: token,
-	})
+	respondJSON(w, http.StatusOK, resp)
 }
 
 // Register handles user registration (new user or guest conversion)
@@ -449,9 +690,15 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// An address someone registered but never verified does not block its
+	// owner for longer than the verification link lasts
 	if req.Email != "" {
-		existingUser, err = h.db.GetUserByEmail(req.Email)
-		if err == nil && existingUser != nil {
+		available, err := h.emailAvailable(req.Email)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to check email")
+			return
+		}
+		if !available {
 			respondError(w, http.StatusConflict, "Email already exists")
 			return
 		}
@@ -481,18 +728,21 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	token, err := h.authService.GenerateToken(*user)
+	h.awardAutomaticBadges(*user)
+	if err := h.sendVerificationEmail(*user); err != nil {
+		fmt.Printf("Error sending verification email to user %s: %v\n", user.ID, err)
+	}
+
+	// Replace the session the request came from, usually a guest one
+	h.endAuthSession(r)
+
+	resp, err := h.startAuthSession(w, r, *user)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to generate token")
 		return
 	}
 
-	h.setAuthCookie(w, token)
-
-	respondJSON(w, http.StatusCreated, models.AuthResponse{
-		User:  *user,
-		Token: token,
-	})
+	respondJSON(w, http.StatusCreated, resp)
 }
 
 // Login authenticates a user
@@ -506,36 +756,67 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	req.Username = strings.TrimSpace(req.Username)
+	ip := auth.ClientIP(r)
+	now := time.Now().UTC()
+
+	// Refuse throttled attempts before spending a bcrypt comparison on them
+	if wait := h.loginWait(ip, req.Username, now); wait > 0 {
+		respondTooManyAttempts(w, wait)
+		return
+	}
 
 	user, err := h.db.GetUserByUsername(req.Username)
 	if err != nil {
+		h.loginFailed(ip, req.Username, nil, now)
 		respondError(w, http.StatusUnauthorized, "Invalid credentials")
 		return
 	}
 
+	lockedUntil, err := h.db.GetLockedUntil(user.ID)
+	if err != nil {
+		fmt.Printf("Error getting lockout for user %s: %v\n", user.ID, err)
+	} else if lockedUntil != nil && now.Before(*lockedUntil) {
+		respondTooManyAttempts(w, lockedUntil.Sub(now))
+		return
+	}
+
 	passwordHash, err := h.db.GetPasswordHash(req.Username)
 	if err != nil {
+		h.loginFailed(ip, req.Username, user, now)
 		respondError(w, http.StatusUnauthorized, "Invalid credentials")
 		return
 	}
 
 	if err := h.authService.CheckPassword(req.Password, passwordHash); err != nil {
+		h.loginFailed(ip, req.Username, user, now)
 		respondError(w, http.StatusUnauthorized, "Invalid credentials")
 		return
 	}
 
-	token, err := h.authService.GenerateToken(*user)
+	// With a second factor, the password only earns a challenge that
+	// VerifyMFA exchanges for a session
+	if user.MFAEnabled {
+		mfaToken, err := h.authService.GenerateMFAToken(user.ID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to generate token")
+			return
+		}
+		respondJSON(w, http.StatusOK, models.MFAChallengeResponse{MFARequired: true, MFAToken: mfaToken})
+		return
+	}
+
+	h.loginSucceeded(req.Username, user.ID)
+
+	// Replace the session the request came from, usually a guest one
+	h.endAuthSession(r)
+
+	resp, err := h.startAuthSession(w, r, *user)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to generate token")
 		return
 	}
 
-	h.setAuthCookie(w, token)
-
-	respondJSON(w, http.StatusOK, models.AuthResponse{
-		User:  *user,
-		Token: token,
-	})
+	respondJSON(w, http.StatusOK, resp)
 }
 
 // GetMe returns the current authenticated user
@@ -551,6 +832,7 @@ func (h *Handler) GetMe(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusNotFound, "User not found")
 		return
 	}
+	user.Tier = h.tierProgress(user.ID)
 
 	respondJSON(w, http.StatusOK, user)
 }
@@ -593,21 +875,34 @@ func (h *Handler) GetUserProfile(w http.ResponseWriter, r *http.Request) {
 		fmt.Printf("Error getting user points: %v\n", err)
 	}
 
+	// Promotions (tier-up events)
+	tierEvents, err := h.tiers.Events(userID)
+	if err != nil {
+		fmt.Printf("Error getting tier events: %v\n", err)
+	}
+	if tierEvents == nil {
+		tierEvents = []models.TierEvent{}
+	}
+
 	publicUser := *user
 	publicUser.Email = nil
+	tier := h.tiers.Tiers().Progress(totalPoints)
+	publicUser.Tier = &tier
 	profile := models.UserProfile{
 		User:             publicUser,
 		Metrics:          metrics,
 		Progress:         progress,
 		CompletedLessons: completedLessons,
 		TotalPoints:      totalPoints,
+		TierEvents:       tierEvents,
 	}
 
 	respondJSON(w, http.StatusOK, profile)
 }
 
-// Logout clears the authentication cookie
+// Logout revokes the current login session and clears the auth cookies
 func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	h.endAuthSession(r)
 	h.clearAuthCookie(w)
 
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Logged out successfully"})
@@ -621,6 +916,12 @@ func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// JWKS publishes the public keys tokens can be verified with
+func (h *Handler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respondJSON(w, http.StatusOK, h.authService.JWKS())
+}
+
 // validateUsername checks that a username is safe and well-formed
 var validUsernameRe = regexp.MustCompile(`^[a-zA-Z0-9_-]{3,30}$`)
 
@@ -631,26 +932,90 @@ func validateUsername(username string) error {
 	return nil
 }
 
-func (h *Handler) CreateBadge(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		Name  string `json:"name"`
-		Color string `json:"color"`
+// Limits on badge fields
+const (
+	maxBadgeName = 50
+	maxBadgeText = 500
+	maxBadgeTag  = 50 // color, icon and category
+)
+
+// badgeFromRequest validates a create or update request and returns the
+// badge it describes, or a message saying what is wrong
+func badgeFromRequest(req models.BadgeRequest) (models.Badge, string) {
+	badge := models.Badge{
+		Name:          strings.TrimSpace(req.Name),
+		Color:         strings.TrimSpace(req.Color),
+		Description:   strings.TrimSpace(req.Description),
+		DescriptionEn: strings.TrimSpace(req.DescriptionEn),
+		Icon:          strings.TrimSpace(req.Icon),
+		Rarity:        strings.TrimSpace(req.Rarity),
+		Category:      strings.TrimSpace(req.Category),
+		Hidden:        req.Hidden,
+		Criteria:      strings.TrimSpace(req.Criteria),
+		CriteriaEn:    strings.TrimSpace(req.CriteriaEn),
+	}
+	if badge.Rarity == "" {
+		badge.Rarity = models.RarityCommon
+	}
+
+	switch {
+	case badge.Name == "" || len(badge.Name) > maxBadgeName:
+		return badge, fmt.Sprintf("name must be 1-%d characters", maxBadgeName)
+	case badge.Color == "" || len(badge.Color) > maxBadgeTag:
+		return badge, fmt.Sprintf("color must be 1-%d characters", maxBadgeTag)
+	case len(badge.Icon) > maxBadgeTag || len(badge.Category) > maxBadgeTag:
+		return badge, fmt.Sprintf("icon and category must be at most %d characters", maxBadgeTag)
+	case len(badge.Description) > maxBadgeText || len(badge.DescriptionEn) > maxBadgeText ||
+		len(badge.Criteria) > maxBadgeText || len(badge.CriteriaEn) > maxBadgeText:
+		return badge, fmt.Sprintf("descriptions and criteria must be at most %d characters", maxBadgeText)
+	}
+	for _, rarity := range models.BadgeRarities {
+		if badge.Rarity == rarity {
+			return badge, ""
+		}
 	}
+	return badge, "rarity must be one of " + strings.Join(models.BadgeRarities, ", ")
+}
+
+// isAdmin reports whether the request comes from a logged-in admin
+func isAdmin(r *http.Request) bool {
+	userCtx, ok := auth.GetUserFromContext(r.Context())
+	return ok && userCtx.Role == models.RoleAdmin
+}
+
+// CreateBadge creates a badge (admin only)
+func (h *Handler) CreateBadge(w http.ResponseWriter, r *http.Request) {
+	var req models.BadgeRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
+	badge, problem := badgeFromRequest(req)
+	if problem != "" {
+		respondError(w, http.StatusBadRequest, problem)
+		return
+	}
 
-	badge, err := h.db.CreateBadge(req.Name, req.Color)
+	if _, err := h.db.GetBadgeByName(badge.Name); err == nil {
+		respondError(w, http.StatusConflict, "A badge with this name already exists")
+		return
+	} else if err != sql.ErrNoRows {
+		respondError(w, http.StatusInternalServerError, "Failed to create badge")
+		return
+	}
+
+	created, err := h.db.CreateBadge(badge)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to create badge")
 		return
 	}
 
-	respondJSON(w, http.StatusCreated, badge)
+	h.auditBadge(r, models.BadgeActionCreate, created.ID, "")
+
+	respondJSON(w, http.StatusCreated, created)
 }
 
-// GetAllBadges returns all badges
+// GetAllBadges returns all badges; hidden ones are only listed for admins
 func (h *Handler) GetAllBadges(w http.ResponseWriter, r *http.Request) {
 	badges, err := h.db.GetAllBadges()
 	if err != nil {
@@ -658,7 +1023,115 @@ func (h *Handler) GetAllBadges(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondJSON(w, http.StatusOK, badges)
+	visible := make([]models.Badge, 0, len(badges))
+	admin := isAdmin(r)
+	for _, b := range badges {
+		if !b.Hidden || admin {
+			visible = append(visible, b)
+		}
+	}
+
+	respondJSON(w, http.StatusOK, visible)
+}
+
+// GetBadge returns a badge and how many users hold it. Hidden badges are
+// only shown to admins and their holders.
+func (h *Handler) GetBadge(w http.ResponseWriter, r *http.Request) {
+	badge, err := h.db.GetBadgeByID(chi.URLParam(r, "id"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondError(w, http.StatusNotFound, "Badge not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to get badge")
+		return
+	}
+
+	holders, err := h.db.GetUsersWithBadge(badge.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get badge holders")
+		return
+	}
+
+	if badge.Hidden && !isAdmin(r) {
+		held := false
+		if userCtx, ok := auth.GetUserFromContext(r.Context()); ok {
+			for _, userID := range holders {
+				if userID == userCtx.UserID {
+					held = true
+					break
+				}
+			}
+		}
+		if !held {
+			respondError(w, http.StatusNotFound, "Badge not found")
+			return
+		}
+	}
+
+	respondJSON(w, http.StatusOK, models.BadgeDetails{Badge: *badge, Holders: len(holders)})
+}
+
+// UpdateBadge replaces a badge's name, color and metadata (admin only)
+func (h *Handler) UpdateBadge(w http.ResponseWriter, r *http.Request) {
+	badgeID := chi.URLParam(r, "id")
+
+	var req models.BadgeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	badge, problem := badgeFromRequest(req)
+	if problem != "" {
+		respondError(w, http.StatusBadRequest, problem)
+		return
+	}
+	badge.ID = badgeID
+
+	if other, err := h.db.GetBadgeByName(badge.Name); err == nil && other.ID != badgeID {
+		respondError(w, http.StatusConflict, "A badge with this name already exists")
+		return
+	} else if err != nil && err != sql.ErrNoRows {
+		respondError(w, http.StatusInternalServerError, "Failed to update badge")
+		return
+	}
+
+	if err := h.db.UpdateBadge(badge); err != nil {
+		if err == sql.ErrNoRows {
+			respondError(w, http.StatusNotFound, "Badge not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to update badge")
+		return
+	}
+
+	h.auditBadge(r, models.BadgeActionUpdate, badgeID, "")
+
+	updated, err := h.db.GetBadgeByID(badgeID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get badge")
+		return
+	}
+	respondJSON(w, http.StatusOK, updated)
+}
+
+// DeleteBadge deletes a badge and takes it away from everyone holding it
+// (admin only)
+func (h *Handler) DeleteBadge(w http.ResponseWriter, r *http.Request) {
+	badgeID := chi.URLParam(r, "id")
+
+	if err := h.db.DeleteBadge(badgeID); err != nil {
+		if err == sql.ErrNoRows {
+			respondError(w, http.StatusNotFound, "Badge not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to delete badge")
+		return
+	}
+
+	h.auditBadge(r, models.BadgeActionDelete, badgeID, "")
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Badge deleted successfully"})
 }
 
 // AssignBadgeToUser assigns a badge to a user
@@ -676,6 +1149,8 @@ func (h *Handler) AssignBadgeToUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.auditBadge(r, models.BadgeActionAssign, badgeID, userID)
+
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Badge assigned successfully"})
 }
 
@@ -694,5 +1169,44 @@ func (h *Handler) RemoveBadgeFromUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.auditBadge(r, models.BadgeActionRemove, badgeID, userID)
+
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Badge removed successfully"})
 }
+
+// GetBadgeAuditLog returns the most recent badge mutations (admin only)
+func (h *Handler) GetBadgeAuditLog(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		fmt.Sscanf(limitStr, "%d", &limit)
+	}
+
+	entries, err := h.db.GetBadgeAuditLog(limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get badge audit log")
+		return
+	}
+	if entries == nil {
+		entries = []models.BadgeAuditEntry{}
+	}
+
+	respondJSON(w, http.StatusOK, entries)
+}
+
+// auditBadge records a badge mutation performed by the authenticated user
+func (h *Handler) auditBadge(r *http.Request, action, badgeID, targetUserID string) {
+	actorID := ""
+	if userCtx, ok := auth.GetUserFromContext(r.Context()); ok {
+		actorID = userCtx.UserID
+	}
+
+	err := h.db.RecordBadgeAudit(models.BadgeAuditEntry{
+		ActorID:      actorID,
+		Action:       action,
+		BadgeID:      badgeID,
+		TargetUserID: targetUserID,
+	})
+	if err != nil {
+		fmt.Printf("Error recording badge audit (%s %s): %v\n", action, badgeID, err)
+	}
+}
@@ -0,0 +1,378 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/typing-code-learn/api-go/internal/auth"
+	"github.com/typing-code-learn/api-go/internal/database"
+	"github.com/typing-code-learn/api-go/internal/lessons"
+	"github.com/typing-code-learn/api-go/internal/mail"
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+const testPassword = "Password123"
+
+// testLesson is short enough to finish a typing session within the clock
+// skew FinishSession tolerates
+var testLesson = models.Lesson{
+	ID: "go-basics-01", Title: "Hola", Language: "go", Level: "basic",
+	Code: "ab", Mode: "strict", Difficulty: "beginner", Order: 1,
+}
+
+// testAPI is the API on an in-memory store, routed like main.go without
+// rate limits
+type testAPI struct {
+	t       *testing.T
+	db      *database.MemoryDB
+	auth    *auth.Service
+	h       *Handler
+	lessons *lessons.Store
+	router  http.Handler
+	outbox  *outbox
+}
+
+// outbox keeps the mail the API sends
+type outbox struct {
+	mu       sync.Mutex
+	messages []mail.Message
+}
+
+func (o *outbox) Send(msg mail.Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+// wait returns the first message to an address with a subject, waiting a
+// little as mail is sent in the background
+func (o *outbox) wait(t *testing.T, to, subject string) mail.Message {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		o.mu.Lock()
+		for _, msg := range o.messages {
+			if msg.To == to && msg.Subject == subject {
+				o.mu.Unlock()
+				return msg
+			}
+		}
+		o.mu.Unlock()
+	}
+	t.Fatalf("no mail to %s: %s", to, subject)
+	return mail.Message{}
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+	db := database.NewMemoryDB()
+	authService := auth.NewService("test-secret-key-that-is-at-least-32-chars")
+	authService.SetDenylist(db)
+	authService.SetSessionTracker(db)
+	authService.SetPersonalTokenStore(db)
+
+	lessonStore := lessons.NewStore()
+	lesson := testLesson
+	lessonStore.Add(&lesson)
+	h := New(db, lessonStore, authService)
+	sent := &outbox{}
+	h.SetMailer(sent)
+
+	r := chi.NewRouter()
+	r.Use(authService.AuthMiddleware)
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Post("/auth/guest", h.CreateGuestUser)
+		r.Post("/auth/register", h.Register)
+		r.Post("/auth/login", h.Login)
+		r.Post("/auth/refresh", h.RefreshToken)
+		r.Post("/auth/logout", h.Logout)
+		r.Post("/auth/password/forgot", h.ForgotPassword)
+		r.Post("/auth/password/reset", h.ResetPassword)
+		r.With(authService.RequireScope(auth.ScopeReadProfile)).Get("/auth/me", h.GetMe)
+		r.With(authService.RequireAuth).Delete("/auth/sessions", h.RevokeOtherSessions)
+		r.With(authService.RequireAuth).Post("/auth/tokens", h.CreatePersonalToken)
+
+		r.Get("/lessons", h.ListLessons)
+		r.Get("/lessons/{id}", h.GetLesson)
+		r.Get("/lessons/language/{language}", h.GetLessonsByLanguage)
+		r.With(authService.RequireScope(auth.ScopeWriteProgress)).Post("/progress", h.SaveProgress)
+
+		r.With(authService.RequireScope(auth.ScopeWriteMetrics)).Post("/metrics", h.SaveMetrics)
+		r.With(authService.RequireScope(auth.ScopeReadMetrics)).Get("/metrics/{userId}", h.GetUserMetrics)
+		r.With(authService.RequireScope(auth.ScopeWriteMetrics)).Post("/sessions", h.StartSession)
+		r.With(authService.RequireScope(auth.ScopeWriteMetrics)).Post("/sessions/{id}/finish", h.FinishSession)
+
+		requireAdmin := authService.RequireRole(models.RoleAdmin)
+		r.With(authService.RequireAuth, requireAdmin).Delete("/users/{userId}/sessions", h.RevokeUserSessions)
+		r.With(authService.RequireAuth, requireAdmin).Delete("/users/{userId}/mfa", h.ResetUserMFA)
+	})
+
+	return &testAPI{t: t, db: db, auth: authService, h: h, lessons: lessonStore, router: r, outbox: sent}
+}
+
+// do sends a request with a JSON body, authenticated by token if it is set
+func (a *testAPI) do(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	a.t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			a.t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// expect fails the test unless the response has the status, and decodes its
+// body into v when v is set
+func (a *testAPI) expect(rec *httptest.ResponseRecorder, status int, v interface{}) {
+	a.t.Helper()
+	if rec.Code != status {
+		a.t.Fatalf("got status %d, want %d: %s", rec.Code, status, rec.Body.String())
+	}
+	if v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			a.t.Fatalf("invalid response %s: %v", rec.Body.String(), err)
+		}
+	}
+}
+
+// register creates an account and returns its first login session
+func (a *testAPI) register(username string) models.AuthResponse {
+	a.t.Helper()
+	var resp models.AuthResponse
+	a.expect(a.do("POST", "/api/v1/auth/register", "", models.RegisterRequest{
+		Username: username, Email: username + "@example.com", Password: testPassword,
+	}), http.StatusCreated, &resp)
+	return resp
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	api := newTestAPI(t)
+	registered := api.register("ada")
+	if registered.Token == "" || registered.RefreshToken == "" || registered.User.IsGuest {
+		t.Fatalf("unexpected registration response %+v", registered)
+	}
+
+	var me models.User
+	api.expect(api.do("GET", "/api/v1/auth/me", registered.Token, nil), http.StatusOK, &me)
+	if me.ID != registered.User.ID || me.Username != "ada" || me.Tier == nil {
+		t.Errorf("unexpected user %+v", me)
+	}
+
+	api.expect(api.do("POST", "/api/v1/auth/login", "", models.LoginRequest{Username: "ada", Password: "Wrong12345"}), http.StatusUnauthorized, nil)
+	api.expect(api.do("POST", "/api/v1/auth/login", "", models.LoginRequest{Username: "nobody", Password: testPassword}), http.StatusUnauthorized, nil)
+
+	var login models.AuthResponse
+	api.expect(api.do("POST", "/api/v1/auth/login", "", models.LoginRequest{Username: "ada", Password: testPassword}), http.StatusOK, &login)
+	if login.User.ID != registered.User.ID || login.Token == "" {
+		t.Errorf("unexpected login response %+v", login)
+	}
+}
+
+func TestRegisterValidation(t *testing.T) {
+	api := newTestAPI(t)
+	api.register("ada")
+
+	tests := []struct {
+		name   string
+		req    models.RegisterRequest
+		status int
+	}{
+		{"taken username", models.RegisterRequest{Username: "ada", Password: testPassword}, http.StatusConflict},
+		{"taken email", models.RegisterRequest{Username: "grace", Email: "ada@example.com", Password: testPassword}, http.StatusConflict},
+		{"weak password", models.RegisterRequest{Username: "grace", Password: "short"}, http.StatusBadRequest},
+		{"invalid username", models.RegisterRequest{Username: "no spaces", Password: testPassword}, http.StatusBadRequest},
+		{"no password", models.RegisterRequest{Username: "grace"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api.t = t
+			api.expect(api.do("POST", "/api/v1/auth/register", "", tt.req), tt.status, nil)
+		})
+	}
+}
+
+func TestGuestConversionKeepsUser(t *testing.T) {
+	api := newTestAPI(t)
+	var guest models.AuthResponse
+	api.expect(api.do("POST", "/api/v1/auth/guest", "", nil), http.StatusOK, &guest)
+	if !guest.User.IsGuest {
+		t.Fatalf("guest user is not a guest: %+v", guest.User)
+	}
+
+	var registered models.AuthResponse
+	api.expect(api.do("POST", "/api/v1/auth/register", guest.Token, models.RegisterRequest{
+		Username: "ada", Password: testPassword, GuestID: &guest.User.ID,
+	}), http.StatusCreated, &registered)
+	if registered.User.ID != guest.User.ID || registered.User.IsGuest {
+		t.Errorf("guest was not converted: %+v", registered.User)
+	}
+
+	// Registering replaces the guest's login session
+	api.expect(api.do("GET", "/api/v1/auth/me", guest.Token, nil), http.StatusUnauthorized, nil)
+}
+
+func TestRefreshRotatesTokens(t *testing.T) {
+	api := newTestAPI(t)
+	first := api.register("ada")
+
+	var refreshed models.AuthResponse
+	api.expect(api.do("POST", "/api/v1/auth/refresh", "", models.RefreshRequest{RefreshToken: first.RefreshToken}), http.StatusOK, &refreshed)
+	if refreshed.RefreshToken == "" || refreshed.RefreshToken == first.RefreshToken {
+		t.Fatalf("refresh token was not rotated: %+v", refreshed)
+	}
+	api.expect(api.do("GET", "/api/v1/auth/me", refreshed.Token, nil), http.StatusOK, nil)
+
+	// Reusing a rotated refresh token revokes the whole session
+	api.expect(api.do("POST", "/api/v1/auth/refresh", "", models.RefreshRequest{RefreshToken: first.RefreshToken}), http.StatusUnauthorized, nil)
+	api.expect(api.do("POST", "/api/v1/auth/refresh", "", models.RefreshRequest{RefreshToken: refreshed.RefreshToken}), http.StatusUnauthorized, nil)
+	api.expect(api.do("GET", "/api/v1/auth/me", refreshed.Token, nil), http.StatusUnauthorized, nil)
+}
+
+func TestLogoutRevokesSession(t *testing.T) {
+	api := newTestAPI(t)
+	session := api.register("ada")
+
+	api.expect(api.do("POST", "/api/v1/auth/logout", session.Token, nil), http.StatusOK, nil)
+	api.expect(api.do("GET", "/api/v1/auth/me", session.Token, nil), http.StatusUnauthorized, nil)
+	api.expect(api.do("POST", "/api/v1/auth/refresh", "", models.RefreshRequest{RefreshToken: session.RefreshToken}), http.StatusUnauthorized, nil)
+}
+
+func TestLogoutWithRefreshCookie(t *testing.T) {
+	api := newTestAPI(t)
+	session := api.register("ada")
+	sessionID, _, err := auth.ParseRefreshToken(session.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logout := func(refreshToken string) {
+		t.Helper()
+		req := httptest.NewRequest("POST", "/api/v1/auth/logout", nil)
+		req.AddCookie(&http.Cookie{Name: refreshCookieName, Value: refreshToken})
+		rec := httptest.NewRecorder()
+		api.router.ServeHTTP(rec, req)
+		api.expect(rec, http.StatusOK, nil)
+	}
+
+	// Knowing the session ID is not enough to end someone's session
+	logout(sessionID + ".guessed")
+	api.expect(api.do("GET", "/api/v1/auth/me", session.Token, nil), http.StatusOK, nil)
+
+	logout(session.RefreshToken)
+	api.expect(api.do("GET", "/api/v1/auth/me", session.Token, nil), http.StatusUnauthorized, nil)
+}
+
+// plausibleMetrics is a clean run of testLesson
+func plausibleMetrics(userID string) models.MetricsRequest {
+	return models.MetricsRequest{
+		UserID: userID, LessonID: testLesson.ID,
+		WPM: 24, Accuracy: 100, TotalTime: 1, TotalChars: 2, CorrectChars: 2,
+	}
+}
+
+func TestSaveMetrics(t *testing.T) {
+	api := newTestAPI(t)
+	ada := api.register("ada")
+	grace := api.register("grace")
+
+	api.expect(api.do("POST", "/api/v1/metrics", "", plausibleMetrics(ada.User.ID)), http.StatusUnauthorized, nil)
+	api.expect(api.do("POST", "/api/v1/metrics", grace.Token, plausibleMetrics(ada.User.ID)), http.StatusForbidden, nil)
+
+	impossible := plausibleMetrics(ada.User.ID)
+	impossible.Accuracy = 140
+	api.expect(api.do("POST", "/api/v1/metrics", ada.Token, impossible), http.StatusUnprocessableEntity, nil)
+
+	var saved struct {
+		Metrics      models.TypingMetrics `json:"metrics"`
+		PointsEarned int                  `json:"pointsEarned"`
+	}
+	api.expect(api.do("POST", "/api/v1/metrics", ada.Token, plausibleMetrics("")), http.StatusOK, &saved)
+	if saved.Metrics.UserID != ada.User.ID || saved.Metrics.ReviewStatus != models.ReviewStatusOK || saved.Metrics.SessionID != "" {
+		t.Errorf("unexpected metrics %+v", saved.Metrics)
+	}
+	// Client-reported metrics never earn points
+	if saved.PointsEarned != 0 {
+		t.Errorf("got %d points, want 0", saved.PointsEarned)
+	}
+	if points, _ := api.db.GetUserPoints(ada.User.ID, time.Time{}, time.Now()); points != 0 {
+		t.Errorf("user has %d points, want 0", points)
+	}
+
+	suspicious := plausibleMetrics("")
+	suspicious.WPM = 60
+	api.expect(api.do("POST", "/api/v1/metrics", ada.Token, suspicious), http.StatusOK, &saved)
+	if saved.Metrics.ReviewStatus != models.ReviewStatusFlagged {
+		t.Errorf("metrics with a WPM that doesn't match the time got status %q, want flagged", saved.Metrics.ReviewStatus)
+	}
+
+	var summary models.UserMetricsSummary
+	api.expect(api.do("GET", "/api/v1/metrics/"+ada.User.ID, ada.Token, nil), http.StatusOK, &summary)
+	if summary.TotalSessions != 2 {
+		t.Errorf("got %d sessions, want 2", summary.TotalSessions)
+	}
+}
+
+// finishSession starts a typing session for the lesson and finishes it with
+// the keystroke log
+func (a *testAPI) finishSession(token, lessonID string, log models.KeystrokeLog) *httptest.ResponseRecorder {
+	a.t.Helper()
+	var started models.StartSessionResponse
+	a.expect(a.do("POST", "/api/v1/sessions", token, models.StartSessionRequest{LessonID: lessonID}), http.StatusCreated, &started)
+	return a.do("POST", "/api/v1/sessions/"+started.Session.ID+"/finish", token, models.FinishSessionRequest{
+		Token: started.Token, Keystrokes: log,
+	})
+}
+
+func TestFinishSessionAwardsPoints(t *testing.T) {
+	api := newTestAPI(t)
+	ada := api.register("ada")
+
+	api.expect(api.finishSession(ada.Token, testLesson.ID, models.KeystrokeLog{Keys: "a", Times: []int64{0}}), http.StatusUnprocessableEntity, nil)
+
+	var finished struct {
+		Metrics      models.TypingMetrics `json:"metrics"`
+		PointsEarned int                  `json:"pointsEarned"`
+	}
+	api.expect(api.finishSession(ada.Token, testLesson.ID, models.KeystrokeLog{Keys: "ab", Times: []int64{0, 1000}}), http.StatusOK, &finished)
+	if finished.Metrics.WPM != 24 || finished.Metrics.Accuracy != 100 || finished.Metrics.SessionID == "" {
+		t.Errorf("unexpected metrics %+v", finished.Metrics)
+	}
+	// 2 points for the run and the first completion bonus
+	if finished.PointsEarned != 52 {
+		t.Errorf("got %d points, want 52", finished.PointsEarned)
+	}
+	if points, _ := api.db.GetUserPoints(ada.User.ID, time.Time{}, time.Now()); points != 52 {
+		t.Errorf("user has %d points, want 52", points)
+	}
+}
+
+func TestFinishSessionOnlyOnce(t *testing.T) {
+	api := newTestAPI(t)
+	ada := api.register("ada")
+	grace := api.register("grace")
+
+	var started models.StartSessionResponse
+	api.expect(api.do("POST", "/api/v1/sessions", ada.Token, models.StartSessionRequest{LessonID: testLesson.ID}), http.StatusCreated, &started)
+	finish := models.FinishSessionRequest{Token: started.Token, Keystrokes: models.KeystrokeLog{Keys: "ab", Times: []int64{0, 1000}}}
+	path := "/api/v1/sessions/" + started.Session.ID + "/finish"
+
+	api.expect(api.do("POST", path, grace.Token, finish), http.StatusForbidden, nil)
+	api.expect(api.do("POST", path, ada.Token, finish), http.StatusOK, nil)
+	api.expect(api.do("POST", path, ada.Token, finish), http.StatusConflict, nil)
+
+	api.expect(api.do("POST", "/api/v1/sessions", ada.Token, models.StartSessionRequest{LessonID: "missing"}), http.StatusNotFound, nil)
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/typing-code-learn/api-go/internal/auth"
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+// LoginLimits throttles failed logins per client IP and per username
+type LoginLimits struct {
+	IP       auth.AttemptLimiter
+	Username auth.AttemptLimiter
+}
+
+// NewLoginLimits creates in-process login limits. An IP gets more free
+// attempts than a username because many users can share one address.
+func NewLoginLimits() LoginLimits {
+	return LoginLimits{
+		IP: auth.NewMemoryLimiter(auth.BackoffPolicy{
+			FreeAttempts: 20,
+			BaseDelay:    time.Second,
+			MaxDelay:     15 * time.Minute,
+			ResetAfter:   time.Hour,
+		}),
+		Username: auth.NewMemoryLimiter(auth.BackoffPolicy{
+			FreeAttempts: 5,
+			BaseDelay:    time.Second,
+			MaxDelay:     15 * time.Minute,
+			ResetAfter:   time.Hour,
+		}),
+	}
+}
+
+// SetLoginLimits replaces the login limiters, e.g. with ones backed by a store
+// shared between replicas
+func (h *Handler) SetLoginLimits(limits LoginLimits) {
+	h.loginLimits = limits
+}
+
+// loginWait returns how long a login for username from ip must wait
+func (h *Handler) loginWait(ip, username string, now time.Time) time.Duration {
+	wait := h.loginLimits.IP.Wait(ip, now)
+	if w := h.loginLimits.Username.Wait(strings.ToLower(username), now); w > wait {
+		wait = w
+	}
+	return wait
+}
+
+// loginFailed records a failed login. When the username is being backed off,
+// the lockout is also recorded on the user so it holds across replicas.
+func (h *Handler) loginFailed(ip, username string, user *models.User, now time.Time) {
+	h.loginLimits.IP.Fail(ip, now)
+	delay := h.loginLimits.Username.Fail(strings.ToLower(username), now)
+	if user == nil {
+		return
+	}
+
+	var lockedUntil *time.Time
+	if delay > 0 {
+		until := now.Add(delay)
+		lockedUntil = &until
+	}
+	if err := h.db.RecordFailedLogin(user.ID, lockedUntil); err != nil {
+		fmt.Printf("Error recording failed login for user %s: %v\n", user.ID, err)
+	}
+}
+
+// loginSucceeded clears the failures of a username. The IP keeps its count so
+// an attacker cannot reset it by logging into an account of their own.
+func (h *Handler) loginSucceeded(username, userID string) {
+	h.loginLimits.Username.Reset(strings.ToLower(username))
+	if err := h.db.ResetFailedLogins(userID); err != nil {
+		fmt.Printf("Error resetting failed logins for user %s: %v\n", userID, err)
+	}
+}
+
+// respondTooManyAttempts writes a 429 telling the client when to retry
+func respondTooManyAttempts(w http.ResponseWriter, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	respondError(w, http.StatusTooManyRequests, fmt.Sprintf("Too many login attempts, try again in %d seconds", seconds))
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+func TestLoginBackoff(t *testing.T) {
+	api := newTestAPI(t)
+	api.register("ada")
+	wrong := models.LoginRequest{Username: "ada", Password: "Wrong12345"}
+	right := models.LoginRequest{Username: "ada", Password: testPassword}
+
+	// The username's free attempts, then one that starts the backoff
+	for i := 0; i < 6; i++ {
+		api.expect(api.do("POST", "/api/v1/auth/login", "", wrong), http.StatusUnauthorized, nil)
+	}
+
+	expectTooMany := func() {
+		t.Helper()
+		rec := api.do("POST", "/api/v1/auth/login", "", right)
+		api.expect(rec, http.StatusTooManyRequests, nil)
+		retry, err := strconv.Atoi(rec.Header().Get("Retry-After"))
+		if err != nil || retry < 1 {
+			t.Errorf("got Retry-After %q, want a number of seconds", rec.Header().Get("Retry-After"))
+		}
+	}
+	// Even the right password has to wait
+	expectTooMany()
+
+	// The lockout is recorded on the user, so a replica that has not seen
+	// the failures enforces it too
+	api.h.SetLoginLimits(NewLoginLimits())
+	expectTooMany()
+
+	// Other users are unaffected
+	api.register("grace")
+	api.expect(api.do("POST", "/api/v1/auth/login", "", models.LoginRequest{Username: "grace", Password: testPassword}), http.StatusOK, nil)
+}
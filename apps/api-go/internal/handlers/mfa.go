@@ -0,0 +1,352 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/typing-code-learn/api-go/internal/auth"
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+// totpIssuer names the account in authenticator apps
+const totpIssuer = "Typer"
+
+// contributorBadge marks accounts that, like admins, may enable TOTP
+const contributorBadge = "Contributor"
+
+// mfaEligible reports whether a user may enroll a second factor. It expects
+// the user's badges to be loaded.
+func mfaEligible(user *models.User) bool {
+	if user.IsGuest {
+		return false
+	}
+	if user.Role == models.RoleAdmin {
+		return true
+	}
+	for _, b := range user.Badges {
+		if b.Badge.Name == contributorBadge {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSecondFactor accepts a TOTP code, or else a recovery code, for a user
+// with TOTP enabled. Each code works only once.
+func (h *Handler) checkSecondFactor(userID string, req models.MFACodeRequest, now time.Time) (bool, error) {
+	if req.Code == "" && req.RecoveryCode != "" {
+		err := h.db.UseRecoveryCode(userID, auth.HashRecoveryCode(req.RecoveryCode), now)
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	cfg, err := h.db.GetTOTP(userID)
+	if err == sql.ErrNoRows || (err == nil && cfg.EnabledAt == nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	step, ok := auth.VerifyTOTP(cfg.Secret, req.Code, now, cfg.LastStep)
+	if !ok {
+		return false, nil
+	}
+	// Lost a race with a request using the same code
+	err = h.db.UseTOTPStep(userID, step)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// passSecondFactor checks a second factor like checkSecondFactor, throttled
+// and counted like a password attempt so codes cannot be guessed. It writes
+// the error response, using failStatus for a wrong code, and returns false
+// if the check did not pass.
+func (h *Handler) passSecondFactor(w http.ResponseWriter, r *http.Request, user *models.User, req models.MFACodeRequest, failStatus int) bool {
+	ip := auth.ClientIP(r)
+	now := time.Now().UTC()
+	if wait := h.loginWait(ip, user.Username, now); wait > 0 {
+		respondTooManyAttempts(w, wait)
+		return false
+	}
+
+	valid, err := h.checkSecondFactor(user.ID, req, now)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to check code")
+		return false
+	}
+	if !valid {
+		h.loginFailed(ip, user.Username, user, now)
+		respondError(w, failStatus, "Invalid code")
+		return false
+	}
+	return true
+}
+
+// issueRecoveryCodes replaces a user's recovery codes and returns the new ones
+func (h *Handler) issueRecoveryCodes(userID string, now time.Time) ([]string, error) {
+	codes, hashes, err := auth.NewRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := h.db.ReplaceRecoveryCodes(userID, hashes, now); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// GetMFAStatus reports whether the current user can use and has enabled TOTP
+func (h *Handler) GetMFAStatus(w http.ResponseWriter, r *http.Request) {
+	userCtx, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	user, err := h.db.GetUserByID(userCtx.UserID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get user")
+		return
+	}
+	left, err := h.db.CountRecoveryCodes(user.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to count recovery codes")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"eligible":          mfaEligible(user),
+		"enabled":           user.MFAEnabled,
+		"recoveryCodesLeft": left,
+	})
+}
+
+// EnrollTOTP creates a TOTP secret for the current user. It stays pending
+// until ConfirmTOTP receives a code generated from it.
+func (h *Handler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	userCtx, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	user, err := h.db.GetUserByID(userCtx.UserID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get user")
+		return
+	}
+	if !mfaEligible(user) {
+		respondError(w, http.StatusForbidden, "Two-factor authentication is available to admins and contributors")
+		return
+	}
+	if user.MFAEnabled {
+		respondError(w, http.StatusConflict, "Two-factor authentication is already enabled")
+		return
+	}
+
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to generate secret")
+		return
+	}
+	if err := h.db.SetTOTPSecret(user.ID, secret); err != nil {
+		if err == sql.ErrNoRows {
+			respondError(w, http.StatusConflict, "Two-factor authentication is already enabled")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to save secret")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.TOTPEnrollResponse{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(totpIssuer, user.Username, secret),
+	})
+}
+
+// ConfirmTOTP enables a pending TOTP enrollment with a first code and returns
+// the user's recovery codes
+func (h *Handler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	userCtx, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	var req models.MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	cfg, err := h.db.GetTOTP(userCtx.UserID)
+	if err != nil && err != sql.ErrNoRows {
+		respondError(w, http.StatusInternalServerError, "Failed to get enrollment")
+		return
+	}
+	if err == sql.ErrNoRows || cfg.EnabledAt != nil {
+		respondError(w, http.StatusConflict, "No pending two-factor enrollment")
+		return
+	}
+
+	now := time.Now().UTC()
+	step, ok := auth.VerifyTOTP(cfg.Secret, req.Code, now, cfg.LastStep)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "Invalid code")
+		return
+	}
+	if err := h.db.EnableTOTP(userCtx.UserID, step, now); err != nil {
+		if err == sql.ErrNoRows {
+			respondError(w, http.StatusConflict, "No pending two-factor enrollment")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to enable two-factor authentication")
+		return
+	}
+
+	codes, err := h.issueRecoveryCodes(userCtx.UserID, now)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create recovery codes")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTOTP turns off the current user's second factor. It takes a TOTP or
+// recovery code so a stolen session alone cannot remove it.
+func (h *Handler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	userCtx, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	var req models.MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	user, err := h.db.GetUserByID(userCtx.UserID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get user")
+		return
+	}
+	if !user.MFAEnabled {
+		respondError(w, http.StatusConflict, "Two-factor authentication is not enabled")
+		return
+	}
+	if !h.passSecondFactor(w, r, user, req, http.StatusBadRequest) {
+		return
+	}
+
+	if err := h.db.DisableTOTP(userCtx.UserID); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to disable two-factor authentication")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes replaces the current user's recovery codes
+func (h *Handler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userCtx, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	var req models.MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	user, err := h.db.GetUserByID(userCtx.UserID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get user")
+		return
+	}
+	if !user.MFAEnabled {
+		respondError(w, http.StatusConflict, "Two-factor authentication is not enabled")
+		return
+	}
+	if !h.passSecondFactor(w, r, user, req, http.StatusBadRequest) {
+		return
+	}
+
+	codes, err := h.issueRecoveryCodes(user.ID, time.Now().UTC())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create recovery codes")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// VerifyMFA completes a login that returned an MFA challenge
+func (h *Handler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20) // 1 MB limit
+
+	var req models.MFAVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	userID, err := h.authService.ValidateMFAToken(req.MFAToken)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "Invalid or expired MFA challenge")
+		return
+	}
+	user, err := h.db.GetUserByID(userID)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "Invalid or expired MFA challenge")
+		return
+	}
+
+	if !h.passSecondFactor(w, r, user, req.MFACodeRequest, http.StatusUnauthorized) {
+		return
+	}
+
+	h.loginSucceeded(user.Username, user.ID)
+
+	// Replace the session the request came from, usually a guest one
+	h.endAuthSession(r)
+
+	resp, err := h.startAuthSession(w, r, *user)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// ResetUserMFA removes the second factor of any user, e.g. when they lost
+// both their device and recovery codes (admin only)
+func (h *Handler) ResetUserMFA(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userId")
+	if _, err := h.db.GetUserByID(userID); err != nil {
+		respondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	if err := h.db.DisableTOTP(userID); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to disable two-factor authentication")
+		return
+	}
+	if _, err := h.revokeAccess(userID); err != nil {
+		fmt.Printf("Error revoking sessions and tokens of user %s: %v\n", userID, err)
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Two-factor authentication disabled"})
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/typing-code-learn/api-go/internal/auth"
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+func TestCheckSecondFactor(t *testing.T) {
+	api := newTestAPI(t)
+	ada := api.register("ada")
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := auth.TOTPCode(secret, auth.TOTPStep(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(req models.MFACodeRequest) bool {
+		t.Helper()
+		ok, err := api.h.checkSecondFactor(ada.User.ID, req, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok
+	}
+
+	// Nothing passes before TOTP is enabled
+	if err := api.db.SetTOTPSecret(ada.User.ID, secret); err != nil {
+		t.Fatal(err)
+	}
+	if check(models.MFACodeRequest{Code: code}) {
+		t.Error("code was accepted before TOTP was enabled")
+	}
+	if err := api.db.EnableTOTP(ada.User.ID, 0, now); err != nil {
+		t.Fatal(err)
+	}
+
+	if !check(models.MFACodeRequest{Code: code}) {
+		t.Error("valid code was rejected")
+	}
+	if check(models.MFACodeRequest{Code: code}) {
+		t.Error("code was accepted twice")
+	}
+
+	codes, err := api.h.issueRecoveryCodes(ada.User.ID, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, recovery := range codes[:2] {
+		if !check(models.MFACodeRequest{RecoveryCode: recovery}) {
+			t.Errorf("recovery code %s was rejected", recovery)
+		}
+		if check(models.MFACodeRequest{RecoveryCode: recovery}) {
+			t.Errorf("recovery code %s was accepted twice", recovery)
+		}
+	}
+	if check(models.MFACodeRequest{RecoveryCode: "aaaaa-aaaaa"}) {
+		t.Error("unknown recovery code was accepted")
+	}
+	if n, err := api.db.CountRecoveryCodes(ada.User.ID); err != nil || n != len(codes)-2 {
+		t.Errorf("got %d recovery codes left (%v), want %d", n, err, len(codes)-2)
+	}
+
+	// Issuing new codes retires the old ones
+	if _, err := api.h.issueRecoveryCodes(ada.User.ID, now); err != nil {
+		t.Fatal(err)
+	}
+	if check(models.MFACodeRequest{RecoveryCode: codes[2]}) {
+		t.Error("replaced recovery code was accepted")
+	}
+}
@@ -0,0 +1,417 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/typing-code-learn/api-go/internal/auth"
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+// oauthStateCookie carries the signed OAuthStateClaims of a login in progress
+// from the redirect to the provider back to its callback
+const (
+	oauthStateCookie     = "oauth_state"
+	oauthStateCookiePath = "/api/v1/auth/oauth"
+)
+
+// invalidUsernameChars matches what validateUsername rejects
+var invalidUsernameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// SetOAuthProviders replaces the identity providers users can log in with.
+// redirectBase is the public URL of this API, which providers send users
+// back to.
+func (h *Handler) SetOAuthProviders(redirectBase string, providers ...*auth.OAuthProvider) {
+	h.oauthRedirectBase = strings.TrimRight(redirectBase, "/")
+	h.oauthProviders = make(map[string]*auth.OAuthProvider, len(providers))
+	for _, p := range providers {
+		h.oauthProviders[p.Name] = p
+	}
+}
+
+// oauthRedirectURI returns the callback URL registered with a provider
+func (h *Handler) oauthRedirectURI(provider string) string {
+	return h.oauthRedirectBase + "/api/v1/auth/oauth/" + url.PathEscape(provider) + "/callback"
+}
+
+// redirectToApp sends the browser back to the web client's OAuth callback page
+func redirectToApp(w http.ResponseWriter, r *http.Request, params url.Values) {
+	http.Redirect(w, r, appURL()+"/oauth-callback?"+params.Encode(), http.StatusFound)
+}
+
+// redirectOAuthError reports a failed OAuth login to the web client
+func redirectOAuthError(w http.ResponseWriter, r *http.Request, message string) {
+	redirectToApp(w, r, url.Values{"error": {message}})
+}
+
+// appPath accepts only paths within the web client as where to go after login
+func appPath(redirect string) string {
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") || strings.Contains(redirect, `\`) {
+		return ""
+	}
+	return redirect
+}
+
+// ListOAuthProviders lists the identity providers users can log in with
+func (h *Handler) ListOAuthProviders(w http.ResponseWriter, r *http.Request) {
+	providers := make([]models.OAuthProviderInfo, 0, len(h.oauthProviders))
+	for _, p := range h.oauthProviders {
+		providers = append(providers, models.OAuthProviderInfo{Name: p.Name, GitHub: p.GitHub})
+	}
+	sort.Slice(providers, func(i, j int) bool { return providers[i].Name < providers[j].Name })
+	respondJSON(w, http.StatusOK, providers)
+}
+
+// StartOAuth redirects the browser to a provider to log in. A registered user
+// links the identity to their account instead; a guest's account is
+// converted if the identity is new.
+func (h *Handler) StartOAuth(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.oauthProviders[chi.URLParam(r, "provider")]
+	if !ok {
+		respondError(w, http.StatusNotFound, "Unknown identity provider")
+		return
+	}
+
+	claims := auth.OAuthStateClaims{
+		Provider: provider.Name,
+		Redirect: appPath(r.URL.Query().Get("redirect")),
+	}
+	// Personal access tokens cannot link identities
+	if userCtx, ok := auth.GetUserFromContext(r.Context()); ok && userCtx.TokenID == "" {
+		user, err := h.db.GetUserByID(userCtx.UserID)
+		if err == nil {
+			claims.UserID = user.ID
+			claims.Link = !user.IsGuest
+		}
+	}
+
+	state := make([]byte, 32)
+	if _, err := rand.Read(state); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to start login")
+		return
+	}
+	verifier, challenge, err := auth.NewPKCE()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to start login")
+		return
+	}
+	claims.State = base64.RawURLEncoding.EncodeToString(state)
+	claims.Verifier = verifier
+
+	cookie, err := h.authService.GenerateOAuthState(claims)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to start login")
+		return
+	}
+	http.SetCookie(w, authCookie(oauthStateCookie, cookie, oauthStateCookiePath, int(auth.OAuthStateDuration.Seconds())))
+
+	http.Redirect(w, r, provider.AuthCodeURL(h.oauthRedirectURI(provider.Name), claims.State, challenge), http.StatusFound)
+}
+
+// OAuthCallback completes a login at a provider: it checks the state the
+// browser left with, exchanges the code and logs in, links or registers the
+// identity. The browser ends up on the web client's /oauth-callback page.
+func (h *Handler) OAuthCallback(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.oauthProviders[chi.URLParam(r, "provider")]
+	if !ok {
+		respondError(w, http.StatusNotFound, "Unknown identity provider")
+		return
+	}
+
+	cookie, err := r.Cookie(oauthStateCookie)
+	if err != nil {
+		redirectOAuthError(w, r, "Login expired, please try again")
+		return
+	}
+	// The state is single use
+	http.SetCookie(w, authCookie(oauthStateCookie, "", oauthStateCookiePath, -1))
+
+	claims, err := h.authService.ValidateOAuthState(cookie.Value)
+	state := r.URL.Query().Get("state")
+	if err != nil || claims.Provider != provider.Name ||
+		subtle.ConstantTimeCompare([]byte(claims.State), []byte(state)) != 1 {
+		redirectOAuthError(w, r, "Login expired, please try again")
+		return
+	}
+	if e := r.URL.Query().Get("error"); e != "" {
+		redirectOAuthError(w, r, "Login was cancelled")
+		return
+	}
+
+	accessToken, err := provider.Exchange(r.Context(), r.URL.Query().Get("code"), h.oauthRedirectURI(provider.Name), claims.Verifier)
+	if err != nil {
+		fmt.Printf("Error exchanging %s authorization code: %v\n", provider.Name, err)
+		redirectOAuthError(w, r, "Login failed at "+provider.Name)
+		return
+	}
+	identity, err := provider.FetchIdentity(r.Context(), accessToken)
+	if err != nil {
+		fmt.Printf("Error fetching %s identity: %v\n", provider.Name, err)
+		redirectOAuthError(w, r, "Login failed at "+provider.Name)
+		return
+	}
+
+	done := url.Values{}
+	if claims.Redirect != "" {
+		done.Set("redirect", claims.Redirect)
+	}
+
+	if claims.Link {
+		if problem := h.linkIdentity(r, provider, identity, claims.UserID); problem != "" {
+			redirectOAuthError(w, r, problem)
+			return
+		}
+		done.Set("linked", provider.Name)
+		redirectToApp(w, r, done)
+		return
+	}
+
+	user, err := h.oauthUser(provider, identity, claims.UserID)
+	if err != nil {
+		fmt.Printf("Error logging in with %s: %v\n", provider.Name, err)
+		redirectOAuthError(w, r, "Failed to log in")
+		return
+	}
+
+	// Accounts with a second factor still need it
+	if user.MFAEnabled {
+		mfaToken, err := h.authService.GenerateMFAToken(user.ID)
+		if err != nil {
+			redirectOAuthError(w, r, "Failed to log in")
+			return
+		}
+		done.Set("mfaToken", mfaToken)
+		redirectToApp(w, r, done)
+		return
+	}
+
+	// Replace the session the request came from, usually a guest one
+	h.endAuthSession(r)
+
+	if _, err := h.startAuthSession(w, r, *user); err != nil {
+		redirectOAuthError(w, r, "Failed to log in")
+		return
+	}
+	redirectToApp(w, r, done)
+}
+
+// linkIdentity links an identity to the logged-in user who started the flow.
+// It returns what went wrong, for the user, or "" once linked.
+func (h *Handler) linkIdentity(r *http.Request, provider *auth.OAuthProvider, identity *auth.Identity, userID string) string {
+	userCtx, ok := auth.GetUserFromContext(r.Context())
+	if !ok || userCtx.UserID != userID {
+		return "Log in again to link your " + provider.Name + " account"
+	}
+
+	existing, err := h.db.GetUserIdentity(identity.Provider, identity.Subject)
+	if err == nil {
+		if existing.UserID != userID {
+			return "This " + provider.Name + " account is linked to another user"
+		}
+		return ""
+	}
+	if err != sql.ErrNoRows {
+		return "Failed to link account"
+	}
+
+	linked, err := h.db.ListUserIdentities(userID)
+	if err != nil {
+		return "Failed to link account"
+	}
+	for _, l := range linked {
+		if l.Provider == provider.Name {
+			return "Unlink your other " + provider.Name + " account first"
+		}
+	}
+
+	now := time.Now().UTC()
+	if err := h.db.CreateUserIdentity(newUserIdentity(identity, userID, now)); err != nil {
+		fmt.Printf("Error linking %s identity to user %s: %v\n", provider.Name, userID, err)
+		return "Failed to link account"
+	}
+	h.verifyGitHubUsername(provider, identity, userID, now)
+	return ""
+}
+
+// oauthUser returns the account an identity logs in to, registering one, or
+// converting the guest account guestID, the first time the identity is seen
+func (h *Handler) oauthUser(provider *auth.OAuthProvider, identity *auth.Identity, guestID string) (*models.User, error) {
+	now := time.Now().UTC()
+
+	existing, err := h.db.GetUserIdentity(identity.Provider, identity.Subject)
+	if err == nil {
+		h.verifyGitHubUsername(provider, identity, existing.UserID, now)
+		return h.db.GetUserByID(existing.UserID)
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	username, err := h.availableUsername(identity)
+	if err != nil {
+		return nil, err
+	}
+	// Only an address the provider verified may take over an unverified
+	// registration of it
+	email := ""
+	if identity.Email != "" && identity.EmailVerified {
+		if available, err := h.emailAvailable(identity.Email); err == nil && available {
+			email = identity.Email
+		}
+	}
+
+	// Password-less accounts log in with their identities only
+	var user *models.User
+	if guestID != "" {
+		user, err = h.db.ConvertGuestToRegistered(guestID, username, email, "", username, "")
+	}
+	if guestID == "" || err == sql.ErrNoRows {
+		user, err = h.db.CreateRegisteredUser(username, email, "", username, "")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.db.CreateUserIdentity(newUserIdentity(identity, user.ID, now)); err != nil {
+		return nil, err
+	}
+	if email != "" {
+		if err := h.db.MarkEmailVerified(user.ID, now); err != nil {
+			fmt.Printf("Error marking email of user %s verified: %v\n", user.ID, err)
+		}
+	}
+	h.verifyGitHubUsername(provider, identity, user.ID, now)
+
+	user, err = h.db.GetUserByID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	h.awardAutomaticBadges(*user)
+	return user, nil
+}
+
+// verifyGitHubUsername records the username a GitHub login came with as the
+// user's verified GitHub username
+func (h *Handler) verifyGitHubUsername(provider *auth.OAuthProvider, identity *auth.Identity, userID string, now time.Time) {
+	if !provider.GitHub || identity.Username == "" {
+		return
+	}
+	if err := h.db.SetVerifiedGitHubUsername(userID, identity.Username, now); err != nil {
+		fmt.Printf("Error setting GitHub username of user %s: %v\n", userID, err)
+	}
+}
+
+// availableUsername derives an unused, valid username from an identity
+func (h *Handler) availableUsername(identity *auth.Identity) (string, error) {
+	base := identity.Username
+	if base == "" {
+		base, _, _ = strings.Cut(identity.Email, "@")
+	}
+	base = invalidUsernameChars.ReplaceAllString(base, "_")
+	if len(base) > 24 {
+		base = base[:24]
+	}
+	if len(base) < 3 {
+		base = identity.Provider + "_user"
+	}
+
+	candidate := base
+	for i := 2; i < 100; i++ {
+		if validateUsername(candidate) == nil {
+			_, err := h.db.GetUserByUsername(candidate)
+			if err == sql.ErrNoRows {
+				return candidate, nil
+			}
+			if err != nil {
+				return "", err
+			}
+		}
+		candidate = fmt.Sprintf("%s_%d", base, i)
+	}
+	return "", fmt.Errorf("no username available for %q", base)
+}
+
+func newUserIdentity(identity *auth.Identity, userID string, now time.Time) models.UserIdentity {
+	return models.UserIdentity{
+		Provider:  identity.Provider,
+		Subject:   identity.Subject,
+		UserID:    userID,
+		Username:  identity.Username,
+		Email:     identity.Email,
+		CreatedAt: now,
+	}
+}
+
+// ListIdentities lists the identities linked to the current user
+func (h *Handler) ListIdentities(w http.ResponseWriter, r *http.Request) {
+	userCtx, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	identities, err := h.db.ListUserIdentities(userCtx.UserID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list identities")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, identities)
+}
+
+// UnlinkIdentity removes one of the current user's identities, unless it is
+// the only way left to log in
+func (h *Handler) UnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	userCtx, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+	provider := chi.URLParam(r, "provider")
+
+	user, err := h.db.GetUserByID(userCtx.UserID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get user")
+		return
+	}
+	identities, err := h.db.ListUserIdentities(user.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list identities")
+		return
+	}
+	if len(identities) == 1 && identities[0].Provider == provider {
+		if _, err := h.db.GetPasswordHash(user.Username); err == sql.ErrNoRows {
+			respondError(w, http.StatusConflict, "Set a password with a password reset before unlinking your last login method")
+			return
+		} else if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to check password")
+			return
+		}
+	}
+
+	if err := h.db.DeleteUserIdentity(user.ID, provider); err != nil {
+		if err == sql.ErrNoRows {
+			respondError(w, http.StatusNotFound, "Identity not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to unlink identity")
+		return
+	}
+	if p, ok := h.oauthProviders[provider]; !ok || p.GitHub {
+		if err := h.db.ClearGitHubVerification(user.ID); err != nil {
+			fmt.Printf("Error clearing GitHub verification of user %s: %v\n", user.ID, err)
+		}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Identity unlinked"})
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/typing-code-learn/api-go/internal/auth"
+	"github.com/typing-code-learn/api-go/internal/mail"
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+// passwordResetDuration is how long a password reset link stays valid
+const passwordResetDuration = time.Hour
+
+// SetMailer replaces the mailer used for account emails
+func (h *Handler) SetMailer(m mail.Mailer) {
+	h.mailer = m
+}
+
+// appURL returns the web client URL that links in emails point to
+func appURL() string {
+	if u := os.Getenv("APP_URL"); u != "" {
+		return strings.TrimRight(u, "/")
+	}
+	return "http://localhost:4200"
+}
+
+// sendMail delivers a message in the background so responses do not reveal,
+// by their timing, whether an email was sent
+func (h *Handler) sendMail(msg mail.Message) {
+	go func() {
+		if err := h.mailer.Send(msg); err != nil {
+			fmt.Printf("Error sending mail to %s: %v\n", msg.To, err)
+		}
+	}()
+}
+
+// ForgotPassword emails a password reset link. The response is the same
+// whether or not the email belongs to an account.
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20) // 1 MB limit
+
+	var req models.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	req.Email = strings.TrimSpace(req.Email)
+	if req.Email == "" {
+		respondError(w, http.StatusBadRequest, "Email is required")
+		return
+	}
+
+	accepted := map[string]string{"message": "If an account uses that email, a reset link is on its way"}
+
+	user, err := h.db.GetUserByEmail(req.Email)
+	if err != nil || user.IsGuest {
+		respondJSON(w, http.StatusAccepted, accepted)
+		return
+	}
+
+	token, hash, err := auth.NewOneTimeToken()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+	now := time.Now().UTC()
+	err = h.db.CreateUserToken(models.UserToken{
+		Hash:      hash,
+		UserID:    user.ID,
+		Purpose:   models.TokenPurposePasswordReset,
+		CreatedAt: now,
+		ExpiresAt: now.Add(passwordResetDuration),
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create reset token")
+		return
+	}
+
+	link := appURL() + "/reset-password?token=" + url.QueryEscape(token)
+	h.sendMail(mail.Message{
+		To:      *user.Email,
+		Subject: "Reset your Typer password",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Someone asked to reset the password of your Typer account. "+
+			"Open this link within %d minutes to choose a new one:\n\n%s\n\n"+
+			"If it wasn't you, ignore this email and your password stays the same.\n",
+			user.Username, int(passwordResetDuration.Minutes()), link),
+	})
+
+	respondJSON(w, http.StatusAccepted, accepted)
+}
+
+// ResetPassword sets a new password using a reset token and logs the user out
+// of every session
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20) // 1 MB limit
+
+	var req models.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Token == "" {
+		respondError(w, http.StatusBadRequest, "Reset token is required")
+		return
+	}
+	if err := auth.ValidatePassword(req.Password); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	passwordHash, err := h.authService.HashPassword(req.Password)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to process password")
+		return
+	}
+
+	token, err := h.db.ConsumeUserToken(auth.HashToken(req.Token), models.TokenPurposePasswordReset, time.Now().UTC())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondError(w, http.StatusBadRequest, "Invalid or expired reset token")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to check reset token")
+		return
+	}
+
+	if err := h.db.SetPasswordHash(token.UserID, passwordHash); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update password")
+		return
+	}
+	if err := h.db.ResetFailedLogins(token.UserID); err != nil {
+		fmt.Printf("Error resetting failed logins for user %s: %v\n", token.UserID, err)
+	}
+	if _, err := h.revokeAccess(token.UserID); err != nil {
+		fmt.Printf("Error revoking sessions and tokens of user %s: %v\n", token.UserID, err)
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Password updated, please log in again"})
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/typing-code-learn/api-go/internal/auth"
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+// Limits on personal access tokens
+const (
+	maxPersonalTokens    = 20
+	maxPersonalTokenName = 100
+	maxPersonalTokenDays = 366
+)
+
+// ListPersonalTokens lists the current user's personal access tokens
+func (h *Handler) ListPersonalTokens(w http.ResponseWriter, r *http.Request) {
+	userCtx, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	tokens, err := h.db.ListPersonalAccessTokens(userCtx.UserID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list tokens")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, tokens)
+}
+
+// CreatePersonalToken creates a personal access token for the current user.
+// The response is the only time the token is shown.
+func (h *Handler) CreatePersonalToken(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20) // 1 MB limit
+
+	userCtx, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+	if userCtx.IsGuest {
+		respondError(w, http.StatusForbidden, "Register to create access tokens")
+		return
+	}
+
+	var req models.CreatePersonalTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > maxPersonalTokenName {
+		respondError(w, http.StatusBadRequest, "Name must be 1-100 characters")
+		return
+	}
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > maxPersonalTokenDays {
+		respondError(w, http.StatusBadRequest, "expiresInDays must be between 0 (no expiry) and 366")
+		return
+	}
+	scopes, err := auth.NormalizeScopes(req.Scopes)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	existing, err := h.db.ListPersonalAccessTokens(userCtx.UserID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to list tokens")
+		return
+	}
+	if len(existing) >= maxPersonalTokens {
+		respondError(w, http.StatusConflict, "Too many access tokens; revoke one first")
+		return
+	}
+
+	token, hash, err := auth.NewPersonalToken()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+	now := time.Now().UTC()
+	pat := models.PersonalAccessToken{
+		ID:        uuid.New().String(),
+		UserID:    userCtx.UserID,
+		Name:      req.Name,
+		Hash:      hash,
+		Scopes:    scopes,
+		CreatedAt: now,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := now.AddDate(0, 0, req.ExpiresInDays)
+		pat.ExpiresAt = &expiresAt
+	}
+	if err := h.db.CreatePersonalAccessToken(pat); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save token")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, models.CreatePersonalTokenResponse{PersonalAccessToken: pat, Token: token})
+}
+
+// RevokePersonalToken deletes one of the current user's personal access
+// tokens
+func (h *Handler) RevokePersonalToken(w http.ResponseWriter, r *http.Request) {
+	userCtx, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	if err := h.db.DeletePersonalAccessToken(userCtx.UserID, chi.URLParam(r, "id")); err != nil {
+		if err == sql.ErrNoRows {
+			respondError(w, http.StatusNotFound, "Token not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to revoke token")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Token revoked"})
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/typing-code-learn/api-go/internal/auth"
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+// createToken creates a personal access token that may read the profile
+func (a *testAPI) createToken(session models.AuthResponse) string {
+	a.t.Helper()
+	var created models.CreatePersonalTokenResponse
+	a.expect(a.do("POST", "/api/v1/auth/tokens", session.Token, models.CreatePersonalTokenRequest{
+		Name: "script", Scopes: []string{auth.ScopeReadProfile},
+	}), http.StatusCreated, &created)
+	return created.Token
+}
+
+// admin registers an account with the admin role and logs it in
+func (a *testAPI) admin() models.AuthResponse {
+	a.t.Helper()
+	registered := a.register("root")
+	if err := a.db.SetUserRole(registered.User.ID, models.RoleAdmin); err != nil {
+		a.t.Fatal(err)
+	}
+	var login models.AuthResponse
+	a.expect(a.do("POST", "/api/v1/auth/login", "", models.LoginRequest{Username: "root", Password: testPassword}), http.StatusOK, &login)
+	return login
+}
+
+func TestPasswordResetRevokesPersonalTokens(t *testing.T) {
+	api := newTestAPI(t)
+	ada := api.register("ada")
+	pat := api.createToken(ada)
+	api.expect(api.do("GET", "/api/v1/auth/me", pat, nil), http.StatusOK, nil)
+
+	api.expect(api.do("POST", "/api/v1/auth/password/forgot", "", models.ForgotPasswordRequest{Email: "ada@example.com"}), http.StatusAccepted, nil)
+	msg := api.outbox.wait(t, "ada@example.com", "Reset your Typer password")
+	link := strings.Fields(msg.Body[strings.Index(msg.Body, "http"):])[0]
+	resetURL, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("invalid reset link %q: %v", link, err)
+	}
+
+	api.expect(api.do("POST", "/api/v1/auth/password/reset", "", models.ResetPasswordRequest{
+		Token: resetURL.Query().Get("token"), Password: "NewPassword456",
+	}), http.StatusOK, nil)
+
+	api.expect(api.do("GET", "/api/v1/auth/me", ada.Token, nil), http.StatusUnauthorized, nil)
+	api.expect(api.do("GET", "/api/v1/auth/me", pat, nil), http.StatusUnauthorized, nil)
+}
+
+func TestAdminRevokeSessionsRevokesPersonalTokens(t *testing.T) {
+	api := newTestAPI(t)
+	root := api.admin()
+	ada := api.register("ada")
+	grace := api.register("grace")
+	adaPAT := api.createToken(ada)
+	gracePAT := api.createToken(grace)
+
+	api.expect(api.do("DELETE", "/api/v1/users/"+ada.User.ID+"/sessions", ada.Token, nil), http.StatusForbidden, nil)
+
+	var revoked map[string]int
+	api.expect(api.do("DELETE", "/api/v1/users/"+ada.User.ID+"/sessions", root.Token, nil), http.StatusOK, &revoked)
+	if revoked["revoked"] != 1 {
+		t.Errorf("got %d sessions revoked, want 1", revoked["revoked"])
+	}
+
+	api.expect(api.do("GET", "/api/v1/auth/me", ada.Token, nil), http.StatusUnauthorized, nil)
+	api.expect(api.do("GET", "/api/v1/auth/me", adaPAT, nil), http.StatusUnauthorized, nil)
+	// Other users keep theirs
+	api.expect(api.do("GET", "/api/v1/auth/me", grace.Token, nil), http.StatusOK, nil)
+	api.expect(api.do("GET", "/api/v1/auth/me", gracePAT, nil), http.StatusOK, nil)
+}
+
+func TestResetUserMFARevokesPersonalTokens(t *testing.T) {
+	api := newTestAPI(t)
+	root := api.admin()
+	ada := api.register("ada")
+	pat := api.createToken(ada)
+
+	api.expect(api.do("DELETE", "/api/v1/users/"+ada.User.ID+"/mfa", root.Token, nil), http.StatusOK, nil)
+
+	api.expect(api.do("GET", "/api/v1/auth/me", ada.Token, nil), http.StatusUnauthorized, nil)
+	api.expect(api.do("GET", "/api/v1/auth/me", pat, nil), http.StatusUnauthorized, nil)
+}
+
+func TestLoggingOutOtherSessionsKeepsPersonalTokens(t *testing.T) {
+	api := newTestAPI(t)
+	ada := api.register("ada")
+	pat := api.createToken(ada)
+	var other models.AuthResponse
+	api.expect(api.do("POST", "/api/v1/auth/login", "", models.LoginRequest{Username: "ada", Password: testPassword}), http.StatusOK, &other)
+
+	api.expect(api.do("DELETE", "/api/v1/auth/sessions", ada.Token, nil), http.StatusOK, nil)
+
+	api.expect(api.do("GET", "/api/v1/auth/me", other.Token, nil), http.StatusUnauthorized, nil)
+	api.expect(api.do("GET", "/api/v1/auth/me", ada.Token, nil), http.StatusOK, nil)
+	api.expect(api.do("GET", "/api/v1/auth/me", pat, nil), http.StatusOK, nil)
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/typing-code-learn/api-go/internal/auth"
+	"github.com/typing-code-learn/api-go/internal/engine"
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+// sessionClockSkew tolerates small differences between the client's timings
+// and the server clock when checking a keystroke log against the session
+const sessionClockSkew = 2 * time.Second
+
+// StartSession issues a signed typing session for a lesson
+func (h *Handler) StartSession(w http.ResponseWriter, r *http.Request) {
+	userCtx, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	var req models.StartSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if _, ok := h.lessonStore.Get(req.LessonID); !ok {
+		respondError(w, http.StatusNotFound, "Lesson not found")
+		return
+	}
+	if !h.allowLesson(w, userCtx.UserID, req.LessonID) {
+		return
+	}
+
+	session, err := h.db.CreateTypingSession(userCtx.UserID, req.LessonID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to start session")
+		return
+	}
+
+	token, err := h.authService.GenerateTypingSessionToken(*session)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to sign session")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, models.StartSessionResponse{
+		Session: *session,
+		Token:   token,
+	})
+}
+
+// FinishSession replays the submitted keystroke log against the lesson and
+// records the metrics, progress and points computed by the server
+func (h *Handler) FinishSession(w http.ResponseWriter, r *http.Request) {
+	userCtx, ok := auth.GetUserFromContext(r.Context())
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+	sessionID := chi.URLParam(r, "id")
+	now := time.Now().UTC()
+
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20) // 1 MB limit
+
+	var req models.FinishSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	claims, err := h.authService.ValidateTypingSessionToken(req.Token)
+	if err != nil || claims.ID != sessionID || claims.UserID != userCtx.UserID {
+		respondError(w, http.StatusForbidden, "Invalid session token")
+		return
+	}
+
+	session, err := h.db.GetTypingSession(sessionID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondError(w, http.StatusNotFound, "Session not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to get session")
+		return
+	}
+	if session.UserID != userCtx.UserID || session.LessonID != claims.LessonID {
+		respondError(w, http.StatusForbidden, "Invalid session token")
+		return
+	}
+	if session.FinishedAt != nil {
+		respondError(w, http.StatusConflict, "Session already finished")
+		return
+	}
+
+	lesson, ok := h.lessonStore.Get(session.LessonID)
+	if !ok {
+		respondError(w, http.StatusNotFound, "Lesson not found")
+		return
+	}
+
+	keystrokes, err := engine.DecodeLog(req.Keystrokes)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(keystrokes) > 0 && keystrokes[len(keystrokes)-1].At > now.Sub(session.StartedAt)+sessionClockSkew {
+		respondError(w, http.StatusUnprocessableEntity, "Keystroke log is longer than the session")
+		return
+	}
+
+	result := engine.Replay(lesson, keystrokes)
+	if !result.Finished {
+		respondError(w, http.StatusUnprocessableEntity, "Keystroke log does not complete the lesson")
+		return
+	}
+
+	// Claim the session before recording anything so it is only scored once
+	if err := h.db.FinishTypingSession(sessionID, now); err != nil {
+		if err == sql.ErrNoRows {
+			respondError(w, http.StatusConflict, "Session already finished")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to finish session")
+		return
+	}
+
+	m := result.Metrics
+	metricsReq := models.MetricsRequest{
+		UserID:         userCtx.UserID,
+		LessonID:       lesson.ID,
+		WPM:            m.WPM,
+		Accuracy:       m.Accuracy,
+		TotalTime:      m.TotalTime,
+		TotalChars:     m.TotalChars,
+		CorrectChars:   m.CorrectChars,
+		IncorrectChars: m.IncorrectChars,
+		CommonErrors:   m.CommonErrors,
+		SessionID:      sessionID,
+	}
+	// Replayed numbers are consistent by construction, but the typing speed
+	// itself can still be implausible
+	if !h.screenMetrics(&metricsReq) {
+		respondError(w, http.StatusUnprocessableEntity, "Metrics are not plausible")
+		return
+	}
+
+	metrics, err := h.db.SaveMetrics(metricsReq)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save metrics")
+		return
+	}
+
+	_, err = h.db.SaveProgress(models.ProgressRequest{
+		UserID:    userCtx.UserID,
+		LessonID:  lesson.ID,
+		WPM:       m.WPM,
+		Accuracy:  m.Accuracy,
+		Completed: true,
+	})
+	if err != nil {
+		fmt.Printf("Error saving progress for session %s: %v\n", sessionID, err)
+	}
+
+	points := h.awardPoints(*metrics)
+	tierUps, tierBadges := h.promote(metrics.UserID)
+
+	streak, err := h.db.UpdateUserStreak(metrics.UserID)
+	if err != nil {
+		fmt.Printf("Error updating streak for user %s: %v\n", metrics.UserID, err)
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"metrics":       metrics,
+		"pointsEarned":  points,
+		"currentStreak": streak,
+		"tierUps":       tierUps,
+		"badgesEarned":  append(tierBadges, h.awardAchievements(metrics.UserID)...),
+	})
+}
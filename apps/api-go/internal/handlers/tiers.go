@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/typing-code-learn/api-go/internal/gamification"
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+// SetTiers sets the engine that moves users up the tier ladder
+func (h *Handler) SetTiers(p *gamification.Promotions) {
+	h.tiers = p
+}
+
+// promote records a user's tier promotions and grants their tier badges,
+// returning both. Failures are logged and never fail a request.
+func (h *Handler) promote(userID string) ([]models.TierEvent, []models.Badge) {
+	events, badges, err := h.tiers.Promote(userID)
+	if err != nil {
+		fmt.Printf("Error promoting user %s: %v\n", userID, err)
+	}
+	if events == nil {
+		events = []models.TierEvent{}
+	}
+	if badges == nil {
+		badges = []models.Badge{}
+	}
+	return events, badges
+}
+
+// tierProgress returns where a user stands on the tier ladder, or nil if
+// their points can't be read
+func (h *Handler) tierProgress(userID string) *models.TierProgress {
+	progress, err := h.tiers.Progress(userID)
+	if err != nil {
+		fmt.Printf("Error getting tier of user %s: %v\n", userID, err)
+		return nil
+	}
+	return progress
+}
+
+// reachedTiers returns the tiers a user has reached; anonymous users only
+// have the first one
+func (h *Handler) reachedTiers(userID string) (gamification.Tiers, error) {
+	if userID == "" {
+		return h.tiers.Tiers()[:1], nil
+	}
+	return h.tiers.Reached(userID)
+}
+
+// lockedBy returns the tier a user still has to reach to open a lesson; ok
+// is false when the lesson is open to them
+func (h *Handler) lockedBy(userID, lessonID string) (tier gamification.Tier, ok bool, err error) {
+	tier, restricted := h.tiers.Tiers().RequiredTier(lessonID)
+	if !restricted {
+		return tier, false, nil
+	}
+	reached, err := h.reachedTiers(userID)
+	if err != nil {
+		return tier, false, err
+	}
+	return tier, !reached.Includes(tier.Name), nil
+}
+
+// allowLesson reports whether a user may open a lesson, responding with an
+// error when they may not
+func (h *Handler) allowLesson(w http.ResponseWriter, userID, lessonID string) bool {
+	tier, locked, err := h.lockedBy(userID, lessonID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to get tier")
+		return false
+	}
+	if locked {
+		respondError(w, http.StatusForbidden, fmt.Sprintf("Reach the %s tier to unlock this lesson", tier.Name))
+		return false
+	}
+	return true
+}
+
+// unlockedLessons leaves out the lessons a user still has to reach a tier
+// for. Their tiers are only read once a restricted lesson comes up.
+func (h *Handler) unlockedLessons(userID string, lessons []*models.Lesson) ([]*models.Lesson, error) {
+	var reached gamification.Tiers
+	unlocked := make([]*models.Lesson, 0, len(lessons))
+	for _, l := range lessons {
+		if tier, restricted := h.tiers.Tiers().RequiredTier(l.ID); restricted {
+			if reached == nil {
+				var err error
+				if reached, err = h.reachedTiers(userID); err != nil {
+					return nil, err
+				}
+			}
+			if !reached.Includes(tier.Name) {
+				continue
+			}
+		}
+		unlocked = append(unlocked, l)
+	}
+	return unlocked, nil
+}
+
+// GetTiers lists the tier ladder with the points, badge and lessons of each
+// tier. Hidden badges are left out for everyone but admins.
+func (h *Handler) GetTiers(w http.ResponseWriter, r *http.Request) {
+	tiers := append(gamification.Tiers(nil), h.tiers.Tiers()...)
+	if !isAdmin(r) {
+		for i, tier := range tiers {
+			if tier.Badge != nil && tier.Badge.Hidden {
+				tiers[i].Badge = nil
+			}
+		}
+	}
+
+	respondJSON(w, http.StatusOK, tiers)
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/typing-code-learn/api-go/internal/gamification"
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+func TestTierLockedLessons(t *testing.T) {
+	api := newTestAPI(t)
+	locked := models.Lesson{
+		ID: "go-advanced-01", Title: "Canales", Language: "go", Level: "advanced",
+		Code: "ch", Mode: "strict", Difficulty: "advanced", Order: 2,
+	}
+	api.lessons.Add(&locked)
+	api.h.SetTiers(gamification.NewPromotions(api.db, gamification.Tiers{
+		{Name: gamification.TierNovice, MinPoints: 0},
+		{Name: gamification.TierApprentice, MinPoints: 100, Lessons: []string{locked.ID}},
+	}))
+	ada := api.register("ada")
+
+	// expectLessons checks which lessons a user is shown
+	expectLessons := func(token string, want ...string) {
+		t.Helper()
+		for _, path := range []string{"/api/v1/lessons", "/api/v1/lessons/language/go"} {
+			var summaries []models.LessonSummary
+			api.expect(api.do("GET", path, token, nil), http.StatusOK, &summaries)
+			var got []string
+			for _, s := range summaries {
+				got = append(got, s.ID)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("%s: got lessons %v, want %v", path, got, want)
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Fatalf("%s: got lessons %v, want %v", path, got, want)
+				}
+			}
+		}
+	}
+
+	progress := models.ProgressRequest{LessonID: locked.ID, WPM: 24, Accuracy: 100, Completed: true}
+	metrics := models.MetricsRequest{
+		LessonID: locked.ID, WPM: 24, Accuracy: 100, TotalTime: 1, TotalChars: 2, CorrectChars: 2,
+	}
+
+	// Anonymous users and users below the tier can't see or use the lesson
+	expectLessons("", testLesson.ID)
+	api.expect(api.do("GET", "/api/v1/lessons/"+locked.ID, "", nil), http.StatusForbidden, nil)
+	expectLessons(ada.Token, testLesson.ID)
+	api.expect(api.do("GET", "/api/v1/lessons/"+locked.ID, ada.Token, nil), http.StatusForbidden, nil)
+	api.expect(api.do("POST", "/api/v1/progress", ada.Token, progress), http.StatusForbidden, nil)
+	api.expect(api.do("POST", "/api/v1/metrics", ada.Token, metrics), http.StatusForbidden, nil)
+	api.expect(api.do("POST", "/api/v1/sessions", ada.Token, models.StartSessionRequest{LessonID: locked.ID}), http.StatusForbidden, nil)
+
+	// Open lessons are unaffected
+	api.expect(api.do("GET", "/api/v1/lessons/"+testLesson.ID, ada.Token, nil), http.StatusOK, nil)
+	api.expect(api.do("POST", "/api/v1/progress", ada.Token, models.ProgressRequest{LessonID: testLesson.ID}), http.StatusOK, nil)
+
+	givePoints := func(userID string, points int) {
+		t.Helper()
+		if err := api.db.SavePointTransaction(models.PointTransaction{
+			ID: uuid.New().String(), UserID: userID, SourceID: testLesson.ID,
+			Points: points, Reason: gamification.ReasonLessonComplete, CreatedAt: time.Now().Add(-time.Minute),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	givePoints(ada.User.ID, 100)
+	if events, _ := api.h.promote(ada.User.ID); len(events) != 1 {
+		t.Fatalf("got promotions %v, want one", events)
+	}
+
+	expectLessons(ada.Token, testLesson.ID, locked.ID)
+	api.expect(api.do("GET", "/api/v1/lessons/"+locked.ID, ada.Token, nil), http.StatusOK, nil)
+	api.expect(api.do("POST", "/api/v1/progress", ada.Token, progress), http.StatusOK, nil)
+	api.expect(api.do("POST", "/api/v1/metrics", ada.Token, metrics), http.StatusOK, nil)
+	api.expect(api.do("POST", "/api/v1/sessions", ada.Token, models.StartSessionRequest{LessonID: locked.ID}), http.StatusCreated, nil)
+
+	// Losing points, e.g. to a recompute, doesn't lock the tier again
+	givePoints(ada.User.ID, -50)
+	expectLessons(ada.Token, testLesson.ID, locked.ID)
+	api.expect(api.do("GET", "/api/v1/lessons/"+locked.ID, ada.Token, nil), http.StatusOK, nil)
+
+	// Other users still can't
+	grace := api.register("grace")
+	expectLessons(grace.Token, testLesson.ID)
+	api.expect(api.do("GET", "/api/v1/lessons/"+locked.ID, grace.Token, nil), http.StatusForbidden, nil)
+}
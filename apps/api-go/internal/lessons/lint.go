@@ -0,0 +1,142 @@
+package lessons
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Authoring rules checked by Lint on top of the load-time validation
+const (
+	RuleHiddenMarkers = "hidden-markers"
+	RuleTranslation   = "translation"
+	RuleIndentation   = "indentation"
+	RuleOrphanedFile  = "orphaned-file"
+)
+
+// Lint runs the same validation as LoadLessons plus authoring checks that do
+// not prevent a lesson from being served: [[hidden]] marker balance, missing
+// English translations, mixed tab/space indentation and orphaned files.
+// It never touches a database and is meant for CI and local authoring.
+func Lint(contentDir string, schema *Schema) (*Report, error) {
+	candidates, err := collectCandidates(contentDir)
+	if err != nil {
+		return nil, err
+	}
+
+	validateCandidates(candidates, schema)
+
+	report := &Report{}
+	for _, c := range candidates {
+		if c.lesson != nil {
+			lintHiddenMarkers(c)
+			lintTranslations(c)
+			lintIndentation(c)
+		}
+		if c.dir != "" {
+			lintOrphanedFiles(c)
+		}
+		report.Issues = append(report.Issues, c.issues...)
+	}
+	return report, nil
+}
+
+// lintHiddenMarkers checks that every [[ is closed by a matching ]] on the
+// same line and that markers are neither nested nor empty
+func lintHiddenMarkers(c *candidate) {
+	code := c.lesson.Code
+	open := -1
+	line := 1
+
+	for i := 0; i < len(code); i++ {
+		switch {
+		case code[i] == '\n':
+			if open >= 0 {
+				// The frontend marker pattern does not match across lines
+				c.addIssue(RuleHiddenMarkers, "line %d: [[ marker is not closed on the same line", line)
+				open = -1
+			}
+			line++
+		case strings.HasPrefix(code[i:], "[["):
+			if open >= 0 {
+				c.addIssue(RuleHiddenMarkers, "line %d: nested [[ inside an open marker", line)
+			}
+			open = i
+			i++
+		case strings.HasPrefix(code[i:], "]]"):
+			if open < 0 {
+				c.addIssue(RuleHiddenMarkers, "line %d: ]] without a matching [[", line)
+			} else if i == open+2 {
+				c.addIssue(RuleHiddenMarkers, "line %d: empty [[]] marker", line)
+			}
+			open = -1
+			i++
+		}
+	}
+
+	if open >= 0 {
+		c.addIssue(RuleHiddenMarkers, "unclosed [[ marker")
+	}
+}
+
+// lintTranslations checks that every localized field has an _en counterpart
+func lintTranslations(c *candidate) {
+	l := c.lesson
+	if l.Title != "" && l.TitleEn == "" {
+		c.addIssue(RuleTranslation, "missing title_en")
+	}
+	if l.Description != "" && l.DescriptionEn == "" {
+		c.addIssue(RuleTranslation, "missing description_en")
+	}
+	if len(l.Explanation) > 0 && len(l.ExplanationEn) == 0 {
+		c.addIssue(RuleTranslation, "missing explanation_en")
+	} else if len(l.Explanation) != len(l.ExplanationEn) {
+		c.addIssue(RuleTranslation, "explanation has %d entries but explanation_en has %d", len(l.Explanation), len(l.ExplanationEn))
+	}
+}
+
+// lintIndentation flags code that indents some lines with tabs and others
+// with spaces, which makes the expected keystrokes ambiguous for learners
+func lintIndentation(c *candidate) {
+	var tabLines, spaceLines []int
+
+	for i, line := range strings.Split(c.lesson.Code, "\n") {
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if indent == "" || strings.TrimSpace(line) == "" {
+			continue
+		}
+		hasTab := strings.Contains(indent, "\t")
+		hasSpace := strings.Contains(indent, " ")
+		switch {
+		case hasTab && hasSpace:
+			c.addIssue(RuleIndentation, "line %d mixes tabs and spaces in its indentation", i+1)
+		case hasTab:
+			tabLines = append(tabLines, i+1)
+		case hasSpace:
+			spaceLines = append(spaceLines, i+1)
+		}
+	}
+
+	if len(tabLines) > 0 && len(spaceLines) > 0 {
+		c.addIssue(RuleIndentation, "%d line(s) indented with tabs (first: line %d) and %d with spaces (first: line %d)",
+			len(tabLines), tabLines[0], len(spaceLines), spaceLines[0])
+	}
+}
+
+// lintOrphanedFiles reports files in a lesson directory that are neither the
+// lesson definition nor the code file it was loaded from
+func lintOrphanedFiles(c *candidate) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		c.addIssue(RuleRead, "failed to list lesson directory: %v", err)
+		return
+	}
+
+	for _, e := range entries {
+		path := filepath.Join(c.dir, e.Name())
+		if e.Name() == "main.json" || path == c.codePath {
+			continue
+		}
+		c.addIssue(RuleOrphanedFile, "%s is not used by the lesson", e.Name())
+	}
+}
@@ -1,13 +1,12 @@
 package lessons
 
 import (
-	"encoding/json"
-	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/typing-code-learn/api-go/internal/models"
 )
@@ -17,6 +16,23 @@ type Store struct {
 	mu      sync.RWMutex
 	lessons map[string]*models.Lesson
 	byLang  map[string][]*models.Lesson
+	sources map[string]*models.Lesson // Loaded lessons by the file they came from
+
+	contentDir string
+	opts       Options
+	status     LoadStatus
+}
+
+// LoadStatus describes the outcome of the most recent content load
+type LoadStatus struct {
+	ContentDir   string    `json:"contentDir"`
+	LessonCount  int       `json:"lessonCount"`
+	LoadedAt     time.Time `json:"loadedAt"`
+	LastAttempt  time.Time `json:"lastAttempt"`
+	LastError    string    `json:"lastError,omitempty"`
+	ReloadCount  int       `json:"reloadCount"`
+	FailureCount int       `json:"failureCount"`
+	Issues       []Issue   `json:"issues,omitempty"`
 }
 
 // NewStore creates a new empty lesson store
@@ -24,12 +40,86 @@ func NewStore() *Store {
 	return &Store{
 		lessons: make(map[string]*models.Lesson),
 		byLang:  make(map[string][]*models.Lesson),
+		sources: make(map[string]*models.Lesson),
+	}
+}
+
+// LoadLessons reads all lesson files from the content directory and validates
+// them according to opts. In strict mode any validation issue aborts the load;
+// in lenient mode offending lessons are skipped and reported in the status.
+func LoadLessons(contentDir string, opts Options) (*Store, error) {
+	store, report, err := loadStore(contentDir, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	store.contentDir = contentDir
+	store.opts = opts
+	store.status = LoadStatus{
+		ContentDir:  contentDir,
+		LessonCount: len(store.lessons),
+		LoadedAt:    now,
+		LastAttempt: now,
+		Issues:      report.Issues,
 	}
+	return store, nil
 }
 
-// LoadLessons reads all lesson files from the content directory
-func LoadLessons(contentDir string) (*Store, error) {
+// loadStore walks the content directory and builds a fresh, fully sorted store.
+// The returned report lists every validation issue found, including those of
+// lessons that were skipped in lenient mode. previous maps files to the lessons
+// they held in the last snapshot; in lenient mode a file that now has issues
+// keeps that lesson instead of disappearing, as long as its id is still free.
+func loadStore(contentDir string, opts Options, previous map[string]*models.Lesson) (*Store, *Report, error) {
+	candidates, err := collectCandidates(contentDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	report := &Report{}
+
+	validateCandidates(candidates, opts.Schema)
+
 	store := NewStore()
+	var broken []*candidate
+	for _, c := range candidates {
+		report.Issues = append(report.Issues, c.issues...)
+		if len(c.issues) == 0 {
+			store.Add(c.lesson)
+			store.sources[c.path] = c.lesson
+		} else {
+			broken = append(broken, c)
+		}
+	}
+
+	for _, c := range broken {
+		if lesson, ok := previous[c.path]; ok {
+			if _, taken := store.lessons[lesson.ID]; !taken {
+				store.Add(lesson)
+				store.sources[c.path] = lesson
+			}
+		}
+	}
+
+	if opts.Mode == ModeStrict && len(report.Issues) > 0 {
+		return nil, report, report
+	}
+
+	// Sort lessons by order within each language
+	store.mu.Lock()
+	for lang := range store.byLang {
+		sort.Slice(store.byLang[lang], func(i, j int) bool {
+			return store.byLang[lang][i].Order < store.byLang[lang][j].Order
+		})
+	}
+	store.mu.Unlock()
+
+	return store, report, nil
+}
+
+// collectCandidates walks the content directory and reads every lesson it finds
+func collectCandidates(contentDir string) ([]*candidate, error) {
+	var candidates []*candidate
 
 	err := filepath.Walk(contentDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -41,11 +131,7 @@ func LoadLessons(contentDir string) (*Store, error) {
 			mainPath := filepath.Join(path, "main.json")
 			if _, err := os.Stat(mainPath); err == nil {
 				// This is a directory-based lesson
-				lesson, err := loadDirectoryLesson(path, contentDir)
-				if err != nil {
-					return err
-				}
-				store.Add(lesson)
+				candidates = append(candidates, loadDirectoryLesson(path, contentDir))
 				return filepath.SkipDir // We processed this dir
 			}
 			return nil
@@ -56,37 +142,33 @@ func LoadLessons(contentDir string) (*Store, error) {
 			return nil
 		}
 
-		data, err := os.ReadFile(path)
-		if err != nil {
-			return fmt.Errorf("failed to read %s: %w", path, err)
-		}
-
-		var lesson models.Lesson
-		if err := json.Unmarshal(data, &lesson); err != nil {
-			return fmt.Errorf("failed to parse %s: %w", path, err)
-		}
-
-		// Extract level from path: content/<language>/<level>/file.json
-		lesson.Level = extractLevelFromPath(path, contentDir)
-
-		store.Add(&lesson)
+		candidates = append(candidates, loadFileLesson(path, contentDir))
 		return nil
 	})
 
 	if err != nil && err != filepath.SkipDir {
 		return nil, err
 	}
+	return candidates, nil
+}
 
-	// Sort lessons by order within each language
-	store.mu.Lock()
-	for lang := range store.byLang {
-		sort.Slice(store.byLang[lang], func(i, j int) bool {
-			return store.byLang[lang][i].Order < store.byLang[lang][j].Order
-		})
+// loadFileLesson loads an old-style single JSON lesson file
+func loadFileLesson(path string, contentDir string) *candidate {
+	c := &candidate{path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		c.addIssue(RuleRead, "failed to read file: %v", err)
+		return c
 	}
-	store.mu.Unlock()
 
-	return store, nil
+	if err := c.decode(data); err != nil {
+		return c
+	}
+
+	// Extract level from path: content/<language>/<level>/file.json
+	c.lesson.Level = extractLevelFromPath(path, contentDir)
+	return c
 }
 
 // extractLevelFromPath extracts the level (basic, intermediate, advanced, exercises)
@@ -239,56 +321,61 @@ func (s *Store) GetLanguages() []models.LanguageInfo {
 }
 
 // loadDirectoryLesson loads a lesson from a directory containing main.json and a code file
-func loadDirectoryLesson(dirPath string, contentDir string) (*models.Lesson, error) {
+func loadDirectoryLesson(dirPath string, contentDir string) *candidate {
 	mainPath := filepath.Join(dirPath, "main.json")
+	c := &candidate{path: mainPath, dir: dirPath}
+
 	data, err := os.ReadFile(mainPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read %s: %w", mainPath, err)
+		c.addIssue(RuleRead, "failed to read file: %v", err)
+		return c
 	}
 
-	var lesson models.Lesson
-	if err := json.Unmarshal(data, &lesson); err != nil {
-		return nil, fmt.Errorf("failed to parse %s: %w", mainPath, err)
+	if err := c.decode(data); err != nil {
+		return c
 	}
+	lesson := c.lesson
 
 	// If code is not in JSON, look for a code file
 	if lesson.Code == "" {
-		codePath := ""
-		// Look for files named code.ext or index.ext or matching the language name
-		exts := []string{".go", ".js", ".py", ".ts", ".c", ".cpp", ".cs", ".rb", ".php", ".swift", ".kt"}
-		files, _ := os.ReadDir(dirPath)
-		for _, f := range files {
-			if f.IsDir() || f.Name() == "main.json" {
-				continue
-			}
-			name := strings.ToLower(f.Name())
-			if strings.HasPrefix(name, "code.") || strings.HasPrefix(name, "exercise.") || strings.HasPrefix(name, "index.") {
-				codePath = filepath.Join(dirPath, f.Name())
-				break
-			}
-			// Fallback: any file with a code extension
-			for _, ext := range exts {
-				if strings.HasSuffix(name, ext) {
-					codePath = filepath.Join(dirPath, f.Name())
-					break
-				}
-			}
-			if codePath != "" {
-				break
-			}
-		}
-
-		if codePath != "" {
+		if codePath := findCodeFile(dirPath); codePath != "" {
+			c.codePath = codePath
 			codeData, err := os.ReadFile(codePath)
 			if err != nil {
-				return nil, fmt.Errorf("failed to read code file %s: %w", codePath, err)
+				c.addIssue(RuleRead, "failed to read code file %s: %v", codePath, err)
+				return c
 			}
 			lesson.Code = string(codeData)
+			c.doc["code"] = lesson.Code
 		}
 	}
 
 	// Extract level from directory path
 	lesson.Level = extractLevelFromPath(dirPath, contentDir)
 
-	return &lesson, nil
+	return c
+}
+
+// findCodeFile returns the path of the code file inside a lesson directory,
+// or an empty string if there is none
+func findCodeFile(dirPath string) string {
+	// Look for files named code.ext or index.ext or matching the language name
+	exts := []string{".go", ".js", ".py", ".ts", ".c", ".cpp", ".cs", ".rb", ".php", ".swift", ".kt"}
+	files, _ := os.ReadDir(dirPath)
+	for _, f := range files {
+		if f.IsDir() || f.Name() == "main.json" {
+			continue
+		}
+		name := strings.ToLower(f.Name())
+		if strings.HasPrefix(name, "code.") || strings.HasPrefix(name, "exercise.") || strings.HasPrefix(name, "index.") {
+			return filepath.Join(dirPath, f.Name())
+		}
+		// Fallback: any file with a code extension
+		for _, ext := range exts {
+			if strings.HasSuffix(name, ext) {
+				return filepath.Join(dirPath, f.Name())
+			}
+		}
+	}
+	return ""
 }
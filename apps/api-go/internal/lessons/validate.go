@@ -0,0 +1,189 @@
+package lessons
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/typing-code-learn/api-go/internal/engine"
+	"github.com/typing-code-learn/api-go/internal/models"
+)
+
+// ValidationMode decides what happens when lesson content fails validation
+type ValidationMode string
+
+const (
+	// ModeStrict refuses to load content that has any validation issue
+	ModeStrict ValidationMode = "strict"
+	// ModeLenient skips invalid lessons and loads the rest
+	ModeLenient ValidationMode = "lenient"
+)
+
+// ParseValidationMode converts a configuration value into a ValidationMode
+func ParseValidationMode(value string) (ValidationMode, error) {
+	switch ValidationMode(strings.ToLower(strings.TrimSpace(value))) {
+	case ModeStrict:
+		return ModeStrict, nil
+	case ModeLenient:
+		return ModeLenient, nil
+	default:
+		return "", fmt.Errorf("unknown validation mode %q (expected strict or lenient)", value)
+	}
+}
+
+// Options controls how lesson content is validated while loading
+type Options struct {
+	Schema *Schema // Optional; when nil only the built-in rules run
+	Mode   ValidationMode
+}
+
+// Rules that produce validation issues
+const (
+	RuleRead        = "read"
+	RuleParse       = "parse"
+	RuleSchema      = "schema"
+	RuleExclude     = "exclude"
+	RuleDuplicateID = "duplicate-id"
+	RuleOrder       = "order"
+)
+
+// Issue is a single validation problem found in a lesson file
+type Issue struct {
+	Path     string `json:"path"`
+	LessonID string `json:"lessonId,omitempty"`
+	Rule     string `json:"rule"`
+	Message  string `json:"message"`
+}
+
+func (i Issue) String() string {
+	if i.LessonID != "" {
+		return fmt.Sprintf("%s (%s) [%s]: %s", i.Path, i.LessonID, i.Rule, i.Message)
+	}
+	return fmt.Sprintf("%s [%s]: %s", i.Path, i.Rule, i.Message)
+}
+
+// Report aggregates every issue found while loading a content directory
+type Report struct {
+	Issues []Issue `json:"issues"`
+}
+
+// Error implements error so a failed strict load can be returned directly
+func (r *Report) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d lesson validation issue(s)", len(r.Issues))
+	for _, issue := range r.Issues {
+		b.WriteString("\n  - ")
+		b.WriteString(issue.String())
+	}
+	return b.String()
+}
+
+// candidate is a lesson read from disk that has not been accepted into a store yet
+type candidate struct {
+	path     string
+	dir      string // Lesson directory; empty for single-file lessons
+	codePath string // Code file the lesson code was read from, if any
+	doc      map[string]interface{}
+	lesson   *models.Lesson
+	issues   []Issue
+}
+
+func (c *candidate) addIssue(rule, format string, args ...interface{}) {
+	issue := Issue{Path: c.path, Rule: rule, Message: fmt.Sprintf(format, args...)}
+	if c.lesson != nil {
+		issue.LessonID = c.lesson.ID
+	}
+	c.issues = append(c.issues, issue)
+}
+
+// decode parses the raw lesson JSON into both a generic document (for schema
+// validation) and a models.Lesson
+func (c *candidate) decode(data []byte) error {
+	if err := json.Unmarshal(data, &c.doc); err != nil {
+		c.addIssue(RuleParse, "failed to parse: %v", err)
+		return err
+	}
+
+	var lesson models.Lesson
+	if err := json.Unmarshal(data, &lesson); err != nil {
+		c.addIssue(RuleParse, "failed to parse: %v", err)
+		return err
+	}
+	c.lesson = &lesson
+	return nil
+}
+
+// validateCandidates runs the schema and cross-lesson rules, attaching any
+// issues to the offending candidates. Cross-lesson rules consider every lesson
+// that parsed, including ones that already have issues, so a single pass
+// reports every collision; when two lessons collide, the first one in walk
+// order keeps the id or order and the later one is reported.
+func validateCandidates(candidates []*candidate, schema *Schema) {
+	for _, c := range candidates {
+		if c.lesson == nil {
+			continue
+		}
+
+		if schema != nil {
+			for _, msg := range schema.Validate(c.doc) {
+				c.addIssue(RuleSchema, "%s", msg)
+			}
+		}
+
+		for _, word := range missingExcludes(c.lesson) {
+			c.addIssue(RuleExclude, "exclude word %q does not hide anything in code", word)
+		}
+	}
+
+	seenIDs := make(map[string]string)
+	seenOrders := make(map[string]string)
+
+	for _, c := range candidates {
+		if c.lesson == nil {
+			continue
+		}
+
+		if c.lesson.ID != "" {
+			if other, ok := seenIDs[c.lesson.ID]; ok {
+				c.addIssue(RuleDuplicateID, "duplicate id %q, already used by %s", c.lesson.ID, other)
+			} else {
+				seenIDs[c.lesson.ID] = c.path
+			}
+		}
+
+		if c.lesson.Order != 0 {
+			key := fmt.Sprintf("%s/%s/%d", c.lesson.Language, c.lesson.Level, c.lesson.Order)
+			if other, ok := seenOrders[key]; ok {
+				c.addIssue(RuleOrder, "order %d already used in %s/%s by %s", c.lesson.Order, c.lesson.Language, c.lesson.Level, other)
+			} else {
+				seenOrders[key] = c.path
+			}
+		}
+	}
+}
+
+// missingExcludes returns the exclude words that hide nothing when the lesson
+// is tokenized by the typing engine, e.g. because they only appear inside
+// [[ ]] markers or never match on a word boundary.
+func missingExcludes(lesson *models.Lesson) []string {
+	baseline := countHidden(engine.Tokenize(&models.Lesson{Code: lesson.Code}))
+
+	var missing []string
+	for _, word := range lesson.Exclude {
+		probe := &models.Lesson{Code: lesson.Code, Exclude: []string{word}}
+		if word == "" || countHidden(engine.Tokenize(probe)) == baseline {
+			missing = append(missing, word)
+		}
+	}
+	return missing
+}
+
+func countHidden(chars []engine.Char) int {
+	n := 0
+	for _, c := range chars {
+		if c.Hidden {
+			n++
+		}
+	}
+	return n
+}
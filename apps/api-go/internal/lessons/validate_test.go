@@ -0,0 +1,65 @@
+package lessons
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeLesson writes a single-file lesson to dir/go/basic/<name>
+func writeLesson(t *testing.T, dir, name, json string) {
+	t.Helper()
+	path := filepath.Join(dir, "go", "basic", name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(json), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// issueKinds returns what each issue is about, keyed by file name
+func issueKinds(issues []Issue) map[string][]string {
+	kinds := []string{"failed to parse", "exclude word", "duplicate id", "order"}
+	got := make(map[string][]string)
+	for _, issue := range issues {
+		name := filepath.Base(issue.Path)
+		for _, kind := range kinds {
+			if strings.HasPrefix(issue.Message, kind) {
+				got[name] = append(got[name], kind)
+			}
+		}
+	}
+	return got
+}
+
+func TestLoadReportsEveryCollision(t *testing.T) {
+	dir := t.TempDir()
+	writeLesson(t, dir, "a.json", `{"id": "go-01", "language": "go", "order": 1, "code": "x := 1", "exclude": ["missing"]}`)
+	writeLesson(t, dir, "b.json", `{"id": "go-01", "language": "go", "order": 1, "code": "x := 1"}`)
+	writeLesson(t, dir, "c.json", `{"id": "go-01", "language": "go", "order": 1, "code": "x := 1", "exclude": ["missing"]}`)
+	writeLesson(t, dir, "d.json", `{"id": "go-02", "language": "go", "order": 1, "code": "x := 1"}`)
+	writeLesson(t, dir, "e.json", `{"id": `)
+
+	store, report, err := loadStore(dir, Options{Mode: ModeLenient}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a.json keeps the id and order even though it is invalid on its own
+	want := map[string][]string{
+		"a.json": {"exclude word"},
+		"b.json": {"duplicate id", "order"},
+		"c.json": {"exclude word", "duplicate id", "order"},
+		"d.json": {"order"},
+		"e.json": {"failed to parse"},
+	}
+	if got := issueKinds(report.Issues); !reflect.DeepEqual(got, want) {
+		t.Errorf("got issues %v, want %v", got, want)
+	}
+	if store.Count() != 0 {
+		t.Errorf("got %d lessons, want none", store.Count())
+	}
+}
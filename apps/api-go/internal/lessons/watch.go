@@ -0,0 +1,131 @@
+package lessons
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultWatchInterval is how often the content tree is scanned for changes
+const DefaultWatchInterval = 2 * time.Second
+
+// Reload re-reads the content directory and atomically swaps in the new index.
+// If loading fails the previous snapshot is kept and the error is recorded in
+// the store status. In lenient mode a lesson file that now has issues keeps
+// serving its previous version until it is fixed or removed.
+func (s *Store) Reload() error {
+	s.mu.RLock()
+	contentDir := s.contentDir
+	opts := s.opts
+	previous := s.sources
+	s.mu.RUnlock()
+
+	if contentDir == "" {
+		return fmt.Errorf("store has no content directory to reload from")
+	}
+
+	fresh, report, err := loadStore(contentDir, opts, previous)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status.LastAttempt = time.Now()
+	if report != nil {
+		s.status.Issues = report.Issues
+	}
+	if err != nil {
+		s.status.LastError = err.Error()
+		s.status.FailureCount++
+		return err
+	}
+
+	s.lessons = fresh.lessons
+	s.byLang = fresh.byLang
+	s.sources = fresh.sources
+	s.status.LessonCount = len(fresh.lessons)
+	s.status.LoadedAt = s.status.LastAttempt
+	s.status.LastError = ""
+	s.status.ReloadCount++
+	return nil
+}
+
+// Status returns the outcome of the most recent load or reload
+func (s *Store) Status() LoadStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.status
+}
+
+// Watch polls the content directory and reloads the store whenever a file is
+// added, removed or modified. Polling is used instead of filesystem events so
+// that it also works on bind-mounted volumes inside containers. Watch blocks
+// until ctx is cancelled.
+func (s *Store) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	s.mu.RLock()
+	contentDir := s.contentDir
+	s.mu.RUnlock()
+
+	last, err := fingerprint(contentDir)
+	if err != nil {
+		log.Printf("Content watcher: failed to scan %s: %v", contentDir, err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current, err := fingerprint(contentDir)
+		if err != nil {
+			log.Printf("Content watcher: failed to scan %s: %v", contentDir, err)
+			continue
+		}
+		if current == last {
+			continue
+		}
+		last = current
+
+		if err := s.Reload(); err != nil {
+			log.Printf("Content reload failed, keeping previous lessons: %v", err)
+			continue
+		}
+		log.Printf("Content reloaded: %d lessons", s.Count())
+		for _, issue := range s.Status().Issues {
+			log.Printf("  issue: %s", issue)
+		}
+	}
+}
+
+// fingerprint summarizes the path, size and modification time of every file
+// under dir so that changes can be detected without reading file contents
+func fingerprint(dir string) (uint64, error) {
+	h := fnv.New64a()
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		fmt.Fprintf(h, "%s|%d|%d\n", path, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return h.Sum64(), nil
+}
@@ -0,0 +1,102 @@
+package lessons
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLenientReloadKeepsPreviousLesson(t *testing.T) {
+	dir := t.TempDir()
+	writeLesson(t, dir, "a.json", `{"id": "go-01", "title": "One", "language": "go", "order": 1, "code": "x := 1"}`)
+	writeLesson(t, dir, "b.json", `{"id": "go-02", "title": "Two", "language": "go", "order": 2, "code": "y := 2"}`)
+
+	store, err := LoadLessons(dir, Options{Mode: ModeLenient})
+	if err != nil {
+		t.Fatal(err)
+	}
+	title := func(id string) string {
+		t.Helper()
+		lesson, ok := store.Get(id)
+		if !ok {
+			return ""
+		}
+		return lesson.Title
+	}
+
+	// A broken edit keeps serving the last good version
+	writeLesson(t, dir, "b.json", `{"id": "go-02", "title": "Two v2", `)
+	if err := store.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := title("go-02"); got != "Two" {
+		t.Errorf("broken lesson has title %q, want the previous %q", got, "Two")
+	}
+	if status := store.Status(); len(status.Issues) != 1 || !strings.HasPrefix(status.Issues[0].Message, "failed to parse") || status.LessonCount != 2 {
+		t.Errorf("unexpected status %+v", status)
+	}
+	if lessons := store.GetByLanguage("go"); len(lessons) != 2 || lessons[1].ID != "go-02" {
+		t.Errorf("unexpected go lessons %v", lessons)
+	}
+
+	// and keeps it across further reloads until the file is fixed
+	writeLesson(t, dir, "a.json", `{"id": "go-01", "title": "One v2", "language": "go", "order": 1, "code": "x := 1"}`)
+	if err := store.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := title("go-02"); got != "Two" {
+		t.Errorf("after a second reload: got %q", got)
+	}
+	if got := title("go-01"); got != "One v2" {
+		t.Errorf("valid edit was not loaded: got %q", got)
+	}
+
+	writeLesson(t, dir, "b.json", `{"id": "go-02", "title": "Two v3", "language": "go", "order": 2, "code": "y := 2"}`)
+	if err := store.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := title("go-02"); got != "Two v3" {
+		t.Errorf("fixed lesson has title %q", got)
+	}
+
+	// A lesson that is broken before it ever loaded stays out
+	writeLesson(t, dir, "c.json", `{"id": "go-03", `)
+	if err := store.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if store.Count() != 2 {
+		t.Errorf("got %d lessons, want 2", store.Count())
+	}
+
+	// Removing the file removes the lesson
+	if err := os.Remove(filepath.Join(dir, "go", "basic", "b.json")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.Get("go-02"); ok {
+		t.Error("removed lesson is still served")
+	}
+}
+
+func TestLenientReloadDoesNotKeepTakenIDs(t *testing.T) {
+	dir := t.TempDir()
+	writeLesson(t, dir, "a.json", `{"id": "go-01", "title": "A", "language": "go", "order": 1, "code": "x := 1"}`)
+
+	store, err := LoadLessons(dir, Options{Mode: ModeLenient})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a.json breaks while another file takes over its id
+	writeLesson(t, dir, "a.json", `{"id": `)
+	writeLesson(t, dir, "b.json", `{"id": "go-01", "title": "B", "language": "go", "order": 1, "code": "x := 1"}`)
+	if err := store.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if lesson, ok := store.Get("go-01"); !ok || lesson.Title != "B" || store.Count() != 1 {
+		t.Errorf("got %+v, want only the lesson from b.json", lesson)
+	}
+}
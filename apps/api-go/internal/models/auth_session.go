@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// AuthSession is a login session. Its refresh token rotates on every use and
+// only the hash of the current one is stored; presenting any other token for
+// the session is treated as reuse and revokes it.
+type AuthSession struct {
+	ID               string     `json:"id"`
+	UserID           string     `json:"userId"`
+	RefreshTokenHash string     `json:"-"`
+	AccessTokenID    string     `json:"-"` // jti of the latest access token
+	UserAgent        string     `json:"userAgent"`
+	IPAddress        string     `json:"ipAddress"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	LastUsedAt       *time.Time `json:"lastUsedAt,omitempty"`
+	ExpiresAt        time.Time  `json:"expiresAt"`
+	RevokedAt        *time.Time `json:"revokedAt,omitempty"`
+	Current          bool       `json:"current"` // set for the session making the request
+}
+
+// RefreshRequest is the request body for refreshing an access token. Browsers
+// send the refresh token as a cookie instead.
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
@@ -4,11 +4,51 @@ import "time"
 
 // Badge represents a badge that can be assigned to users
 type Badge struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Color     string    `json:"color"` // e.g., "#FF0000" or "red"
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Color         string    `json:"color"` // e.g., "#FF0000" or "red"
+	Description   string    `json:"description,omitempty"`
+	DescriptionEn string    `json:"description_en,omitempty"`
+	Icon          string    `json:"icon,omitempty"` // icon identifier, e.g. "trophy"
+	Rarity        string    `json:"rarity"`
+	Category      string    `json:"category,omitempty"` // e.g. "community", "progress"
+	Hidden        bool      `json:"hidden"`             // secret until earned
+	Criteria      string    `json:"criteria,omitempty"` // how the badge is earned
+	CriteriaEn    string    `json:"criteria_en,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// Badge rarity tiers, from most to least common
+const (
+	RarityCommon    = "common"
+	RarityUncommon  = "uncommon"
+	RarityRare      = "rare"
+	RarityEpic      = "epic"
+	RarityLegendary = "legendary"
+)
+
+// BadgeRarities lists the rarity tiers in order
+var BadgeRarities = []string{RarityCommon, RarityUncommon, RarityRare, RarityEpic, RarityLegendary}
+
+// BadgeRequest is the request body for creating or updating a badge
+type BadgeRequest struct {
+	Name          string `json:"name"`
+	Color         string `json:"color"`
+	Description   string `json:"description"`
+	DescriptionEn string `json:"description_en"`
+	Icon          string `json:"icon"`
+	Rarity        string `json:"rarity"` // common if empty
+	Category      string `json:"category"`
+	Hidden        bool   `json:"hidden"`
+	Criteria      string `json:"criteria"`
+	CriteriaEn    string `json:"criteria_en"`
+}
+
+// BadgeDetails is a badge with the number of users holding it
+type BadgeDetails struct {
+	Badge
+	Holders int `json:"holders"`
 }
 
 // UserBadge represents the assignment of a badge to a user
@@ -22,4 +62,23 @@ type UserBadge struct {
 type BadgeWithDetails struct {
 	Badge      Badge     `json:"badge"`
 	AssignedAt time.Time `json:"assignedAt"`
-}
\ No newline at end of file
+}
+
+// Badge audit actions
+const (
+	BadgeActionCreate = "create"
+	BadgeActionUpdate = "update"
+	BadgeActionDelete = "delete"
+	BadgeActionAssign = "assign"
+	BadgeActionRemove = "remove"
+)
+
+// BadgeAuditEntry records who changed a badge or a badge assignment
+type BadgeAuditEntry struct {
+	ID           string    `json:"id"`
+	ActorID      string    `json:"actorId"`
+	Action       string    `json:"action"`
+	BadgeID      string    `json:"badgeId"`
+	TargetUserID string    `json:"targetUserId,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
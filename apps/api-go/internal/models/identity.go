@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// UserIdentity is an account at an external identity provider that a user
+// logs in with
+type UserIdentity struct {
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"-"` // the provider's stable user ID
+	UserID    string    `json:"userId"`
+	Username  string    `json:"username,omitempty"`
+	Email     string    `json:"email,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// OAuthProviderInfo describes a provider users can log in with
+type OAuthProviderInfo struct {
+	Name   string `json:"name"`
+	GitHub bool   `json:"github"`
+}
@@ -14,9 +14,25 @@ type TypingMetrics struct {
 	CorrectChars   int          `json:"correctChars"`
 	IncorrectChars int          `json:"incorrectChars"`
 	CommonErrors   []ErrorEntry `json:"commonErrors"`
-	CreatedAt      time.Time    `json:"createdAt"`
+	ReviewStatus   string       `json:"reviewStatus"`
+	FlagReason     string       `json:"flagReason,omitempty"`
+	ReviewedBy     string       `json:"reviewedBy,omitempty"`
+	ReviewedAt     *time.Time   `json:"reviewedAt,omitempty"`
+	// SessionID is set when the metrics come from a typing session, the
+	// only runs that earn points
+	SessionID string    `json:"sessionId,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
 }
 
+// Review states of a metrics row. Points earned by flagged or rejected rows
+// do not count towards rankings.
+const (
+	ReviewStatusOK       = "ok"
+	ReviewStatusFlagged  = "flagged"
+	ReviewStatusApproved = "approved"
+	ReviewStatusRejected = "rejected"
+)
+
 // ErrorEntry represents a common typing error
 type ErrorEntry struct {
 	Expected string `json:"expected"`
@@ -35,6 +51,18 @@ type MetricsRequest struct {
 	CorrectChars   int          `json:"correctChars"`
 	IncorrectChars int          `json:"incorrectChars"`
 	CommonErrors   []ErrorEntry `json:"commonErrors"`
+
+	// FlagReason is set by the server when the anti-cheat guard flags the
+	// submission; it is never read from the request body
+	FlagReason string `json:"-"`
+	// SessionID is set by the server for metrics replayed from a typing
+	// session; it is never read from the request body
+	SessionID string `json:"-"`
+}
+
+// MetricsReviewRequest is the request body for an admin review of flagged metrics
+type MetricsReviewRequest struct {
+	Status string `json:"status"` // "approved" or "rejected"
 }
 
 // UserMetricsSummary is an aggregated view of user metrics
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// TOTPConfig is a user's TOTP second factor. It is pending until EnabledAt is
+// set by confirming a first code.
+type TOTPConfig struct {
+	Secret    string
+	EnabledAt *time.Time
+	// LastStep is the last time step a code was accepted for
+	LastStep int64
+}
+
+// TOTPEnrollResponse holds what an authenticator app needs to generate codes
+type TOTPEnrollResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+// MFACodeRequest carries a TOTP code or, instead, a recovery code
+type MFACodeRequest struct {
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recoveryCode,omitempty"`
+}
+
+// RecoveryCodesResponse lists freshly issued recovery codes. They are only
+// shown once.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// MFAChallengeResponse is returned by login instead of an AuthResponse when
+// the account has a second factor
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfaRequired"`
+	MFAToken    string `json:"mfaToken"`
+}
+
+// MFAVerifyRequest completes a login with the challenge token and a second
+// factor
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfaToken"`
+	MFACodeRequest
+}
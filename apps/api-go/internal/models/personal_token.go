@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// PersonalAccessToken is a named, scoped token a user created for scripts and
+// editor plugins. Only its hash is stored; the token itself is shown once.
+type PersonalAccessToken struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"userId"`
+	Name       string     `json:"name"`
+	Hash       string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"` // nil for tokens that never expire
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+// CreatePersonalTokenRequest asks for a new personal access token
+type CreatePersonalTokenRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// ExpiresInDays is how long the token lasts; 0 for no expiry
+	ExpiresInDays int `json:"expiresInDays,omitempty"`
+}
+
+// CreatePersonalTokenResponse returns a new token. Token is never shown
+// again.
+type CreatePersonalTokenResponse struct {
+	PersonalAccessToken
+	Token string `json:"token"`
+}
@@ -4,12 +4,35 @@ import "time"
 
 // PointTransaction represents a point earning event
 type PointTransaction struct {
-	ID        string    `json:"id"`
-	UserID    string    `json:"userId"`
-	SourceID  string    `json:"sourceId"` // e.g. LessonID
-	Points    int       `json:"points"`
-	Reason    string    `json:"reason"` // e.g. "lesson_complete", "daily_streak"
-	CreatedAt time.Time `json:"createdAt"`
+	ID        string `json:"id"`
+	UserID    string `json:"userId"`
+	SourceID  string `json:"sourceId"` // e.g. LessonID
+	MetricsID string `json:"metricsId,omitempty"`
+	Points    int    `json:"points"`
+	Reason    string `json:"reason"` // e.g. "lesson_complete", "daily_streak"
+	// Strategy and StrategyVersion name the point strategy that calculated
+	// the points
+	Strategy        string    `json:"strategy,omitempty"`
+	StrategyVersion string    `json:"strategyVersion,omitempty"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+// PointsLedgerEntry is a point transaction with the run that earned it
+type PointsLedgerEntry struct {
+	PointTransaction
+	LessonTitle  string  `json:"lessonTitle,omitempty"`
+	WPM          float64 `json:"wpm,omitempty"`
+	Accuracy     float64 `json:"accuracy,omitempty"`
+	ReviewStatus string  `json:"reviewStatus,omitempty"`
+	// Counted is false while the run is flagged or after it was rejected
+	Counted bool `json:"counted"`
+}
+
+// PointsLedger explains where a user's points came from, oldest first
+type PointsLedger struct {
+	UserID      string              `json:"userId"`
+	TotalPoints int                 `json:"totalPoints"` // sum of counted entries
+	Entries     []PointsLedgerEntry `json:"entries"`
 }
 
 // LeaderboardEntry represents a user's standing in a leaderboard
@@ -17,6 +40,7 @@ type LeaderboardEntry struct {
 	UserID         string             `json:"userId"`
 	Username       string             `json:"username"` // Optional, if we have usernames
 	GitHubUsername *string            `json:"githubUsername,omitempty"` // Optional, if user linked GitHub
+	GitHubVerified bool               `json:"githubVerified"`           // GitHubUsername came from a GitHub login
 	Points         int                `json:"points"`
 	Rank           int                `json:"rank"`
 	AvatarURL      string             `json:"avatarUrl"`
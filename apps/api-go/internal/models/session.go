@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// TypingSession is a server-issued attempt at a lesson. Points are only
+// awarded when a session is finished with a keystroke log the server replays.
+type TypingSession struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"userId"`
+	LessonID   string     `json:"lessonId"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// KeystrokeLog is the compact record of a typing attempt. Keys holds every
+// key in order ("\b" for backspace) and Times the milliseconds elapsed since
+// the previous key (0 for the first one).
+type KeystrokeLog struct {
+	Keys  string  `json:"keys"`
+	Times []int64 `json:"times"`
+}
+
+// StartSessionRequest is the request body for starting a typing session
+type StartSessionRequest struct {
+	LessonID string `json:"lessonId"`
+}
+
+// StartSessionResponse carries the new session and the token that proves it
+// was issued by the server
+type StartSessionResponse struct {
+	Session TypingSession `json:"session"`
+	Token   string        `json:"token"`
+}
+
+// FinishSessionRequest is the request body for finishing a typing session
+type FinishSessionRequest struct {
+	Token      string       `json:"token"`
+	Keystrokes KeystrokeLog `json:"keystrokes"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// TierProgress is where a user stands on the tier ladder
+type TierProgress struct {
+	Tier       string `json:"tier"`
+	Points     int    `json:"points"`
+	TierPoints int    `json:"tierPoints"` // points the current tier starts at
+	// NextTier and NextTierPoints are empty at the top tier
+	NextTier       string `json:"nextTier,omitempty"`
+	NextTierPoints int    `json:"nextTierPoints,omitempty"`
+	PointsToNext   int    `json:"pointsToNext"`
+	// Progress is the share of the way from the current tier to the next,
+	// from 0 to 1; it is 1 at the top tier
+	Progress float64 `json:"progress"`
+}
+
+// TierEvent records the first time a user reached a tier
+type TierEvent struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"userId"`
+	Tier      string    `json:"tier"`
+	Points    int       `json:"points"` // total points at the promotion
+	CreatedAt time.Time `json:"createdAt"`
+}
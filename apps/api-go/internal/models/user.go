@@ -2,19 +2,30 @@ package models
 
 import "time"
 
+// User roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 // User represents a user account (guest or registered)
 type User struct {
-	ID             string             `json:"id"`
-	Username       string             `json:"username"`
-	Email          *string            `json:"email,omitempty"`
-	DisplayName    string             `json:"displayName"`
-	GitHubUsername *string            `json:"githubUsername,omitempty"`
-	IsGuest        bool               `json:"isGuest"`
-	CurrentStreak  int                `json:"currentStreak"`
-	LastStreakAt   *time.Time         `json:"lastStreakAt"`
-	Badges         []BadgeWithDetails `json:"badges,omitempty"`
-	CreatedAt      time.Time          `json:"createdAt"`
-	UpdatedAt      time.Time          `json:"updatedAt"`
+	ID              string             `json:"id"`
+	Username        string             `json:"username"`
+	Email           *string            `json:"email,omitempty"`
+	EmailVerifiedAt *time.Time         `json:"emailVerifiedAt,omitempty"`
+	DisplayName     string             `json:"displayName"`
+	GitHubUsername  *string            `json:"githubUsername,omitempty"`
+	GitHubVerified  bool               `json:"githubVerified"` // GitHubUsername came from a GitHub login
+	IsGuest         bool               `json:"isGuest"`
+	Role            string             `json:"role"`
+	MFAEnabled      bool               `json:"mfaEnabled"`
+	CurrentStreak   int                `json:"currentStreak"`
+	LastStreakAt    *time.Time         `json:"lastStreakAt"`
+	Badges          []BadgeWithDetails `json:"badges,omitempty"`
+	Tier            *TierProgress      `json:"tier,omitempty"`
+	CreatedAt       time.Time          `json:"createdAt"`
+	UpdatedAt       time.Time          `json:"updatedAt"`
 }
 
 // RegisterRequest represents a registration request
@@ -34,15 +45,17 @@ type LoginRequest struct {
 
 // AuthResponse represents the response after successful authentication
 type AuthResponse struct {
-	User  User   `json:"user"`
-	Token string `json:"token"`
+	User         User   `json:"user"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken,omitempty"`
 }
 
 // UserProfile represents a public user profile with stats
 type UserProfile struct {
-	User             User               `json:"user"`
+	User             User                `json:"user"`
 	Metrics          *UserMetricsSummary `json:"metrics,omitempty"`
-	Progress         []Progress         `json:"progress,omitempty"`
-	CompletedLessons int                `json:"completedLessons"`
-	TotalPoints      int                `json:"totalPoints"`
+	Progress         []Progress          `json:"progress,omitempty"`
+	CompletedLessons int                 `json:"completedLessons"`
+	TotalPoints      int                 `json:"totalPoints"`
+	TierEvents       []TierEvent         `json:"tierEvents"`
 }
@@ -0,0 +1,270 @@
+// Package ratelimit provides token-bucket rate limiting middleware with
+// named per-route policies and the standard RateLimit-* response headers.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/typing-code-learn/api-go/internal/auth"
+)
+
+// Rate allows Limit requests per Period, with bursts of up to Limit
+type Rate struct {
+	Limit  int
+	Period time.Duration
+}
+
+// ParseRate parses a rate written as "<limit>/<period>", e.g. "30/1m".
+// "off" disables the policy.
+func ParseRate(s string) (Rate, error) {
+	s = strings.TrimSpace(s)
+	if s == "off" {
+		return Rate{}, nil
+	}
+	limit, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Rate{}, fmt.Errorf("invalid rate %q: want <limit>/<period>", s)
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil || n <= 0 {
+		return Rate{}, fmt.Errorf("invalid rate %q: limit must be a positive integer", s)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Rate{}, fmt.Errorf("invalid rate %q: period must be a positive duration", s)
+	}
+	return Rate{Limit: n, Period: d}, nil
+}
+
+// Disabled reports whether the rate lets everything through
+func (r Rate) Disabled() bool {
+	return r.Limit <= 0
+}
+
+// KeyFunc returns the bucket a request is counted against
+type KeyFunc func(r *http.Request) string
+
+// ByIP counts requests per client IP
+func ByIP(r *http.Request) string {
+	return "ip:" + auth.ClientIP(r)
+}
+
+// ByUser counts requests per authenticated user, falling back to the client
+// IP for anonymous requests. It relies on auth.AuthMiddleware having run.
+func ByUser(r *http.Request) string {
+	if userCtx, ok := auth.GetUserFromContext(r.Context()); ok {
+		return "user:" + userCtx.UserID
+	}
+	return ByIP(r)
+}
+
+// Policy is a named rate applied per key
+type Policy struct {
+	Name string
+	Rate Rate
+	Key  KeyFunc
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Limiter enforces one policy with a token bucket per key
+type Limiter struct {
+	policy Policy
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastPrune time.Time
+}
+
+// NewLimiter creates a limiter for a policy
+func NewLimiter(policy Policy) *Limiter {
+	return &Limiter{
+		policy:  policy,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Result describes the state of a bucket after taking a token
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	Reset      time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until the next token, when not allowed
+}
+
+// Take spends a token from the bucket of key
+func (l *Limiter) Take(key string, now time.Time) Result {
+	rate := l.policy.Rate
+	perToken := rate.Period / time.Duration(rate.Limit)
+	capacity := float64(rate.Limit)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.prune(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.updated).Seconds()/perToken.Seconds())
+	b.updated = now
+
+	res := Result{Allowed: b.tokens >= 1}
+	if res.Allowed {
+		b.tokens--
+	} else {
+		res.RetryAfter = time.Duration((1 - b.tokens) * float64(perToken))
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = time.Duration((capacity - b.tokens) * float64(perToken))
+	return res
+}
+
+// prune drops buckets that have refilled completely, at most once per period
+func (l *Limiter) prune(now time.Time) {
+	period := l.policy.Rate.Period
+	if now.Sub(l.lastPrune) < period {
+		return
+	}
+	l.lastPrune = now
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= period {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Allowlist holds client addresses that are never rate limited, such as
+// internal health probes
+type Allowlist []*net.IPNet
+
+// ParseAllowlist parses a comma-separated list of IPs and CIDR ranges
+func ParseAllowlist(s string) (Allowlist, error) {
+	var list Allowlist
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowlist entry %q", entry)
+		}
+		list = append(list, ipNet)
+	}
+	return list, nil
+}
+
+// Contains reports whether an IP address is allowlisted
+func (a Allowlist) Contains(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipNet := range a {
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// Set is a collection of named policies sharing an allowlist
+type Set struct {
+	limiters  map[string]*Limiter
+	allowlist Allowlist
+}
+
+// NewSet creates a set from default policies. overrides replaces rates by
+// name, written as "name=<limit>/<period>,..." (see ParseRate).
+func NewSet(policies []Policy, overrides string, allowlist Allowlist) (*Set, error) {
+	s := &Set{limiters: make(map[string]*Limiter), allowlist: allowlist}
+	byName := make(map[string]*Policy, len(policies))
+	for i := range policies {
+		byName[policies[i].Name] = &policies[i]
+	}
+
+	for _, override := range strings.Split(overrides, ",") {
+		if strings.TrimSpace(override) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(override, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit %q: want name=<limit>/<period>", override)
+		}
+		policy, found := byName[strings.TrimSpace(name)]
+		if !found {
+			return nil, fmt.Errorf("unknown rate limit policy %q", strings.TrimSpace(name))
+		}
+		rate, err := ParseRate(value)
+		if err != nil {
+			return nil, err
+		}
+		policy.Rate = rate
+	}
+
+	for _, policy := range policies {
+		s.limiters[policy.Name] = NewLimiter(policy)
+	}
+	return s, nil
+}
+
+// Limit returns middleware enforcing the named policy. It panics on unknown
+// names, which are programming errors.
+func (s *Set) Limit(name string) func(http.Handler) http.Handler {
+	l, ok := s.limiters[name]
+	if !ok {
+		panic("ratelimit: unknown policy " + name)
+	}
+	if l.policy.Rate.Disabled() {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	rate := l.policy.Rate
+	policyHeader := fmt.Sprintf("%d;w=%d", rate.Limit, int(math.Ceil(rate.Period.Seconds())))
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if s.allowlist.Contains(auth.ClientIP(r)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			res := l.Take(l.policy.Key(r), time.Now())
+			h := w.Header()
+			h.Set("RateLimit-Policy", policyHeader)
+			h.Set("RateLimit-Limit", strconv.Itoa(rate.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+			if !res.Allowed {
+				h.Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+				h.Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusTooManyRequests)
+				_, _ = w.Write([]byte(`{"error":"rate limit exceeded"}`))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
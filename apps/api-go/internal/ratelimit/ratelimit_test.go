@@ -0,0 +1,201 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		in    string
+		want  Rate
+		valid bool
+	}{
+		{"30/1m", Rate{Limit: 30, Period: time.Minute}, true},
+		{" 5/10s ", Rate{Limit: 5, Period: 10 * time.Second}, true},
+		{"off", Rate{}, true},
+		{"30", Rate{}, false},
+		{"0/1m", Rate{}, false},
+		{"-1/1m", Rate{}, false},
+		{"x/1m", Rate{}, false},
+		{"30/0s", Rate{}, false},
+		{"30/minute", Rate{}, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseRate(tt.in)
+		if (err == nil) != tt.valid {
+			t.Errorf("ParseRate(%q) error = %v, want valid: %v", tt.in, err, tt.valid)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRate(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+	if rate, _ := ParseRate("off"); !rate.Disabled() {
+		t.Error("off is not disabled")
+	}
+}
+
+func TestTakeRefills(t *testing.T) {
+	l := NewLimiter(Policy{Name: "test", Rate: Rate{Limit: 3, Period: 3 * time.Second}, Key: ByIP})
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// A full bucket allows a burst of Limit requests
+	for want := 2; want >= 0; want-- {
+		res := l.Take("a", now)
+		if !res.Allowed || res.Remaining != want {
+			t.Fatalf("got %+v, want allowed with %d remaining", res, want)
+		}
+	}
+	res := l.Take("a", now)
+	if res.Allowed || res.RetryAfter != time.Second || res.Reset != 3*time.Second {
+		t.Errorf("empty bucket: got %+v, want denied, retry after 1s, full in 3s", res)
+	}
+	if res := l.Take("b", now); !res.Allowed {
+		t.Error("other keys share the bucket")
+	}
+
+	// One token per second comes back
+	res = l.Take("a", now.Add(1500*time.Millisecond))
+	if !res.Allowed || res.Remaining != 0 {
+		t.Errorf("after 1.5s: got %+v, want allowed with 0 remaining", res)
+	}
+	res = l.Take("a", now.Add(1700*time.Millisecond))
+	if res.Allowed || res.RetryAfter != 300*time.Millisecond {
+		t.Errorf("after 1.7s: got %+v, want denied, retry after 300ms", res)
+	}
+
+	// and never more than Limit
+	res = l.Take("a", now.Add(time.Hour))
+	if !res.Allowed || res.Remaining != 2 {
+		t.Errorf("after an hour: got %+v, want allowed with 2 remaining", res)
+	}
+}
+
+func TestParseAllowlist(t *testing.T) {
+	list, err := ParseAllowlist(" 10.0.0.0/8, 192.168.1.5,, ::1 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]bool{
+		"10.1.2.3":    true,
+		"192.168.1.5": true,
+		"192.168.1.6": false,
+		"::1":         true,
+		"::2":         false,
+		"not an ip":   false,
+	}
+	for ip, want := range tests {
+		if got := list.Contains(ip); got != want {
+			t.Errorf("Contains(%q) = %v, want %v", ip, got, want)
+		}
+	}
+
+	if list, err := ParseAllowlist(""); err != nil || len(list) != 0 {
+		t.Errorf("empty allowlist: got %v, %v", list, err)
+	}
+	for _, bad := range []string{"10.0.0.0/33", "example.com"} {
+		if _, err := ParseAllowlist(bad); err == nil {
+			t.Errorf("%q was accepted", bad)
+		}
+	}
+}
+
+func testPolicies() []Policy {
+	return []Policy{
+		{Name: "login", Rate: Rate{Limit: 2, Period: time.Minute}, Key: ByIP},
+		{Name: "api", Rate: Rate{Limit: 100, Period: time.Minute}, Key: ByUser},
+	}
+}
+
+func TestNewSetOverrides(t *testing.T) {
+	s, err := NewSet(testPolicies(), " login = 5/10s , api=off", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.limiters["login"].policy.Rate; got != (Rate{Limit: 5, Period: 10 * time.Second}) {
+		t.Errorf("login rate = %+v, want 5/10s", got)
+	}
+	if !s.limiters["api"].policy.Rate.Disabled() {
+		t.Error("api was not turned off")
+	}
+
+	for _, bad := range []string{"login", "nope=5/1m", "login=5"} {
+		if _, err := NewSet(testPolicies(), bad, nil); err == nil {
+			t.Errorf("override %q was accepted", bad)
+		}
+	}
+}
+
+func TestLimitMiddleware(t *testing.T) {
+	allowlist, err := ParseAllowlist("10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewSet(testPolicies(), "api=off", allowlist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	login := s.Limit("login")(ok)
+	serve := func(h http.Handler, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/auth/login", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, remaining := range []string{"1", "0"} {
+		rec := serve(login, "192.0.2.1:1234")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d, want 200", rec.Code)
+		}
+		h := rec.Header()
+		if h.Get("RateLimit-Policy") != "2;w=60" || h.Get("RateLimit-Limit") != "2" || h.Get("RateLimit-Remaining") != remaining {
+			t.Errorf("unexpected headers %v", h)
+		}
+	}
+
+	rec := serve(login, "192.0.2.1:1234")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("got status %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("got Retry-After %q, want 30", got)
+	}
+	if got := rec.Header().Get("RateLimit-Reset"); got != "60" {
+		t.Errorf("got RateLimit-Reset %q, want 60", got)
+	}
+	if !strings.Contains(rec.Body.String(), "rate limit exceeded") {
+		t.Errorf("unexpected body %s", rec.Body.String())
+	}
+
+	// Allowlisted clients are never limited or told about limits
+	for i := 0; i < 5; i++ {
+		rec := serve(login, "10.0.0.1:1234")
+		if rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("allowlisted request: got %d with headers %v", rec.Code, rec.Header())
+		}
+	}
+
+	// Disabled policies pass everything through without headers
+	api := s.Limit("api")(ok)
+	for i := 0; i < 200; i++ {
+		if rec := serve(api, "192.0.2.1:1234"); rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("disabled policy: got %d with headers %v", rec.Code, rec.Header())
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("unknown policy did not panic")
+		}
+	}()
+	s.Limit("unknown")
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"log"
+
+	"github.com/typing-code-learn/api-go/internal/database"
+	"github.com/typing-code-learn/api-go/internal/gamification"
+)
+
+const pointsUsage = `Usage: api-server points <command>
+
+Commands:
+  recompute [-version v2 | -config points.json] [-user ID] [-dry-run]
+                        Replay typing metrics through a point strategy version and
+                        write compensating transactions; defaults to the strategies
+                        the server uses (POINTS_CONFIG or the current built-in ones)`
+
+// runPointsCommand implements the `points` CLI subcommand
+func runPointsCommand(db database.Store, lessons gamification.LessonLookup, pointsConfig string, args []string) error {
+	if len(args) < 1 || args[0] != "recompute" {
+		return errors.New(pointsUsage)
+	}
+
+	fs := flag.NewFlagSet("recompute", flag.ContinueOnError)
+	version := fs.String("version", "", "built-in strategy version to replay with ("+gamification.StrategyVersionLegacy+" or "+gamification.StrategyVersionCurrent+")")
+	config := fs.String("config", "", "point strategies file to replay with")
+	userID := fs.String("user", "", "only recompute this user's points")
+	dryRun := fs.Bool("dry-run", false, "only list the transactions that would be written")
+	if err := fs.Parse(args[1:]); err != nil {
+		return errors.New(pointsUsage)
+	}
+
+	var registry *gamification.Registry
+	var err error
+	switch {
+	case *version != "" && *config != "":
+		return errors.New("use either -version or -config")
+	case *version != "":
+		registry, err = gamification.BuiltinRegistry(*version)
+	case *config != "":
+		registry, err = gamification.LoadRegistry(*config)
+	case pointsConfig != "":
+		registry, err = gamification.LoadRegistry(pointsConfig)
+	default:
+		registry = gamification.DefaultRegistry()
+	}
+	if err != nil {
+		return err
+	}
+
+	userIDs := []string{*userID}
+	if *userID == "" {
+		if userIDs, err = db.GetUserIDsWithMetrics(); err != nil {
+			return err
+		}
+	}
+
+	written, net := 0, 0
+	for _, id := range userIDs {
+		history, err := db.GetMetricsHistory(id)
+		if err != nil {
+			return err
+		}
+		ledger, err := db.GetUserPointTransactions(id)
+		if err != nil {
+			return err
+		}
+
+		for _, pt := range gamification.Recompute(registry, lessons, history, ledger) {
+			if !*dryRun {
+				if err := db.SavePointTransaction(pt); err != nil {
+					return err
+				}
+			}
+			log.Printf("User %s: %+d points (%s) for run %s of %s", id, pt.Points, pt.Reason, pt.MetricsID, pt.SourceID)
+			written++
+			net += pt.Points
+		}
+	}
+
+	if *dryRun {
+		log.Printf("Dry run: %d transactions (%+d points) would be written for %d users with strategy version %s", written, net, len(userIDs), registry.Version())
+	} else {
+		log.Printf("Wrote %d transactions (%+d points) for %d users with strategy version %s", written, net, len(userIDs), registry.Version())
+	}
+	return nil
+}
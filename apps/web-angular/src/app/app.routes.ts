@@ -41,6 +41,27 @@ export const routes: Routes = [
         (m) => m.UserProfileComponent
       ),
   },
+  {
+    path: 'reset-password',
+    loadComponent: () =>
+      import('./pages/reset-password/reset-password.component').then(
+        (m) => m.ResetPasswordComponent
+      ),
+  },
+  {
+    path: 'verify-email',
+    loadComponent: () =>
+      import('./pages/verify-email/verify-email.component').then(
+        (m) => m.VerifyEmailComponent
+      ),
+  },
+  {
+    path: 'oauth-callback',
+    loadComponent: () =>
+      import('./pages/oauth-callback/oauth-callback.component').then(
+        (m) => m.OAuthCallbackComponent
+      ),
+  },
   {
     path: '**',
     redirectTo: '',
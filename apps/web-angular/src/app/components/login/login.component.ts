@@ -1,7 +1,8 @@
-import { Component, EventEmitter, Output } from '@angular/core';
+import { Component, EventEmitter, OnInit, Output } from '@angular/core';
 import { CommonModule } from '@angular/common';
 import { FormsModule } from '@angular/forms';
 import { UserService } from '../../services/user.service';
+import { OAuthProvider } from '../../models/user.model';
 
 @Component({
     selector: 'app-login',
@@ -10,7 +11,7 @@ import { UserService } from '../../services/user.service';
     templateUrl: './login.component.html',
     styleUrls: ['./login.component.scss'],
 })
-export class LoginComponent {
+export class LoginComponent implements OnInit {
     @Output() loginSuccess = new EventEmitter<void>();
     @Output() closeModal = new EventEmitter<void>();
     @Output() switchToRegister = new EventEmitter<void>();
@@ -20,8 +21,37 @@ export class LoginComponent {
     error = '';
     loading = false;
 
+    // Second step for accounts with two-factor authentication
+    mfaToken = '';
+    mfaCode = '';
+    useRecoveryCode = false;
+
+    // Forgot password mode
+    forgotMode = false;
+    email = '';
+    resetSent = false;
+
+    // Identity providers offered besides the password
+    providers: OAuthProvider[] = [];
+
     constructor(private userService: UserService) { }
 
+    ngOnInit() {
+        this.userService.getOAuthProviders().subscribe({
+            next: (providers) => (this.providers = providers),
+            error: () => (this.providers = []),
+        });
+    }
+
+    providerLabel(provider: OAuthProvider): string {
+        return provider.github ? 'GitHub' : provider.name;
+    }
+
+    // The API redirects to the provider and back to /oauth-callback
+    loginWith(provider: OAuthProvider) {
+        window.location.href = this.userService.oauthLoginUrl(provider.name, window.location.pathname);
+    }
+
     async onSubmit() {
         if (!this.username || !this.password) {
             this.error = 'Username and password are required';
@@ -32,7 +62,11 @@ export class LoginComponent {
         this.error = '';
 
         try {
-            await this.userService.login(this.username, this.password);
+            const result = await this.userService.login(this.username, this.password);
+            if ('mfaRequired' in result) {
+                this.mfaToken = result.mfaToken;
+                return;
+            }
             // Successfully logged in - emit success event
             this.loginSuccess.emit();
         } catch (err: any) {
@@ -42,6 +76,68 @@ export class LoginComponent {
         }
     }
 
+    async onMfaSubmit() {
+        if (!this.mfaCode) {
+            this.error = 'Code is required';
+            return;
+        }
+
+        this.loading = true;
+        this.error = '';
+
+        try {
+            await this.userService.verifyMfa(this.mfaToken, this.mfaCode, this.useRecoveryCode);
+            this.loginSuccess.emit();
+        } catch (err: any) {
+            this.error = err.error?.error || 'Invalid code';
+            // The challenge expired; start over with the password
+            if (err.error?.error === 'Invalid or expired MFA challenge') {
+                this.cancelMfa();
+                this.error = 'The login took too long, please try again';
+            }
+        } finally {
+            this.loading = false;
+        }
+    }
+
+    toggleRecoveryCode() {
+        this.useRecoveryCode = !this.useRecoveryCode;
+        this.mfaCode = '';
+        this.error = '';
+    }
+
+    cancelMfa() {
+        this.mfaToken = '';
+        this.mfaCode = '';
+        this.useRecoveryCode = false;
+        this.password = '';
+    }
+
+    async onForgotSubmit() {
+        if (!this.email) {
+            this.error = 'Email is required';
+            return;
+        }
+
+        this.loading = true;
+        this.error = '';
+
+        try {
+            await this.userService.forgotPassword(this.email);
+            this.resetSent = true;
+        } catch (err: any) {
+            this.error = err.error?.error || 'Could not send the reset email';
+        } finally {
+            this.loading = false;
+        }
+    }
+
+    toggleForgotMode() {
+        this.forgotMode = !this.forgotMode;
+        this.error = '';
+        this.resetSent = false;
+    }
+
     onClose() {
         this.closeModal.emit();
     }
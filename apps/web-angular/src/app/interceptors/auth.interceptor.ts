@@ -1,8 +1,18 @@
-import { HttpInterceptorFn } from '@angular/common/http';
+import { HttpErrorResponse, HttpHandlerFn, HttpInterceptorFn, HttpRequest, HttpResponse } from '@angular/common/http';
+import { Observable, catchError, filter, finalize, shareReplay, switchMap, take, throwError } from 'rxjs';
+import { environment } from '../../environments/environment';
+
+/** Auth endpoints that must not trigger a token refresh when they fail */
+const NO_REFRESH_PATHS = ['/auth/refresh', '/auth/login', '/auth/mfa/verify', '/auth/register', '/auth/guest', '/auth/logout'];
+
+/** In-flight refresh shared by every request that hit an expired token */
+let refreshInFlight: Observable<unknown> | null = null;
 
 /**
  * HTTP interceptor that adds credentials to all requests
- * This ensures cookies are sent with API calls
+ * This ensures cookies are sent with API calls.
+ * Access tokens are short-lived: on a 401 the session is refreshed once
+ * using the refresh token cookie and the request is retried.
  */
 export const authInterceptor: HttpInterceptorFn = (req, next) => {
     // Clone request and add withCredentials option
@@ -10,5 +20,32 @@ export const authInterceptor: HttpInterceptorFn = (req, next) => {
         withCredentials: true,
     });
 
-    return next(authReq);
+    return next(authReq).pipe(
+        catchError((error) => {
+            if (!(error instanceof HttpErrorResponse) || error.status !== 401 || !canRefresh(req)) {
+                return throwError(() => error);
+            }
+            return refreshSession(next).pipe(
+                switchMap(() => next(authReq)),
+                catchError(() => throwError(() => error)),
+            );
+        }),
+    );
 };
+
+function canRefresh(req: HttpRequest<unknown>): boolean {
+    return req.url.startsWith(environment.apiUrl) && !NO_REFRESH_PATHS.some((path) => req.url.includes(path));
+}
+
+function refreshSession(next: HttpHandlerFn): Observable<unknown> {
+    if (!refreshInFlight) {
+        const refreshReq = new HttpRequest('POST', `${environment.apiUrl}/auth/refresh`, {}, { withCredentials: true });
+        refreshInFlight = next(refreshReq).pipe(
+            filter((event) => event instanceof HttpResponse),
+            take(1),
+            finalize(() => (refreshInFlight = null)),
+            shareReplay(1),
+        );
+    }
+    return refreshInFlight;
+}
@@ -1,11 +1,26 @@
+export type BadgeRarity = 'common' | 'uncommon' | 'rare' | 'epic' | 'legendary';
+
 export interface Badge {
   id: string;
   name: string;
   color: string;
+  description?: string;
+  description_en?: string;
+  icon?: string;
+  rarity: BadgeRarity;
+  category?: string;
+  hidden: boolean;
+  criteria?: string;
+  criteria_en?: string;
   createdAt: string;
   updatedAt: string;
 }
 
+/** A badge with the number of users holding it (GET /badges/{id}) */
+export interface BadgeDetails extends Badge {
+  holders: number;
+}
+
 export interface BadgeWithDetails {
   badge: Badge;
   assignedAt: string;
@@ -15,6 +30,7 @@ export interface LeaderboardEntry {
   userId: string;
   username: string;
   githubUsername?: string;
+  githubVerified?: boolean;
   points: number;
   rank: number;
   avatarUrl?: string;
@@ -25,11 +41,28 @@ export interface PointTransaction {
   id: string;
   userId: string;
   sourceId: string;
+  metricsId?: string;
   points: number;
   reason: string;
+  strategy?: string;
+  strategyVersion?: string;
   createdAt: string;
 }
 
+export interface PointsLedgerEntry extends PointTransaction {
+  lessonTitle?: string;
+  wpm?: number;
+  accuracy?: number;
+  reviewStatus?: string;
+  counted: boolean;
+}
+
+export interface PointsLedger {
+  userId: string;
+  totalPoints: number;
+  entries: PointsLedgerEntry[];
+}
+
 export interface MetricSaveResponse {
   metrics: unknown;
   pointsEarned: number;
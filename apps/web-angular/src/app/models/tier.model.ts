@@ -0,0 +1,21 @@
+/** Where a user stands on the tier ladder */
+export interface TierProgress {
+  tier: string;
+  points: number;
+  tierPoints: number;
+  /** Empty at the top tier */
+  nextTier?: string;
+  nextTierPoints?: number;
+  pointsToNext: number;
+  /** Share of the way to the next tier, from 0 to 1 */
+  progress: number;
+}
+
+/** The first time a user reached a tier */
+export interface TierEvent {
+  id: string;
+  userId: string;
+  tier: string;
+  points: number;
+  createdAt: string;
+}
@@ -1,3 +1,6 @@
+import { Badge } from './leaderboard.model';
+import { TierEvent } from './tier.model';
+
 export interface TypingMetrics {
   id: string;
   userId: string;
@@ -30,6 +33,36 @@ export interface MetricsRequest {
   commonErrors: ErrorEntry[];
 }
 
+/**
+ * Compact record of a typing attempt: every key in order ("\b" for backspace)
+ * and the milliseconds since the previous key (0 for the first one).
+ */
+export interface KeystrokeLog {
+  keys: string;
+  times: number[];
+}
+
+export interface TypingSession {
+  id: string;
+  userId: string;
+  lessonId: string;
+  startedAt: string;
+  finishedAt?: string;
+}
+
+export interface StartSessionResponse {
+  session: TypingSession;
+  token: string;
+}
+
+export interface FinishSessionResponse {
+  metrics: TypingMetrics;
+  pointsEarned: number;
+  currentStreak: number;
+  tierUps: TierEvent[];
+  badgesEarned: Badge[];
+}
+
 export interface UserMetricsSummary {
   userId: string;
   averageWpm: number;
@@ -1,6 +1,7 @@
 import { User } from './user.model';
 import { BadgeWithDetails } from './leaderboard.model';
 import { Progress } from './progress.model';
+import { TierEvent } from './tier.model';
 
 export interface UserMetricsSummary {
   userId: string;
@@ -17,4 +18,5 @@ export interface UserProfile {
   progress?: Progress[];
   completedLessons: number;
   totalPoints: number;
+  tierEvents: TierEvent[];
 }
@@ -1,15 +1,22 @@
 import { BadgeWithDetails } from './leaderboard.model';
+import { TierProgress } from './tier.model';
 
 export interface User {
     id: string;
     username: string;
     email?: string;
+    emailVerifiedAt?: string;
     displayName: string;
     githubUsername?: string;
+    /** githubUsername came from a GitHub login rather than being typed */
+    githubVerified?: boolean;
     isGuest: boolean;
+    mfaEnabled?: boolean;
     currentStreak: number;
     lastStreakAt?: string;
     badges?: BadgeWithDetails[];
+    /** Set by /auth/me and user profiles */
+    tier?: TierProgress;
     createdAt: string;
     updatedAt: string;
 }
@@ -30,4 +37,70 @@ export interface LoginRequest {
 export interface AuthResponse {
     user: User;
     token: string;
+    refreshToken?: string;
+}
+
+/** Returned by login instead of an AuthResponse when the account has 2FA */
+export interface MFAChallenge {
+    mfaRequired: true;
+    mfaToken: string;
+}
+
+/** Two-factor authentication state of the current user (GET /auth/mfa) */
+export interface MFAStatus {
+    eligible: boolean;
+    enabled: boolean;
+    recoveryCodesLeft: number;
+}
+
+/** A pending TOTP enrollment */
+export interface TOTPEnrollment {
+    secret: string;
+    provisioningUri: string;
+}
+
+/** An identity provider users can log in with (GET /auth/oauth) */
+export interface OAuthProvider {
+    name: string;
+    github: boolean;
+}
+
+/** An identity provider account linked to the current user (GET /auth/identities) */
+export interface UserIdentity {
+    provider: string;
+    userId: string;
+    username?: string;
+    email?: string;
+    createdAt: string;
+}
+
+/** Scopes a personal access token can be granted */
+export type TokenScope = 'read-profile' | 'read-progress' | 'write-progress' | 'read-metrics' | 'write-metrics';
+
+/** A personal access token of the current user (GET /auth/tokens) */
+export interface PersonalAccessToken {
+    id: string;
+    userId: string;
+    name: string;
+    scopes: TokenScope[];
+    createdAt: string;
+    expiresAt?: string;
+    lastUsedAt?: string;
+}
+
+/** A new personal access token; token is only ever shown this once */
+export interface CreatedPersonalAccessToken extends PersonalAccessToken {
+    token: string;
+}
+
+/** A login session of the current user (GET /auth/sessions) */
+export interface AuthSession {
+    id: string;
+    userId: string;
+    userAgent: string;
+    ipAddress: string;
+    createdAt: string;
+    lastUsedAt?: string;
+    expiresAt: string;
+    current: boolean;
 }
@@ -7,6 +7,7 @@ import { TypingEngineService } from '../../services/typing-engine.service';
 import { UserService } from '../../services/user.service';
 import { I18nService } from '../../services/i18n.service';
 import { Lesson } from '../../models/lesson.model';
+import { StartSessionResponse } from '../../models/typing.model';
 import { User } from '../../models/user.model';
 import { TypingEditorComponent } from '../../components/typing-editor/typing-editor.component';
 import { ShareComponent } from '../../components/share/share.component';
@@ -309,6 +310,7 @@ export class LessonComponent implements OnInit {
     incorrectChars: 0,
   };
   pointsEarned = 0;
+  private session: StartSessionResponse | null = null;
 
   ngOnInit(): void {
     const id = this.route.snapshot.paramMap.get('id');
@@ -333,6 +335,17 @@ export class LessonComponent implements OnInit {
 
   startTyping(): void {
     this.showEditor = true;
+    this.startSession();
+  }
+
+  /** Ask the API for a typing session so the attempt can earn points */
+  private startSession(): void {
+    this.session = null;
+    if (!this.lesson) return;
+    this.progressService.startSession(this.lesson.id).subscribe({
+      next: (res) => (this.session = res),
+      error: (err) => console.error('Failed to start session:', err),
+    });
   }
 
   onCompleted(): void {
@@ -346,7 +359,24 @@ export class LessonComponent implements OnInit {
       if (this.lesson) {
         const userId = this.userService.getUserId();
 
-        // Save progress
+        // The API replays the keystrokes and records metrics, progress and points
+        if (this.session) {
+          const { session, token } = this.session;
+          this.session = null;
+          this.progressService
+            .finishSession(session.id, token, this.typingEngine.getKeystrokeLog())
+            .subscribe({
+              next: (res) => {
+                this.pointsEarned = res.pointsEarned || 0;
+                // Refresh user data to update streak
+                this.userService.initializeUser();
+              },
+              error: (err) => console.error('Failed to finish session:', err),
+            });
+          return;
+        }
+
+        // Without a session, save unverified results (no points)
         this.progressService
           .saveProgress({
             userId,
@@ -359,17 +389,12 @@ export class LessonComponent implements OnInit {
             error: (err) => console.error('Failed to save progress:', err),
           });
 
-        // Save metrics
         const metricsReq = this.typingEngine.buildMetricsRequest(
           userId,
           this.lesson.id
         );
         this.progressService.saveMetrics(metricsReq).subscribe({
-          next: (res: any) => {
-            this.pointsEarned = res.pointsEarned || 0;
-            // Refresh user data to update streak
-            this.userService.initializeUser();
-          },
+          next: () => this.userService.initializeUser(),
           error: (err) => console.error('Failed to save metrics:', err),
         });
       }
@@ -384,6 +409,7 @@ export class LessonComponent implements OnInit {
     // Small delay to re-render
     setTimeout(() => {
       this.showEditor = true;
+      this.startSession();
     }, 50);
   }
 }
@@ -0,0 +1,132 @@
+import { Component, OnInit, inject } from '@angular/core';
+import { CommonModule } from '@angular/common';
+import { FormsModule } from '@angular/forms';
+import { ActivatedRoute, Router, RouterLink } from '@angular/router';
+import { UserService } from '../../services/user.service';
+import { I18nService } from '../../services/i18n.service';
+
+@Component({
+  selector: 'app-oauth-callback',
+  standalone: true,
+  imports: [CommonModule, FormsModule, RouterLink],
+  template: `
+    <div class="oauth container">
+      <div class="oauth__card card">
+        <h1>{{ i18n.t('oauth.title') }}</h1>
+
+        @if (checking) {
+          <p>{{ i18n.t('oauth.checking') }}</p>
+        } @else if (mfaToken) {
+          <form class="oauth__form" (ngSubmit)="verify()">
+            <p>{{ i18n.t('oauth.mfaPrompt') }}</p>
+            <input type="text" [(ngModel)]="code" name="code" autocomplete="one-time-code" required />
+            @if (error) {
+              <p class="oauth__error">{{ error }}</p>
+            }
+            <button type="submit" class="btn btn--primary" [disabled]="!code">{{ i18n.t('oauth.verify') }}</button>
+          </form>
+        } @else if (linked) {
+          <p class="oauth__success">{{ i18n.t('oauth.linked', { provider: linked }) }}</p>
+        } @else {
+          <p class="oauth__error">{{ error }}</p>
+        }
+
+        @if (!checking && !mfaToken) {
+          <a [routerLink]="redirect" class="btn btn--primary">{{ i18n.t('common.back') }}</a>
+        }
+      </div>
+    </div>
+  `,
+  styles: [
+    `
+      .oauth {
+        padding: 2rem 1.5rem;
+        max-width: 480px;
+
+        h1 {
+          font-size: 1.5rem;
+          margin-bottom: 1.5rem;
+          text-align: center;
+        }
+      }
+
+      .oauth__card,
+      .oauth__form {
+        display: flex;
+        flex-direction: column;
+        align-items: center;
+        gap: 1rem;
+      }
+
+      .oauth__success {
+        color: var(--accent-success);
+      }
+
+      .oauth__error {
+        color: var(--accent-error);
+      }
+    `,
+  ],
+})
+export class OAuthCallbackComponent implements OnInit {
+  private route = inject(ActivatedRoute);
+  private router = inject(Router);
+  private userService = inject(UserService);
+  i18n = inject(I18nService);
+
+  checking = true;
+  mfaToken = '';
+  code = '';
+  linked = '';
+  error = '';
+  redirect = '/';
+
+  async ngOnInit(): Promise<void> {
+    const params = this.route.snapshot.queryParamMap;
+    const redirect = params.get('redirect');
+    // The API only passes on paths within this app
+    if (redirect?.startsWith('/') && !redirect.startsWith('//')) {
+      this.redirect = redirect;
+    }
+
+    const error = params.get('error');
+    if (error) {
+      this.error = error;
+      this.checking = false;
+      return;
+    }
+
+    // Accounts with two-factor authentication still need a code
+    this.mfaToken = params.get('mfaToken') ?? '';
+    if (this.mfaToken) {
+      this.checking = false;
+      return;
+    }
+
+    try {
+      await this.userService.reloadCurrentUser();
+      this.linked = params.get('linked') ?? '';
+      if (!this.linked) {
+        await this.router.navigateByUrl(this.redirect);
+      }
+    } catch {
+      this.error = this.i18n.t('oauth.failed');
+    } finally {
+      this.checking = false;
+    }
+  }
+
+  async verify(): Promise<void> {
+    // Recovery codes look like xxxxx-xxxxx, TOTP codes are digits only
+    const useRecoveryCode = !/^\d+$/.test(this.code.trim());
+    try {
+      await this.userService.verifyMfa(this.mfaToken, this.code.trim(), useRecoveryCode);
+      await this.router.navigateByUrl(this.redirect);
+    } catch (err: any) {
+      this.error = err.error?.error || this.i18n.t('oauth.failed');
+      if (err.error?.error === 'Invalid or expired MFA challenge') {
+        this.mfaToken = '';
+      }
+    }
+  }
+}
@@ -0,0 +1,129 @@
+import { Component, OnInit, inject } from '@angular/core';
+import { CommonModule } from '@angular/common';
+import { FormsModule } from '@angular/forms';
+import { ActivatedRoute, RouterLink } from '@angular/router';
+import { UserService } from '../../services/user.service';
+import { I18nService } from '../../services/i18n.service';
+
+@Component({
+  selector: 'app-reset-password',
+  standalone: true,
+  imports: [CommonModule, FormsModule, RouterLink],
+  template: `
+    <div class="reset container">
+      <div class="reset__card card">
+        <h1>{{ i18n.t('reset.title') }}</h1>
+
+        @if (done) {
+          <p class="reset__success">{{ i18n.t('reset.done') }}</p>
+          <a routerLink="/" class="btn btn--primary">{{ i18n.t('common.back') }}</a>
+        } @else if (!token) {
+          <p class="reset__error">{{ i18n.t('reset.missingToken') }}</p>
+          <a routerLink="/" class="btn">{{ i18n.t('common.back') }}</a>
+        } @else {
+          <form (ngSubmit)="onSubmit()">
+            <label for="password">{{ i18n.t('reset.password') }}</label>
+            <input type="password" id="password" name="password" [(ngModel)]="password"
+              [disabled]="saving" required />
+
+            <label for="confirm">{{ i18n.t('reset.confirm') }}</label>
+            <input type="password" id="confirm" name="confirm" [(ngModel)]="confirm"
+              [disabled]="saving" required />
+
+            @if (error) {
+              <p class="reset__error">{{ error }}</p>
+            }
+
+            <button type="submit" class="btn btn--primary" [disabled]="saving">
+              {{ saving ? i18n.t('reset.saving') : i18n.t('reset.submit') }}
+            </button>
+          </form>
+        }
+      </div>
+    </div>
+  `,
+  styles: [
+    `
+      .reset {
+        padding: 2rem 1.5rem;
+        max-width: 480px;
+
+        h1 {
+          font-size: 1.5rem;
+          margin-bottom: 1.5rem;
+          text-align: center;
+        }
+      }
+
+      form {
+        display: flex;
+        flex-direction: column;
+        gap: 0.5rem;
+      }
+
+      label {
+        font-size: 0.875rem;
+        color: var(--text-secondary);
+      }
+
+      input {
+        padding: 0.625rem 0.75rem;
+        margin-bottom: 0.75rem;
+        border: 1px solid var(--border-color);
+        border-radius: var(--border-radius);
+        background: var(--bg-tertiary);
+        color: var(--text-primary);
+        font-family: var(--font-ui);
+      }
+
+      .btn {
+        justify-content: center;
+      }
+
+      .reset__success {
+        color: var(--accent-success);
+        margin-bottom: 1.5rem;
+      }
+
+      .reset__error {
+        color: var(--accent-error);
+        margin-bottom: 1rem;
+      }
+    `,
+  ],
+})
+export class ResetPasswordComponent implements OnInit {
+  private route = inject(ActivatedRoute);
+  private userService = inject(UserService);
+  i18n = inject(I18nService);
+
+  token = '';
+  password = '';
+  confirm = '';
+  error = '';
+  saving = false;
+  done = false;
+
+  ngOnInit(): void {
+    this.token = this.route.snapshot.queryParamMap.get('token') ?? '';
+  }
+
+  async onSubmit(): Promise<void> {
+    if (this.password !== this.confirm) {
+      this.error = this.i18n.t('reset.mismatch');
+      return;
+    }
+
+    this.saving = true;
+    this.error = '';
+
+    try {
+      await this.userService.resetPassword(this.token, this.password);
+      this.done = true;
+    } catch (err: any) {
+      this.error = err.error?.error || this.i18n.t('reset.failed');
+    } finally {
+      this.saving = false;
+    }
+  }
+}
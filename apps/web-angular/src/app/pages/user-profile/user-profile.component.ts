@@ -4,6 +4,7 @@ import { ActivatedRoute, Router, RouterLink } from '@angular/router';
 import { UserService } from '../../services/user.service';
 import { I18nService } from '../../services/i18n.service';
 import { UserProfile } from '../../models/user-profile.model';
+import { Badge } from '../../models/leaderboard.model';
 import { catchError, of } from 'rxjs';
 
 @Component({
@@ -51,6 +52,9 @@ import { catchError, of } from 'rxjs';
                     <path d="M12 0c-6.626 0-12 5.373-12 12 0 5.302 3.438 9.8 8.207 11.387.599.111.793-.261.793-.577v-2.234c-3.338.726-4.033-1.416-4.033-1.416-.546-1.387-1.333-1.756-1.333-1.756-1.089-.745.083-.729.083-.729 1.205.084 1.839 1.237 1.839 1.237 1.07 1.834 2.807 1.304 3.492.997.107-.775.418-1.305.762-1.604-2.665-.305-5.467-1.334-5.467-5.931 0-1.311.469-2.381 1.236-3.221-.124-.303-.535-1.524.117-3.176 0 0 1.008-.322 3.301 1.23.957-.266 1.983-.399 3.003-.404 1.02.005 2.047.138 3.006.404 2.291-1.552 3.297-1.23 3.297-1.23.653 1.653.242 2.874.118 3.176.77.84 1.235 1.911 1.235 3.221 0 4.609-2.807 5.624-5.479 5.921.43.372.823 1.102.823 2.222v3.293c0 .319.192.694.801.576 4.765-1.589 8.199-6.086 8.199-11.386 0-6.627-5.373-12-12-12z"/>
                   </svg>
                   {{ profile()!.user.githubUsername }}
+                  @if (profile()!.user.githubVerified) {
+                    <span class="github-verified" title="Verified with a GitHub login">✔</span>
+                  }
                 </a>
               }
             </div>
@@ -107,15 +111,40 @@ import { catchError, of } from 'rxjs';
             }
           </div>
 
+          <!-- Tier Section -->
+          @if (profile()!.user.tier; as tier) {
+            <div class="tier-section">
+              <h2>{{ i18n.t('user.tier') }}: {{ tier.tier }}</h2>
+              <div class="tier-bar">
+                <div class="tier-bar-fill" [style.width.%]="tier.progress * 100"></div>
+              </div>
+              <p class="tier-next">
+                @if (tier.nextTier) {
+                  {{ i18n.t('user.tierNext', { points: (tier.pointsToNext | number) ?? '', tier: tier.nextTier }) }}
+                } @else {
+                  {{ i18n.t('user.tierTop') }}
+                }
+              </p>
+              @if (profile()!.tierEvents.length > 0) {
+                <ul class="tier-events">
+                  @for (event of profile()!.tierEvents; track event.id) {
+                    <li>{{ event.tier }} · {{ formatDate(event.createdAt) }}</li>
+                  }
+                </ul>
+              }
+            </div>
+          }
+
           <!-- Badges Section -->
           @if ((profile()!.user.badges?.length ?? 0) > 0) {
             <div class="badges-section">
               <h2>{{ i18n.t('user.badges') || 'Badges' }}</h2>
               <div class="badges-grid">
                 @for (badge of profile()!.user.badges!; track badge.badge.id) {
-                  <div class="badge-card" [style.border-color]="badge.badge.color">
+                  <div class="badge-card" [style.border-color]="badge.badge.color" [title]="badgeDescription(badge.badge)">
                     <div class="badge-icon" [style.background-color]="badge.badge.color">🏅</div>
                     <div class="badge-name">{{ badge.badge.name }}</div>
+                    <div class="badge-rarity">{{ i18n.t('badge.rarity.' + badge.badge.rarity) }}</div>
                     <div class="badge-date">{{ formatDate(badge.assignedAt) }}</div>
                   </div>
                 }
@@ -270,6 +299,10 @@ import { catchError, of } from 'rxjs';
       height: 16px;
     }
 
+    .github-verified {
+      color: var(--accent-success);
+    }
+
     .user-info {
       flex: 1;
     }
@@ -349,7 +382,7 @@ import { catchError, of } from 'rxjs';
       margin-top: 0.25rem;
     }
 
-    .badges-section, .progress-section {
+    .badges-section, .progress-section, .tier-section {
       background: var(--bg-card);
       border-radius: 16px;
       padding: 1.5rem;
@@ -357,12 +390,47 @@ import { catchError, of } from 'rxjs';
       margin-bottom: 1.5rem;
     }
 
-    .badges-section h2, .progress-section h2 {
+    .badges-section h2, .progress-section h2, .tier-section h2 {
       margin: 0 0 1rem;
       font-size: 1.25rem;
       color: var(--text-primary);
     }
 
+    .tier-bar {
+      height: 10px;
+      border-radius: 5px;
+      background: var(--bg-tertiary);
+      overflow: hidden;
+    }
+
+    .tier-bar-fill {
+      height: 100%;
+      background: linear-gradient(90deg, var(--accent-primary) 0%, var(--accent-success) 100%);
+    }
+
+    .tier-next {
+      margin: 0.5rem 0 0;
+      font-size: 0.85rem;
+      color: var(--text-secondary);
+    }
+
+    .tier-events {
+      list-style: none;
+      margin: 1rem 0 0;
+      padding: 0;
+      display: flex;
+      flex-wrap: wrap;
+      gap: 0.5rem;
+      font-size: 0.8rem;
+      color: var(--text-secondary);
+    }
+
+    .tier-events li {
+      background: var(--bg-tertiary);
+      border-radius: 8px;
+      padding: 0.25rem 0.75rem;
+    }
+
     .badges-grid {
       display: grid;
       grid-template-columns: repeat(auto-fill, minmax(150px, 1fr));
@@ -394,6 +462,13 @@ import { catchError, of } from 'rxjs';
       color: var(--text-primary);
     }
 
+    .badge-rarity {
+      font-size: 0.7rem;
+      text-transform: uppercase;
+      letter-spacing: 0.05em;
+      color: var(--text-secondary);
+    }
+
     .badge-date {
       font-size: 0.75rem;
       color: var(--text-secondary);
@@ -511,6 +586,14 @@ export class UserProfileComponent implements OnInit {
     return user.displayName.slice(0, 2).toUpperCase();
   }
 
+  /** The badge description and how it is earned, in the current language */
+  badgeDescription(badge: Badge): string {
+    const en = this.i18n.getLocale() === 'en';
+    const description = (en && badge.description_en) || badge.description || '';
+    const criteria = (en && badge.criteria_en) || badge.criteria || '';
+    return [description, criteria].filter(Boolean).join(' — ');
+  }
+
   formatDate(dateString: string | Date): string {
     const date = new Date(dateString);
     return date.toLocaleDateString(undefined, { year: 'numeric', month: 'short', day: 'numeric' });
@@ -0,0 +1,108 @@
+import { Component, OnInit, inject } from '@angular/core';
+import { CommonModule } from '@angular/common';
+import { ActivatedRoute, RouterLink } from '@angular/router';
+import { UserService } from '../../services/user.service';
+import { I18nService } from '../../services/i18n.service';
+
+@Component({
+  selector: 'app-verify-email',
+  standalone: true,
+  imports: [CommonModule, RouterLink],
+  template: `
+    <div class="verify container">
+      <div class="verify__card card">
+        <h1>{{ i18n.t('verify.title') }}</h1>
+
+        @if (checking) {
+          <p>{{ i18n.t('verify.checking') }}</p>
+        } @else if (done) {
+          <p class="verify__success">{{ i18n.t('verify.done') }}</p>
+        } @else {
+          <p class="verify__error">{{ error }}</p>
+          @if (canResend) {
+            @if (resent) {
+              <p>{{ i18n.t('verify.resent') }}</p>
+            } @else {
+              <button class="btn" (click)="resend()">{{ i18n.t('verify.resend') }}</button>
+            }
+          }
+        }
+
+        @if (!checking) {
+          <a routerLink="/" class="btn btn--primary">{{ i18n.t('common.back') }}</a>
+        }
+      </div>
+    </div>
+  `,
+  styles: [
+    `
+      .verify {
+        padding: 2rem 1.5rem;
+        max-width: 480px;
+
+        h1 {
+          font-size: 1.5rem;
+          margin-bottom: 1.5rem;
+          text-align: center;
+        }
+      }
+
+      .verify__card {
+        display: flex;
+        flex-direction: column;
+        align-items: center;
+        gap: 1rem;
+      }
+
+      .verify__success {
+        color: var(--accent-success);
+      }
+
+      .verify__error {
+        color: var(--accent-error);
+      }
+    `,
+  ],
+})
+export class VerifyEmailComponent implements OnInit {
+  private route = inject(ActivatedRoute);
+  private userService = inject(UserService);
+  i18n = inject(I18nService);
+
+  checking = true;
+  done = false;
+  resent = false;
+  error = '';
+
+  get canResend(): boolean {
+    const user = this.userService.getCurrentUser();
+    return !!user && !user.isGuest && !!user.email && !user.emailVerifiedAt;
+  }
+
+  async ngOnInit(): Promise<void> {
+    const token = this.route.snapshot.queryParamMap.get('token');
+    if (!token) {
+      this.error = this.i18n.t('verify.missingToken');
+      this.checking = false;
+      return;
+    }
+
+    try {
+      await this.userService.verifyEmail(token);
+      this.done = true;
+    } catch (err: any) {
+      this.error = err.error?.error || this.i18n.t('verify.failed');
+    } finally {
+      this.checking = false;
+    }
+  }
+
+  async resend(): Promise<void> {
+    try {
+      await this.userService.resendVerificationEmail();
+      this.resent = true;
+    } catch (err: any) {
+      this.error = err.error?.error || this.i18n.t('verify.failed');
+    }
+  }
+}
@@ -163,6 +163,44 @@ const translations: Record<Locale, TranslationDict> = {
     'user.recentProgress': 'Progreso Reciente',
     'user.memberSince': 'Miembro desde',
     'user.guest': 'Invitado',
+    'user.tier': 'Rango',
+    'user.tierNext': 'Faltan {{points}} puntos para {{tier}}',
+    'user.tierTop': 'Rango más alto alcanzado',
+
+    // ── Reset Password ──
+    'reset.title': 'Restablecer contraseña',
+    'reset.password': 'Nueva contraseña',
+    'reset.confirm': 'Repite la contraseña',
+    'reset.submit': 'Guardar contraseña',
+    'reset.saving': 'Guardando...',
+    'reset.mismatch': 'Las contraseñas no coinciden',
+    'reset.missingToken': 'El enlace no es válido. Solicita uno nuevo.',
+    'reset.failed': 'El enlace no es válido o ha caducado',
+    'reset.done': 'Tu contraseña se ha cambiado. Ya puedes iniciar sesión.',
+
+    // ── Verify Email ──
+    'verify.title': 'Verificar email',
+    'verify.checking': 'Verificando tu email...',
+    'verify.done': 'Tu email está verificado.',
+    'verify.failed': 'El enlace no es válido o ha caducado',
+    'verify.missingToken': 'El enlace no es válido.',
+    'verify.resend': 'Enviar un nuevo enlace',
+    'verify.resent': 'Te hemos enviado un nuevo enlace.',
+
+    // ── OAuth login ──
+    'oauth.title': 'Iniciar sesión',
+    'oauth.checking': 'Completando el inicio de sesión...',
+    'oauth.linked': 'Cuenta de {{provider}} vinculada.',
+    'oauth.failed': 'No se pudo iniciar sesión',
+    'oauth.mfaPrompt': 'Introduce el código de tu app de autenticación o un código de recuperación.',
+    'oauth.verify': 'Verificar',
+
+    // ── Badges ──
+    'badge.rarity.common': 'Común',
+    'badge.rarity.uncommon': 'Poco común',
+    'badge.rarity.rare': 'Rara',
+    'badge.rarity.epic': 'Épica',
+    'badge.rarity.legendary': 'Legendaria',
 
     // ── Common ──
     'common.back': 'Volver',
@@ -327,6 +365,44 @@ const translations: Record<Locale, TranslationDict> = {
     'user.recentProgress': 'Recent Progress',
     'user.memberSince': 'Member since',
     'user.guest': 'Guest',
+    'user.tier': 'Tier',
+    'user.tierNext': '{{points}} points to {{tier}}',
+    'user.tierTop': 'Top tier reached',
+
+    // ── Reset Password ──
+    'reset.title': 'Reset password',
+    'reset.password': 'New password',
+    'reset.confirm': 'Repeat the password',
+    'reset.submit': 'Save password',
+    'reset.saving': 'Saving...',
+    'reset.mismatch': 'The passwords do not match',
+    'reset.missingToken': 'This link is not valid. Request a new one.',
+    'reset.failed': 'The link is invalid or has expired',
+    'reset.done': 'Your password has been changed. You can log in now.',
+
+    // ── Verify Email ──
+    'verify.title': 'Verify email',
+    'verify.checking': 'Verifying your email...',
+    'verify.done': 'Your email is verified.',
+    'verify.failed': 'The link is invalid or has expired',
+    'verify.missingToken': 'This link is not valid.',
+    'verify.resend': 'Send a new link',
+    'verify.resent': 'We sent you a new link.',
+
+    // ── OAuth login ──
+    'oauth.title': 'Log in',
+    'oauth.checking': 'Finishing your login...',
+    'oauth.linked': 'Your {{provider}} account is linked.',
+    'oauth.failed': 'Could not log you in',
+    'oauth.mfaPrompt': 'Enter the code from your authenticator app or a recovery code.',
+    'oauth.verify': 'Verify',
+
+    // ── Badges ──
+    'badge.rarity.common': 'Common',
+    'badge.rarity.uncommon': 'Uncommon',
+    'badge.rarity.rare': 'Rare',
+    'badge.rarity.epic': 'Epic',
+    'badge.rarity.legendary': 'Legendary',
 
     // ── Common ──
     'common.back': 'Back',
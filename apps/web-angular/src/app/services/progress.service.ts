@@ -2,7 +2,14 @@ import { Injectable, inject } from '@angular/core';
 import { HttpClient } from '@angular/common/http';
 import { Observable } from 'rxjs';
 import { Progress, ProgressRequest } from '../models/progress.model';
-import { MetricsRequest, TypingMetrics, UserMetricsSummary } from '../models/typing.model';
+import {
+  FinishSessionResponse,
+  KeystrokeLog,
+  MetricsRequest,
+  StartSessionResponse,
+  TypingMetrics,
+  UserMetricsSummary,
+} from '../models/typing.model';
 import { environment } from '../../environments/environment';
 
 @Injectable({ providedIn: 'root' })
@@ -28,6 +35,24 @@ export class ProgressService {
     return this.http.post<TypingMetrics>(`${this.apiUrl}/metrics`, req);
   }
 
+  /** Start a server-verified typing session; only finished sessions earn points */
+  startSession(lessonId: string): Observable<StartSessionResponse> {
+    return this.http.post<StartSessionResponse>(`${this.apiUrl}/sessions`, {
+      lessonId,
+    });
+  }
+
+  finishSession(
+    sessionId: string,
+    token: string,
+    keystrokes: KeystrokeLog
+  ): Observable<FinishSessionResponse> {
+    return this.http.post<FinishSessionResponse>(
+      `${this.apiUrl}/sessions/${sessionId}/finish`,
+      { token, keystrokes }
+    );
+  }
+
   getUserMetrics(userId: string): Observable<UserMetricsSummary> {
     return this.http.get<UserMetricsSummary>(
       `${this.apiUrl}/metrics/${userId}`
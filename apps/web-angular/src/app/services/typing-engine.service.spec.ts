@@ -0,0 +1,80 @@
+import { TestBed } from '@angular/core/testing';
+import { ErrorEntry, KeystrokeLog } from '../models/typing.model';
+import { TypingEngineService } from './typing-engine.service';
+import fixtures from '../../../../../packages/engine-fixtures/fixtures.json';
+
+/** A golden case shared with the API's Go engine (packages/engine-fixtures) */
+interface Fixture {
+  name: string;
+  lesson: { code: string; mode?: 'strict' | 'practice'; exclude?: string[] };
+  keystrokes: KeystrokeLog;
+  expected: {
+    text: string;
+    hidden: string;
+    currentIndex: number;
+    finished: boolean;
+    metrics: {
+      wpm: number;
+      accuracy: number;
+      totalTime: number;
+      totalChars: number;
+      correctChars: number;
+      incorrectChars: number;
+      totalKeystrokes: number;
+      commonErrors: ErrorEntry[];
+    };
+  };
+}
+
+/** Maps a key from a keystroke log to the KeyboardEvent the browser sends */
+function keyEvent(key: string): KeyboardEvent {
+  switch (key) {
+    case '\b':
+      return new KeyboardEvent('keydown', { key: 'Backspace' });
+    case '\n':
+      return new KeyboardEvent('keydown', { key: 'Enter' });
+    case '\t':
+      return new KeyboardEvent('keydown', { key: 'Tab' });
+    default:
+      return new KeyboardEvent('keydown', { key });
+  }
+}
+
+describe('TypingEngineService fixtures', () => {
+  let engine: TypingEngineService;
+
+  beforeEach(() => {
+    TestBed.configureTestingModule({});
+    engine = TestBed.inject(TypingEngineService);
+    jasmine.clock().install();
+    jasmine.clock().mockDate(new Date('2024-01-01T00:00:00Z'));
+  });
+
+  afterEach(() => {
+    jasmine.clock().uninstall();
+  });
+
+  for (const fixture of fixtures as Fixture[]) {
+    it(fixture.name, () => {
+      const { keys, times } = fixture.keystrokes;
+      const keyList = Array.from(keys);
+      expect(keyList.length).withContext('keys and times').toBe(times.length);
+
+      engine.init(fixture.lesson);
+      keyList.forEach((key, i) => {
+        // Advancing the clock also settles strict-mode error flashes
+        jasmine.clock().tick(times[i]);
+        engine.handleKey(keyEvent(key));
+      });
+
+      const state = engine.state$.value;
+      const { commonErrors, ...metrics } = fixture.expected.metrics;
+      expect(state.chars.map((c) => c.char).join('')).withContext('text').toBe(fixture.expected.text);
+      expect(state.chars.map((c) => (c.isHidden ? '1' : '0')).join('')).withContext('hidden').toBe(fixture.expected.hidden);
+      expect(state.currentIndex).withContext('currentIndex').toBe(fixture.expected.currentIndex);
+      expect(state.finished).withContext('finished').toBe(fixture.expected.finished);
+      expect(engine.getMetrics()).withContext('metrics').toEqual(metrics);
+      expect(engine.buildMetricsRequest('', '').commonErrors).withContext('commonErrors').toEqual(commonErrors);
+    });
+  }
+});
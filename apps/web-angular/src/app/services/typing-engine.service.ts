@@ -4,9 +4,15 @@ import {
   TypingState,
   CharState,
   ErrorEntry,
+  KeystrokeLog,
   MetricsRequest,
 } from '../models/typing.model';
 
+/**
+ * Typing rules are mirrored by the API (apps/api-go/internal/engine) to verify
+ * sessions; keep packages/engine-fixtures passing when changing them
+ * (typing-engine.service.spec.ts runs them).
+ */
 @Injectable({ providedIn: 'root' })
 export class TypingEngineService {
   private state!: TypingState;
@@ -18,6 +24,11 @@ export class TypingEngineService {
   readonly finished$ = new BehaviorSubject<boolean>(false);
   totalErrorAttempts = 0;
 
+  /** Raw keys fed to the engine, replayed by the API to verify a session */
+  private keyLog: string[] = [];
+  private keyTimes: number[] = [];
+  private lastKeyAt: number | null = null;
+
   /** Initialize the engine with a code snippet */
   init(lesson: { code: string; mode?: 'strict' | 'practice'; exclude?: string[] }): void {
     this.lastLesson = lesson;
@@ -103,6 +114,9 @@ export class TypingEngineService {
     };
 
     this.totalErrorAttempts = 0;
+    this.keyLog = [];
+    this.keyTimes = [];
+    this.lastKeyAt = null;
     this.finished$.next(false);
     this.emit();
   }
@@ -167,6 +181,7 @@ export class TypingEngineService {
   /** Process a single character */
   private processChar(typed: string): void {
     if (this.state.currentIndex >= this.state.chars.length) return;
+    this.recordKey(typed);
 
     // Start timer on first keypress
     if (!this.state.started) {
@@ -223,6 +238,7 @@ export class TypingEngineService {
 
   /** Handle backspace */
   private processBackspace(): void {
+    this.recordKey('\b');
     if (this.state.currentIndex <= 0) return;
 
     // Only allow backspace in practice mode or when current char is incorrect
@@ -291,6 +307,18 @@ export class TypingEngineService {
     };
   }
 
+  /** Keystroke log for the current attempt, in the API's compact format */
+  getKeystrokeLog(): KeystrokeLog {
+    return { keys: this.keyLog.join(''), times: [...this.keyTimes] };
+  }
+
+  private recordKey(key: string): void {
+    const now = Date.now();
+    this.keyLog.push(key);
+    this.keyTimes.push(this.lastKeyAt === null ? 0 : now - this.lastKeyAt);
+    this.lastKeyAt = now;
+  }
+
   /** Get current WPM (live) */
   getLiveWPM(): number {
     if (!this.state.started || !this.state.startTime) return 0;
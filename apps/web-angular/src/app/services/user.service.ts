@@ -2,7 +2,7 @@ import { Injectable } from '@angular/core';
 import { HttpClient } from '@angular/common/http';
 import { BehaviorSubject, Observable, firstValueFrom } from 'rxjs';
 import { environment } from '../../environments/environment';
-import { User, RegisterRequest, LoginRequest, AuthResponse } from '../models/user.model';
+import { User, RegisterRequest, LoginRequest, AuthResponse, AuthSession, MFAChallenge, MFAStatus, TOTPEnrollment, OAuthProvider, UserIdentity, PersonalAccessToken, CreatedPersonalAccessToken, TokenScope } from '../models/user.model';
 import { UserProfile } from '../models/user-profile.model';
 
 @Injectable({ providedIn: 'root' })
@@ -71,10 +71,27 @@ export class UserService {
   /**
    * Login with username and password
    */
-  async login(username: string, password: string): Promise<User> {
+  async login(username: string, password: string): Promise<User | MFAChallenge> {
     const request: LoginRequest = { username, password };
     const response = await firstValueFrom(
-      this.http.post<AuthResponse>(`${environment.apiUrl}/auth/login`, request)
+      this.http.post<AuthResponse | MFAChallenge>(`${environment.apiUrl}/auth/login`, request)
+    );
+    // Accounts with two-factor authentication finish logging in with verifyMfa
+    if ('mfaRequired' in response) {
+      return response;
+    }
+    this.currentUserSubject.next(response.user);
+    return response.user;
+  }
+
+  /**
+   * Finish a login that returned an MFA challenge, with a TOTP code or a
+   * recovery code
+   */
+  async verifyMfa(mfaToken: string, code: string, useRecoveryCode = false): Promise<User> {
+    const body = useRecoveryCode ? { mfaToken, recoveryCode: code } : { mfaToken, code };
+    const response = await firstValueFrom(
+      this.http.post<AuthResponse>(`${environment.apiUrl}/auth/mfa/verify`, body)
     );
     this.currentUserSubject.next(response.user);
     return response.user;
@@ -95,6 +112,165 @@ export class UserService {
     await this.createGuestUser();
   }
 
+  /**
+   * Verify an email address with the token from a verification email
+   */
+  async verifyEmail(token: string): Promise<User> {
+    const user = await firstValueFrom(
+      this.http.post<User>(`${environment.apiUrl}/auth/email/verify`, { token })
+    );
+    if (this.currentUserSubject.value?.id === user.id) {
+      this.currentUserSubject.next(user);
+    }
+    return user;
+  }
+
+  /**
+   * Send the current user a new verification email
+   */
+  async resendVerificationEmail(): Promise<void> {
+    await firstValueFrom(
+      this.http.post(`${environment.apiUrl}/auth/email/resend`, {})
+    );
+  }
+
+  /**
+   * Ask for a password reset email
+   */
+  async forgotPassword(email: string): Promise<void> {
+    await firstValueFrom(
+      this.http.post(`${environment.apiUrl}/auth/password/forgot`, { email })
+    );
+  }
+
+  /**
+   * Set a new password with the token from a reset email
+   */
+  async resetPassword(token: string, password: string): Promise<void> {
+    await firstValueFrom(
+      this.http.post(`${environment.apiUrl}/auth/password/reset`, { token, password })
+    );
+  }
+
+  /**
+   * Reload the current user, e.g. after the API logged them in through a
+   * redirect
+   */
+  async reloadCurrentUser(): Promise<User> {
+    const user = await firstValueFrom(
+      this.http.get<User>(`${environment.apiUrl}/auth/me`)
+    );
+    this.currentUserSubject.next(user);
+    return user;
+  }
+
+  /**
+   * Identity providers users can log in with
+   */
+  getOAuthProviders(): Observable<OAuthProvider[]> {
+    return this.http.get<OAuthProvider[]>(`${environment.apiUrl}/auth/oauth`);
+  }
+
+  /**
+   * URL the browser navigates to for logging in with a provider. Registered
+   * users link the provider to their account instead.
+   */
+  oauthLoginUrl(provider: string, redirect?: string): string {
+    const query = redirect ? `?redirect=${encodeURIComponent(redirect)}` : '';
+    return `${environment.apiUrl}/auth/oauth/${encodeURIComponent(provider)}${query}`;
+  }
+
+  /**
+   * Identity provider accounts linked to the current user
+   */
+  getIdentities(): Observable<UserIdentity[]> {
+    return this.http.get<UserIdentity[]>(`${environment.apiUrl}/auth/identities`);
+  }
+
+  /**
+   * Unlink an identity provider account
+   */
+  unlinkIdentity(provider: string): Observable<void> {
+    return this.http.delete<void>(`${environment.apiUrl}/auth/identities/${encodeURIComponent(provider)}`);
+  }
+
+  /**
+   * List the current user's personal access tokens
+   */
+  getPersonalTokens(): Observable<PersonalAccessToken[]> {
+    return this.http.get<PersonalAccessToken[]>(`${environment.apiUrl}/auth/tokens`);
+  }
+
+  /**
+   * Create a personal access token for scripts and editor plugins
+   */
+  createPersonalToken(name: string, scopes: TokenScope[], expiresInDays?: number): Observable<CreatedPersonalAccessToken> {
+    return this.http.post<CreatedPersonalAccessToken>(`${environment.apiUrl}/auth/tokens`, { name, scopes, expiresInDays });
+  }
+
+  /**
+   * Revoke a personal access token
+   */
+  revokePersonalToken(tokenId: string): Observable<void> {
+    return this.http.delete<void>(`${environment.apiUrl}/auth/tokens/${tokenId}`);
+  }
+
+  /**
+   * List the sessions the current user is logged in with
+   */
+  getSessions(): Observable<AuthSession[]> {
+    return this.http.get<AuthSession[]>(`${environment.apiUrl}/auth/sessions`);
+  }
+
+  /**
+   * Log out of one session
+   */
+  revokeSession(sessionId: string): Observable<void> {
+    return this.http.delete<void>(`${environment.apiUrl}/auth/sessions/${sessionId}`);
+  }
+
+  /**
+   * Log out everywhere except the current session
+   */
+  revokeOtherSessions(): Observable<{ revoked: number }> {
+    return this.http.delete<{ revoked: number }>(`${environment.apiUrl}/auth/sessions`);
+  }
+
+  /**
+   * Whether the current user can enable, or has enabled, two-factor authentication
+   */
+  getMfaStatus(): Observable<MFAStatus> {
+    return this.http.get<MFAStatus>(`${environment.apiUrl}/auth/mfa`);
+  }
+
+  /**
+   * Start TOTP enrollment; the provisioning URI is shown as a QR code
+   */
+  enrollTotp(): Observable<TOTPEnrollment> {
+    return this.http.post<TOTPEnrollment>(`${environment.apiUrl}/auth/mfa/totp`, {});
+  }
+
+  /**
+   * Enable TOTP with a first code from the authenticator app
+   */
+  confirmTotp(code: string): Observable<{ recoveryCodes: string[] }> {
+    return this.http.post<{ recoveryCodes: string[] }>(`${environment.apiUrl}/auth/mfa/totp/confirm`, { code });
+  }
+
+  /**
+   * Turn off TOTP with a current code
+   */
+  disableTotp(code: string): Observable<void> {
+    return this.http.post<void>(`${environment.apiUrl}/auth/mfa/totp/disable`, { code });
+  }
+
+  /**
+   * Replace the recovery codes, invalidating the old ones
+   */
+  regenerateRecoveryCodes(code: string): Observable<{ recoveryCodes: string[] }> {
+    return this.http.post<{ recoveryCodes: string[] }>(`${environment.apiUrl}/auth/mfa/recovery-codes`, { code });
+  }
+
   /**
    * Get current user (synchronous)
    */